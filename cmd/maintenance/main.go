@@ -0,0 +1,133 @@
+// Command maintenance runs one-off and periodic jobs against the HR database.
+//
+//	go run ./cmd/maintenance recompute-scores
+//	go run ./cmd/maintenance rescore-fraud
+//	go run ./cmd/maintenance analyze-sentiment [-all]
+//	go run ./cmd/maintenance evaluate-badges
+//	go run ./cmd/maintenance rebuild-points
+//	go run ./cmd/maintenance snapshot-leaderboards
+//	go run ./cmd/maintenance recompute-levels
+//	go run ./cmd/maintenance evaluate-achievements
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"githup.ahmedramadan.4cashier/internal/bootstrap"
+	"githup.ahmedramadan.4cashier/internal/models"
+	mylogger "githup.ahmedramadan.4cashier/internal/mylogger"
+	"githup.ahmedramadan.4cashier/internal/repos"
+	"githup.ahmedramadan.4cashier/internal/service"
+)
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: maintenance <job>")
+	fmt.Fprintln(os.Stderr, "jobs:")
+	fmt.Fprintln(os.Stderr, "  recompute-scores   recompute the weighted (Bayesian) score of every HR profile")
+	fmt.Fprintln(os.Stderr, "  rescore-fraud      recompute the fraud risk of recent rates (FRAUD_RESCORE_DAYS)")
+	fmt.Fprintln(os.Stderr, "  analyze-sentiment  analyze rates not yet analyzed with the current lexicons (-all: every rate)")
+	fmt.Fprintln(os.Stderr, "  evaluate-badges    re-evaluate the badges of every profile, then run any other queued badge jobs")
+	fmt.Fprintln(os.Stderr, "  rebuild-points     recompute the cached points balance of every employee from the points ledger")
+	fmt.Fprintln(os.Stderr, "  snapshot-leaderboards  snapshot every leaderboard now (all-time, this month, and last month if not final)")
+	fmt.Fprintln(os.Stderr, "  recompute-levels   re-evaluate the contributor level of every employee (after changing LEVEL_* thresholds)")
+	fmt.Fprintln(os.Stderr, "  evaluate-achievements  award every employee the achievements they reached, then recompute levels")
+	os.Exit(2)
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+
+	logger := mylogger.ConfigureLogger(mylogger.LogConfig{ConsoleLoggingEnabled: true})
+	ctx := context.Background()
+
+	db := bootstrap.InitDB()
+	defer db.Close()
+
+	hrRepo := repos.NewPosHRRepository(db, bootstrap.LoadScoringConfig())
+	notificationService := service.NewNotificationService(logger, repos.NewPosNotificationRepository(db))
+	auditTrail := service.NewAuditTrail(logger, repos.NewPosModerationRepository(db))
+	contributorLevels := service.NewContributorLevels(logger, hrRepo, bootstrap.LoadLevelConfig(), notificationService, nil)
+	achievements := service.NewAchievementService(logger, hrRepo, notificationService, contributorLevels)
+	hrService := service.NewHRService(logger, hrRepo, bootstrap.LoadRatePolicy(), bootstrap.LoadFraudConfig(), notificationService, auditTrail, nil,
+		contributorLevels, achievements)
+
+	switch os.Args[1] {
+	case "recompute-scores":
+		count, err := hrService.RecalculateAllScores(ctx)
+		if err != nil {
+			logger.Fatal().Err(err).Int("processed", count).Msg("recompute-scores failed")
+		}
+		logger.Info().Int("profiles", count).Msg("recompute-scores finished")
+	case "rescore-fraud":
+		count, err := hrService.RescoreRecentRates(ctx)
+		if err != nil {
+			logger.Fatal().Err(err).Int("processed", count).Msg("rescore-fraud failed")
+		}
+		logger.Info().Int("rates", count).Msg("rescore-fraud finished")
+	case "analyze-sentiment":
+		all := len(os.Args) > 2 && os.Args[2] == "-all"
+		count, err := hrService.ReanalyzeRates(ctx, all)
+		if err != nil {
+			logger.Fatal().Err(err).Int("processed", count).Msg("analyze-sentiment failed")
+		}
+		logger.Info().Int("rates", count).Bool("all", all).Msg("analyze-sentiment finished")
+	case "evaluate-badges":
+		job, err := hrService.QueueBadgeBackfill(ctx, models.BadgeTriggerManual)
+		if err != nil {
+			logger.Fatal().Err(err).Msg("evaluate-badges failed")
+		}
+		jobs := 0
+		for {
+			ran, err := hrService.RunNextBadgeJob(ctx)
+			if err != nil {
+				logger.Fatal().Err(err).Int("processed", jobs).Msg("evaluate-badges failed")
+			}
+			if !ran {
+				break
+			}
+			jobs++
+		}
+		if job, err = hrService.GetBadgeEvaluationJob(ctx, job.ID); err != nil {
+			logger.Fatal().Err(err).Msg("evaluate-badges failed")
+		}
+		logger.Info().Int64("jobID", job.ID).Str("status", job.Status).Int("profiles", job.ProfilesProcessed).
+			Int("awarded", job.BadgesAwarded).Int("jobs", jobs).Msg("evaluate-badges finished")
+	case "rebuild-points":
+		count, err := hrService.RebuildPointsBalances(ctx)
+		if err != nil {
+			logger.Fatal().Err(err).Msg("rebuild-points failed")
+		}
+		logger.Info().Int("fixed", count).Msg("rebuild-points finished")
+	case "snapshot-leaderboards":
+		leaderboards := service.NewLeaderboardService(logger, hrRepo, bootstrap.LoadLeaderboardConfig())
+		snapshots, err := leaderboards.TakeSnapshots(ctx, time.Now())
+		if err != nil {
+			logger.Fatal().Err(err).Msg("snapshot-leaderboards failed")
+		}
+		logger.Info().Int("snapshots", len(snapshots)).Msg("snapshot-leaderboards finished")
+	case "recompute-levels":
+		count, err := contributorLevels.RecomputeAll(ctx)
+		if err != nil {
+			logger.Fatal().Err(err).Int("changed", count).Msg("recompute-levels failed")
+		}
+		logger.Info().Int("changed", count).Msg("recompute-levels finished")
+	case "evaluate-achievements":
+		count, err := achievements.EvaluateAll(ctx)
+		if err != nil {
+			logger.Fatal().Err(err).Int("awarded", count).Msg("evaluate-achievements failed")
+		}
+		// نقاط الإنجازات ترفع المستويات
+		changed, err := contributorLevels.RecomputeAll(ctx)
+		if err != nil {
+			logger.Fatal().Err(err).Int("awarded", count).Msg("evaluate-achievements failed")
+		}
+		logger.Info().Int("awarded", count).Int("levelsChanged", changed).Msg("evaluate-achievements finished")
+	default:
+		usage()
+	}
+}
@@ -1,6 +1,7 @@
 package myfiber
 
 import (
+	"context"
 	"log"
 	"time"
 
@@ -15,8 +16,8 @@ import (
 	"githup.ahmedramadan.4cashier/internal/handler"
 )
 
-// setup fiber
-func SetupFiber(handlers Handlers, db *sqlx.DB) {
+// setup fiber; the server shuts down gracefully when ctx is cancelled
+func SetupFiber(ctx context.Context, handlers Handlers, db *sqlx.DB) {
 	app := fiber.New(fiber.Config{
 		AppName:       "HADEF Fiber App",
 		ReadTimeout:   10 * time.Second,
@@ -41,21 +42,114 @@ func SetupFiber(handlers Handlers, db *sqlx.DB) {
 
 	hrGroup := app.Group("/hr")
 
-	hrGroup.Get("/hr-profiles", handlers.HRHandler.GetHRProfiles)     // Get HR Profiles
-	hrGroup.Get("/rates", handlers.HRHandler.GetRates)                // Get HR rates
-	hrGroup.Post("/:id/experience", handlers.HRHandler.AddExperience) // Add experience to HR
-	hrGroup.Post("/:id/job-roles", handlers.HRHandler.AddJobRoles)    // Add job roles to HR
-	hrGroup.Post("/rate", handlers.HRHandler.RateHR)                  // Rate an HR profile
-	hrGroup.Post("/rate/like", handlers.HRHandler.LikeRate)           // Like a HR rate
-	hrGroup.Post("/badge", handlers.HRHandler.AwardBadge)             // Award a badge to HR
-	hrGroup.Post("/badge/like", handlers.HRHandler.LikeBadge)         // Like a badge for HR
-	hrGroup.Get("/:employee_id/stats", handlers.HRHandler.GetEmployeeStats)
+	hrGroup.Get("/hr-profiles", handlers.HRHandler.GetHRProfiles)                                                                              // Get HR Profiles
+	hrGroup.Get("/rates", handler.OptionalJWTMiddleware(), handlers.HRHandler.GetRates)                                                        // Get HR rates (anonymous reviewers hidden unless author/moderator)
+	hrGroup.Post("/:id/experience", handlers.HRHandler.AddExperience)                                                                          // Add experience to HR
+	hrGroup.Post("/:id/job-roles", handlers.HRHandler.AddJobRoles)                                                                             // Add job roles to HR
+	hrGroup.Post("/rate", handler.JWTAuthMiddleware(), handler.HasRolesMiddleware("employee"), handlers.HRHandler.RateHR)                      // Rate an HR profile
+	hrGroup.Post("/rate/like", handler.JWTAuthMiddleware(), handler.HasRolesMiddleware("employee"), handlers.HRHandler.LikeRate)               // Like or dislike a HR rate
+	hrGroup.Delete("/rate/:id/like", handler.JWTAuthMiddleware(), handler.HasRolesMiddleware("employee"), handlers.HRHandler.DeleteRateVote)   // Remove own like/dislike
+	hrGroup.Get("/rate/:id", handler.OptionalJWTMiddleware(), handlers.HRHandler.GetRate)                                                      // Get a single rate
+	hrGroup.Put("/rate/:id", handler.JWTAuthMiddleware(), handler.HasRolesMiddleware("employee"), handlers.HRHandler.UpdateRate)               // Edit own rate
+	hrGroup.Delete("/rate/:id", handler.JWTAuthMiddleware(), handler.HasRolesMiddleware("employee"), handlers.HRHandler.DeleteRate)            // Delete own rate
+	hrGroup.Get("/rate/:id/revisions", handler.JWTAuthMiddleware(), handlers.HRHandler.GetRateRevisions)                                       // Rate edit history
+	hrGroup.Put("/rate/:id/response", handler.JWTAuthMiddleware(), handler.HasRolesMiddleware("hr"), handlers.HRHandler.RespondToRate)         // HR response to a rate
+	hrGroup.Delete("/rate/:id/response", handler.JWTAuthMiddleware(), handler.HasRolesMiddleware("hr"), handlers.HRHandler.DeleteRateResponse) // Remove HR response
+	hrGroup.Post("/badge/like", handler.JWTAuthMiddleware(), handler.HasRolesMiddleware("employee"), handlers.HRHandler.LikeBadge)             // Like or dislike a badge
+	hrGroup.Get("/badge-definitions", handlers.HRHandler.GetBadgeDefinitions)                                                                  // Badge catalog (name, icon, tier)
+	hrGroup.Get("/rating-criteria", handlers.HRHandler.GetRatingCriteria)                                                                      // Active rating criteria
+	hrGroup.Get("/rating-contexts", handlers.HRHandler.GetRatingContexts)                                                                      // Active rating contexts (interview, onboarding...)
+	hrGroup.Get("/aspects", handlers.HRHandler.GetAspects)                                                                                     // Aspects detected in review text
+	hrGroup.Get("/aspects/:aspect/leaders", handlers.HRHandler.GetAspectLeaders)                                                               // HRs most praised (or ?order=criticized) for an aspect
+
+	// Proof-of-employment for rates
+	hrGroup.Post("/rate/:id/verification/email", handler.JWTAuthMiddleware(), handler.HasRolesMiddleware("employee"), handlers.VerificationHandler.StartEmailVerification)
+	hrGroup.Post("/rate/:id/verification/email/confirm", handler.JWTAuthMiddleware(), handler.HasRolesMiddleware("employee"), handlers.VerificationHandler.ConfirmEmailVerification)
+	hrGroup.Post("/rate/:id/verification/document", handler.JWTAuthMiddleware(), handler.HasRolesMiddleware("employee"), handlers.VerificationHandler.SubmitDocument)
+	hrGroup.Post("/rate/:id/verification/invite", handler.JWTAuthMiddleware(), handler.HasRolesMiddleware("employee"), handlers.VerificationHandler.RedeemInviteToken)
+	hrGroup.Post("/invite-tokens", handler.JWTAuthMiddleware(), handler.HasRolesMiddleware("hr"), handlers.VerificationHandler.CreateInviteToken)
+	hrGroup.Get("/invite-tokens", handler.JWTAuthMiddleware(), handler.HasRolesMiddleware("hr"), handlers.VerificationHandler.GetInviteTokens)
+	hrGroup.Delete("/invite-tokens/:id", handler.JWTAuthMiddleware(), handler.HasRolesMiddleware("hr"), handlers.VerificationHandler.RevokeInviteToken)
+
+	hrGroup.Get("/:id/ratings/summary", handlers.HRHandler.GetRatingSummary)                         // Star distribution, monthly trend, last 90 days vs lifetime
+	hrGroup.Get("/:id/badges", handler.OptionalJWTMiddleware(), handlers.HRHandler.GetProfileBadges) // Active badges and history, with counters and own vote
+	hrGroup.Get("/:employee_id/stats", handler.OptionalJWTMiddleware(), handlers.HRHandler.GetEmployeeStats)
+	hrGroup.Get("/:id", handlers.HRHandler.GetHRProfile) // HR profile detail with criteria breakdown
+
+	moderation := app.Group("/moderation", handler.JWTAuthMiddleware(), handler.HasRolesMiddleware("admin", "moderator"))
+	moderation.Get("/verifications", handlers.VerificationHandler.GetVerifications)
+	moderation.Get("/verifications/:id/document", handlers.VerificationHandler.GetVerificationDocument)
+	moderation.Post("/verifications/:id/decision", handlers.VerificationHandler.ReviewDocument)
+	moderation.Get("/rates", handlers.HRHandler.GetModerationRates)                 // Held (or ?status=rejected) rates
+	moderation.Post("/rates/:id/decision", handlers.HRHandler.DecideRate)           // Publish or reject a rate
+	moderation.Get("/responses", handlers.HRHandler.GetHeldResponses)               // Held HR responses
+	moderation.Post("/responses/:id/decision", handlers.HRHandler.DecideHRResponse) // Publish or reject an HR response
+	moderation.Get("/risky-rates", handlers.HRHandler.GetRiskyRates)                // Rates flagged by fraud scoring
+	moderation.Post("/rates/:id/risk-decision", handlers.HRHandler.ReviewRateRisk)  // Clear or reject a flagged rate
+	moderation.Get("/reports", handlers.ReportHandler.GetReports)                   // Report queue (?status=open&target_type=rate)
+	moderation.Post("/reports/:id/decision", handlers.ReportHandler.DecideReport)   // Uphold or dismiss a report
+	moderation.Get("/audit-log", handlers.ReportHandler.GetAuditLog)                // Moderation audit log
+
+	// User reports
+	app.Post("/rates/:id/report", handler.JWTAuthMiddleware(), handler.HasRolesMiddleware("employee"), handlers.ReportHandler.ReportRate)
+	app.Post("/badges/:id/report", handler.JWTAuthMiddleware(), handler.HasRolesMiddleware("employee"), handlers.ReportHandler.ReportBadge)
+
+	// Badges
+	app.Get("/badges/recent", handler.OptionalJWTMiddleware(), handlers.HRHandler.GetRecentBadges) // Latest awarded badges
+	app.Get("/badges/:id", handler.OptionalJWTMiddleware(), handlers.HRHandler.GetBadge)
+	app.Delete("/badges/:id/like", handler.JWTAuthMiddleware(), handler.HasRolesMiddleware("employee"), handlers.HRHandler.DeleteBadgeVote) // Remove own like/dislike
+
+	// Employee points
+	app.Get("/employees/me/points", handler.JWTAuthMiddleware(), handler.HasRolesMiddleware("employee"), handlers.HRHandler.GetMyPoints) // Balance and ledger
+
+	// Employee achievements and weekly contribution streaks
+	app.Get("/achievements", handlers.AchievementHandler.GetCatalog)
+	app.Get("/employees/:employee_id/achievements", handlers.AchievementHandler.GetEmployeeAchievements) // Earned, progress and streak
+
+	// Leaderboards (live, and past rankings from the periodic snapshots)
+	app.Get("/leaderboards/contributors", handlers.LeaderboardHandler.GetContributors) // ?scope=overall|city|job_field&value=&period=all|month&month=YYYY-MM
+	app.Get("/leaderboards/hrs", handlers.LeaderboardHandler.GetHRs)                   // ?scope=overall|company|job_position&value=&period=all|month&month=YYYY-MM
+	app.Get("/leaderboards/:board/history", handlers.LeaderboardHandler.GetHistory)    // ?at=YYYY-MM-DD
+	app.Get("/leaderboards/:board/snapshots", handlers.LeaderboardHandler.GetSnapshots)
+
+	// Embeddable rating widgets (careers pages)
+	app.Get("/embed/hr/:id/badge.svg", handlers.EmbedHandler.GetBadgeSVG)
+	app.Get("/embed/hr/:id/widget", handlers.EmbedHandler.GetWidget) // HTML for iframes
+
+	// Open Badges 2.0 (public, fetched by verifiers and external sites)
+	openBadges := app.Group("/openbadges")
+	openBadges.Get("/issuer", handlers.OpenBadgesHandler.GetIssuer)
+	openBadges.Get("/issuer/key", handlers.OpenBadgesHandler.GetPublicKey)
+	openBadges.Get("/revocations", handlers.OpenBadgesHandler.GetRevocationList)
+	openBadges.Get("/badges/:id", handlers.OpenBadgesHandler.GetBadgeClass)
+	openBadges.Get("/assertions/:id", handlers.OpenBadgesHandler.GetAssertion)
+	openBadges.Get("/assertions/:id/signed", handlers.OpenBadgesHandler.GetSignedAssertion) // JWS, when a signing key is configured
+
+	notifications := app.Group("/notifications", handler.JWTAuthMiddleware())
+	notifications.Get("", handlers.NotificationHandler.GetNotifications)
+	notifications.Post("/:id/read", handlers.NotificationHandler.MarkNotificationRead)
 
 	app.Post("/signin", handler.SignIn(db))
 	app.Post("/signup", handler.SignUpUser(db))
 
 	admin := app.Group("/api/admin", handler.JWTAuthMiddleware(), handler.HasRolesMiddleware("admin"))
 	admin.Get("/dashboard", bootstrap.AdminEndpoint)
+	admin.Post("/rating-criteria", handlers.HRHandler.CreateRatingCriterion)
+	admin.Put("/rating-criteria/:id", handlers.HRHandler.UpdateRatingCriterion)
+	admin.Post("/rating-contexts", handlers.HRHandler.CreateRatingContext)
+	admin.Put("/rating-contexts/:id", handlers.HRHandler.UpdateRatingContext)
+	admin.Post("/badge-definitions", handlers.HRHandler.CreateBadgeDefinition)
+	admin.Post("/badge-definitions/dry-run", handlers.HRHandler.DryRunBadgeRule)
+	admin.Get("/badge-rules/metrics", handlers.HRHandler.GetBadgeRuleMetrics)
+	admin.Put("/badge-definitions/:id", handlers.HRHandler.UpdateBadgeDefinition)
+	admin.Delete("/badge-definitions/:id", handlers.HRHandler.DeleteBadgeDefinition)
+	admin.Get("/badge-jobs", handlers.HRHandler.GetBadgeEvaluationJobs) // Badge evaluation jobs and their progress
+	admin.Get("/badge-jobs/:id", handlers.HRHandler.GetBadgeEvaluationJob)
+	admin.Post("/badge-jobs", handlers.HRHandler.QueueBadgeBackfill) // Re-evaluate the badges of every profile
+	admin.Put("/hr-profiles/:id/verified", handlers.HRHandler.SetProfileVerified)
+	admin.Post("/badges", handlers.HRHandler.AwardBadge) // Award a badge to HR manually
+	admin.Post("/badges/:id/revoke", handlers.HRHandler.RevokeBadge)
+	admin.Post("/leaderboards/snapshots", handlers.LeaderboardHandler.TakeSnapshots) // Snapshot every leaderboard now
 
 	app.Get("/zat", func(c fiber.Ctx) error {
 
@@ -81,5 +175,8 @@ func SetupFiber(handlers Handlers, db *sqlx.DB) {
 		return c.JSON(fiber.Map{"message": "Welcome, Editor! Here is your content.", "user_id": userClaims.ID})
 	})
 
-	log.Fatal(app.Listen(":8080"))
+	err := app.Listen(bootstrap.ListenAddr, fiber.ListenConfig{GracefulContext: ctx, ShutdownTimeout: 10 * time.Second})
+	if err != nil {
+		log.Fatal(err)
+	}
 }
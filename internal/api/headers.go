@@ -1,6 +1,8 @@
 package myfiber
 
 import (
+	"context"
+
 	"github.com/jmoiron/sqlx"
 	"github.com/rs/zerolog"
 
@@ -14,6 +16,13 @@ type Handlers struct {
 	
 	HRHandler             handler.HRHandler
 	EmployeeHandler             handler.EmployeeHandler
+	NotificationHandler         handler.NotificationHandler
+	VerificationHandler         handler.VerificationHandler
+	ReportHandler               handler.ReportHandler
+	OpenBadgesHandler           handler.OpenBadgesHandler
+	EmbedHandler                handler.EmbedHandler
+	LeaderboardHandler          handler.LeaderboardHandler
+	AchievementHandler          handler.AchievementHandler
 }
 
 type App struct {
@@ -21,7 +30,9 @@ type App struct {
 	Handlers Handlers
 }
 
-func SetupHandlers(logger zerolog.Logger) *App {
+// SetupHandlers wires the application. The background workers run until ctx
+// is cancelled.
+func SetupHandlers(ctx context.Context, logger zerolog.Logger) *App {
 	db := bootstrap.InitDB()
 
 	// accountRepo := repos.NewPosAccountRepository(db)
@@ -34,15 +45,61 @@ func SetupHandlers(logger zerolog.Logger) *App {
 	employeeService := service.NewEmployeeService(logger, employeeRepo)
 	employeeHandler := handler.NewEmployeeHandler(logger, employeeService)
 
-	hrRepo := repos.NewPosHRRepository(db)
-	hrService := service.NewHRService(logger, hrRepo)
+	notificationRepo := repos.NewPosNotificationRepository(db)
+	notificationService := service.NewNotificationService(logger, notificationRepo)
+	notificationHandler := handler.NewNotificationHandler(logger, notificationService)
+
+	moderationRepo := repos.NewPosModerationRepository(db)
+	auditTrail := service.NewAuditTrail(logger, moderationRepo)
+
+	hrRepo := repos.NewPosHRRepository(db, bootstrap.LoadScoringConfig())
+	embedService := service.NewEmbedService(logger, hrRepo, bootstrap.LoadEmbedConfig())
+	contributorLevels := service.NewContributorLevels(logger, hrRepo, bootstrap.LoadLevelConfig(), notificationService, embedService)
+	achievementService := service.NewAchievementService(logger, hrRepo, notificationService, contributorLevels)
+	achievementService.Start(ctx)
+	achievementHandler := handler.NewAchievementHandler(logger, achievementService)
+	hrService := service.NewHRService(logger, hrRepo, bootstrap.LoadRatePolicy(), bootstrap.LoadFraudConfig(), notificationService, auditTrail, embedService,
+		contributorLevels, achievementService)
 	hrHandler := handler.NewHRHandler(logger, hrService)
 
+	// تقييم الشارات في الخلفية (أحداث + إعادة تقييم دورية)
+	service.NewBadgeWorker(logger, hrService, bootstrap.LoadBadgeWorkerConfig()).Start(ctx)
+
+	openBadgeService := service.NewOpenBadgeService(logger, hrRepo, bootstrap.LoadOpenBadgesConfig())
+	openBadgesHandler := handler.NewOpenBadgesHandler(logger, openBadgeService)
+	embedHandler := handler.NewEmbedHandler(logger, embedService)
+
+	// لوحات الصدارة ولقطاتها الدورية
+	leaderboardService := service.NewLeaderboardService(logger, hrRepo, bootstrap.LoadLeaderboardConfig())
+	leaderboardService.StartSnapshots(ctx)
+	leaderboardHandler := handler.NewLeaderboardHandler(logger, leaderboardService)
+
+	mailer, err := service.NewMailer(logger, bootstrap.LoadMailConfig())
+	if err != nil {
+		logger.Fatal().Err(err).Msg("mailer is not configured")
+	}
+
+	verificationRepo := repos.NewPosVerificationRepository(db)
+	verificationService := service.NewVerificationService(logger, verificationRepo, hrRepo, notificationService, auditTrail,
+		mailer, bootstrap.VerificationUploadDir(), contributorLevels, achievementService)
+	verificationHandler := handler.NewVerificationHandler(logger, verificationService)
+
+	reportService := service.NewReportService(logger, moderationRepo, hrRepo, notificationService, auditTrail, bootstrap.LoadReportPolicy(), contributorLevels,
+		embedService)
+	reportHandler := handler.NewReportHandler(logger, reportService, auditTrail)
+
 	return &App{
 		DB: db,
 		Handlers: Handlers{
 			HRHandler:              *hrHandler,
 			EmployeeHandler:            *employeeHandler,
+			NotificationHandler:        *notificationHandler,
+			VerificationHandler:        *verificationHandler,
+			ReportHandler:              *reportHandler,
+			OpenBadgesHandler:          *openBadgesHandler,
+			EmbedHandler:               *embedHandler,
+			LeaderboardHandler:         *leaderboardHandler,
+			AchievementHandler:         *achievementHandler,
 		},
 	}
 }
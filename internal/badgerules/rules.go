@@ -0,0 +1,383 @@
+// Package badgerules parses and evaluates the declarative criteria stored on
+// badge definitions, so a new badge is data instead of a Go type:
+//
+//	total_rates_count >= 50 AND weighted_rate >= 4.5 AND verified
+//	rates_count(30d) >= 20 AND NOT (avg_rate(30d) < 4)
+//
+// A rule is comparisons and boolean metrics joined with AND, OR, NOT and
+// parentheses. Windowed metrics take the window in days, e.g. rates_count(30d).
+// The caller computes the metrics a rule needs (Rule.Metrics) and passes their
+// values to Rule.Evaluate.
+package badgerules
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// MaxWindowDays bounds windowed metrics.
+const MaxWindowDays = 365
+
+// MetricSpec describes a metric a rule may use.
+type MetricSpec struct {
+	Name        string `json:"name"`
+	Windowed    bool   `json:"windowed"` // needs a window: name(30d)
+	Bool        bool   `json:"bool"`     // used alone, not compared
+	Description string `json:"description"`
+}
+
+// Specs is the catalog of metrics; the repository knows how to compute each.
+var Specs = []MetricSpec{
+	{Name: "total_rates_count", Description: "counted rates of the profile"},
+	{Name: "rate", Description: "average rate (hr_profiles.rate)"},
+	{Name: "weighted_rate", Description: "confidence-adjusted score used for ranking"},
+	{Name: "verified", Bool: true, Description: "the profile is verified"},
+	{Name: "verified_rates_count", Description: "counted rates with proof of employment"},
+	{Name: "response_rate", Description: "share (0..1) of counted rates with a published HR response"},
+	{Name: "rates_count", Windowed: true, Description: "counted rates created in the window"},
+	{Name: "avg_rate", Windowed: true, Description: "average of the counted rates created in the window"},
+}
+
+func spec(name string) (MetricSpec, bool) {
+	for _, s := range Specs {
+		if s.Name == name {
+			return s, true
+		}
+	}
+	return MetricSpec{}, false
+}
+
+// Metric is a metric reference in a rule; WindowDays is 0 for unwindowed metrics.
+type Metric struct {
+	Name       string
+	WindowDays int
+}
+
+// Key identifies the metric in the values passed to Evaluate.
+func (m Metric) Key() string {
+	if m.WindowDays > 0 {
+		return fmt.Sprintf("%s(%dd)", m.Name, m.WindowDays)
+	}
+	return m.Name
+}
+
+// Rule is a parsed, validated rule.
+type Rule struct {
+	source string
+	root   node
+}
+
+// Parse validates a rule and returns it ready for evaluation.
+func Parse(source string) (*Rule, error) {
+	tokens, err := lex(source)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokEOF {
+		return nil, p.errorf(tok, "unexpected %q", tok.text)
+	}
+	return &Rule{source: strings.TrimSpace(source), root: root}, nil
+}
+
+func (r *Rule) String() string { return r.source }
+
+// Metrics lists the distinct metrics the rule reads, sorted by key.
+func (r *Rule) Metrics() []Metric {
+	seen := map[string]Metric{}
+	r.root.collect(seen)
+	metrics := make([]Metric, 0, len(seen))
+	for _, m := range seen {
+		metrics = append(metrics, m)
+	}
+	sort.Slice(metrics, func(i, j int) bool { return metrics[i].Key() < metrics[j].Key() })
+	return metrics
+}
+
+// Evaluate applies the rule to metric values keyed by Metric.Key; a missing
+// value counts as 0 (false for boolean metrics).
+func (r *Rule) Evaluate(values map[string]float64) bool {
+	return r.root.eval(values)
+}
+
+// =================================================================
+// AST
+// =================================================================
+
+type node interface {
+	eval(values map[string]float64) bool
+	collect(seen map[string]Metric)
+}
+
+type andNode struct{ left, right node }
+type orNode struct{ left, right node }
+type notNode struct{ inner node }
+type boolNode struct{ metric Metric }
+type compareNode struct {
+	metric Metric
+	op     string
+	value  float64
+}
+
+func (n andNode) eval(v map[string]float64) bool { return n.left.eval(v) && n.right.eval(v) }
+func (n orNode) eval(v map[string]float64) bool  { return n.left.eval(v) || n.right.eval(v) }
+func (n notNode) eval(v map[string]float64) bool { return !n.inner.eval(v) }
+func (n boolNode) eval(v map[string]float64) bool {
+	return v[n.metric.Key()] != 0
+}
+func (n compareNode) eval(v map[string]float64) bool {
+	x := v[n.metric.Key()]
+	switch n.op {
+	case ">=":
+		return x >= n.value
+	case "<=":
+		return x <= n.value
+	case ">":
+		return x > n.value
+	case "<":
+		return x < n.value
+	case "=":
+		return x == n.value
+	case "!=":
+		return x != n.value
+	}
+	return false
+}
+
+func (n andNode) collect(seen map[string]Metric)     { n.left.collect(seen); n.right.collect(seen) }
+func (n orNode) collect(seen map[string]Metric)      { n.left.collect(seen); n.right.collect(seen) }
+func (n notNode) collect(seen map[string]Metric)     { n.inner.collect(seen) }
+func (n boolNode) collect(seen map[string]Metric)    { seen[n.metric.Key()] = n.metric }
+func (n compareNode) collect(seen map[string]Metric) { seen[n.metric.Key()] = n.metric }
+
+// =================================================================
+// Lexer
+// =================================================================
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokIdent
+	tokNumber
+	tokOp
+	tokLParen
+	tokRParen
+	tokAnd
+	tokOr
+	tokNot
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int // 1-based column
+}
+
+func lex(source string) ([]token, error) {
+	var tokens []token
+	runes := []rune(source)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		start := i
+		switch {
+		case unicode.IsSpace(r):
+			i++
+			continue
+		case r == '(':
+			tokens = append(tokens, token{tokLParen, "(", start + 1})
+			i++
+		case r == ')':
+			tokens = append(tokens, token{tokRParen, ")", start + 1})
+			i++
+		case strings.ContainsRune("<>=!", r):
+			op := string(r)
+			if i+1 < len(runes) && runes[i+1] == '=' {
+				op += "="
+			}
+			i += len(op)
+			switch op {
+			case "==":
+				op = "="
+			case "!":
+				return nil, fmt.Errorf("column %d: unexpected \"!\"; use NOT or !=", start+1)
+			}
+			tokens = append(tokens, token{tokOp, op, start + 1})
+		case unicode.IsDigit(r) || r == '.':
+			for i < len(runes) && (unicode.IsDigit(runes[i]) || runes[i] == '.') {
+				i++
+			}
+			// a window such as 30d keeps its unit
+			if i < len(runes) && runes[i] == 'd' {
+				i++
+			}
+			tokens = append(tokens, token{tokNumber, string(runes[start:i]), start + 1})
+		case unicode.IsLetter(r) || r == '_':
+			for i < len(runes) && (unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i]) || runes[i] == '_') {
+				i++
+			}
+			word := string(runes[start:i])
+			kind := tokIdent
+			switch strings.ToUpper(word) {
+			case "AND":
+				kind = tokAnd
+			case "OR":
+				kind = tokOr
+			case "NOT":
+				kind = tokNot
+			}
+			tokens = append(tokens, token{kind, word, start + 1})
+		default:
+			return nil, fmt.Errorf("column %d: unexpected %q", start+1, string(r))
+		}
+	}
+	return append(tokens, token{tokEOF, "end of rule", len(runes) + 1}), nil
+}
+
+// =================================================================
+// Parser
+// =================================================================
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peek() token { return p.tokens[p.pos] }
+
+func (p *parser) next() token {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokEOF {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *parser) errorf(tok token, format string, args ...interface{}) error {
+	return fmt.Errorf("column %d: %s", tok.pos, fmt.Sprintf(format, args...))
+}
+
+func (p *parser) parseOr() (node, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == tokOr {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = orNode{left, right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (node, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == tokAnd {
+		p.next()
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = andNode{left, right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseUnary() (node, error) {
+	if p.peek().kind == tokNot {
+		p.next()
+		inner, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return notNode{inner}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *parser) parsePrimary() (node, error) {
+	tok := p.next()
+	switch tok.kind {
+	case tokLParen:
+		inner, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if closing := p.next(); closing.kind != tokRParen {
+			return nil, p.errorf(closing, "expected \")\", got %q", closing.text)
+		}
+		return inner, nil
+	case tokIdent:
+		return p.parseCondition(tok)
+	}
+	return nil, p.errorf(tok, "expected a metric, NOT or \"(\", got %q", tok.text)
+}
+
+// parseCondition reads "metric[(Nd)] op number" or a boolean metric alone.
+func (p *parser) parseCondition(name token) (node, error) {
+	s, ok := spec(strings.ToLower(name.text))
+	if !ok {
+		return nil, p.errorf(name, "unknown metric %q", name.text)
+	}
+	metric := Metric{Name: s.Name}
+
+	if p.peek().kind == tokLParen {
+		if !s.Windowed {
+			return nil, p.errorf(p.peek(), "%s does not take a window", s.Name)
+		}
+		p.next()
+		window := p.next()
+		days, err := parseWindow(window.text)
+		if window.kind != tokNumber || err != nil {
+			return nil, p.errorf(window, "expected a window from 1d to %dd, got %q", MaxWindowDays, window.text)
+		}
+		if closing := p.next(); closing.kind != tokRParen {
+			return nil, p.errorf(closing, "expected \")\", got %q", closing.text)
+		}
+		metric.WindowDays = days
+	} else if s.Windowed {
+		return nil, p.errorf(name, "%s needs a window, e.g. %s(30d)", s.Name, s.Name)
+	}
+
+	if s.Bool {
+		if p.peek().kind == tokOp {
+			return nil, p.errorf(p.peek(), "%s is true or false and cannot be compared; use %s or NOT %s", s.Name, s.Name, s.Name)
+		}
+		return boolNode{metric}, nil
+	}
+
+	op := p.next()
+	if op.kind != tokOp {
+		return nil, p.errorf(op, "expected a comparison after %s, got %q", metric.Key(), op.text)
+	}
+	number := p.next()
+	value, err := strconv.ParseFloat(number.text, 64)
+	if number.kind != tokNumber || err != nil {
+		return nil, p.errorf(number, "expected a number, got %q", number.text)
+	}
+	return compareNode{metric: metric, op: op.text, value: value}, nil
+}
+
+func parseWindow(text string) (int, error) {
+	if !strings.HasSuffix(text, "d") {
+		return 0, fmt.Errorf("missing unit")
+	}
+	days, err := strconv.Atoi(strings.TrimSuffix(text, "d"))
+	if err != nil || days < 1 || days > MaxWindowDays {
+		return 0, fmt.Errorf("window must be 1d to %dd", MaxWindowDays)
+	}
+	return days, nil
+}
@@ -0,0 +1,335 @@
+package bootstrap
+
+import (
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// RatePolicyUpdate turns a repeated rate from the same employee into an edit
+	// of the existing one.
+	RatePolicyUpdate = "update"
+	// RatePolicyCooldown rejects a repeated rate until the cooldown has passed,
+	// then replaces the previous one.
+	RatePolicyCooldown = "cooldown"
+)
+
+// RatePolicy controls what happens when an employee rates the same HR twice.
+type RatePolicy struct {
+	Mode     string
+	Cooldown time.Duration
+}
+
+// LoadRatePolicy reads RATE_REPEAT_POLICY (update|cooldown) and
+// RATE_COOLDOWN_DAYS from the environment.
+func LoadRatePolicy() RatePolicy {
+	policy := RatePolicy{
+		Mode:     RatePolicyUpdate,
+		Cooldown: 30 * 24 * time.Hour,
+	}
+
+	if mode := os.Getenv("RATE_REPEAT_POLICY"); mode == RatePolicyCooldown {
+		policy.Mode = RatePolicyCooldown
+	}
+
+	if days := envInt("RATE_COOLDOWN_DAYS", -1); days >= 0 {
+		policy.Cooldown = time.Duration(days) * 24 * time.Hour
+	}
+
+	return policy
+}
+
+// envInt returns the integer value of an environment variable or def.
+func envInt(key string, def int) int {
+	if val, err := strconv.Atoi(os.Getenv(key)); err == nil {
+		return val
+	}
+	return def
+}
+
+const (
+	PriorScopeGlobal      = "global"
+	PriorScopeJobPosition = "job_position"
+)
+
+// ScoringConfig tunes the confidence-adjusted (Bayesian) HR score:
+//
+//	weighted_rate = (PriorWeight*prior + Σ w·rate) / (PriorWeight + Σ w)
+//
+// where prior is the mean of all rates (or of the same job position) and w is
+// the weight of each rate (recency decay × reviewer credibility).
+type ScoringConfig struct {
+	PriorWeight            float64 // how many "virtual" prior rates every profile starts with
+	PriorScope             string  // global | job_position
+	RecencyHalfLifeDays    float64 // 0 disables recency decay
+	VerifiedReviewerWeight float64 // weight of rates written by verified employees
+	TrustedReviewerWeight  float64 // extra weight of rates by trusted contributors (LevelConfig)
+	ExpertReviewerWeight   float64 // extra weight of rates by expert contributors (LevelConfig)
+}
+
+// LoadScoringConfig reads SCORE_PRIOR_WEIGHT, SCORE_PRIOR_SCOPE,
+// SCORE_RECENCY_HALF_LIFE_DAYS and SCORE_VERIFIED_REVIEWER_WEIGHT; the level
+// weights come from LoadLevelConfig.
+func LoadScoringConfig() ScoringConfig {
+	levels := LoadLevelConfig()
+	config := ScoringConfig{
+		PriorWeight:            envFloat("SCORE_PRIOR_WEIGHT", 10),
+		PriorScope:             PriorScopeGlobal,
+		RecencyHalfLifeDays:    envFloat("SCORE_RECENCY_HALF_LIFE_DAYS", 365),
+		VerifiedReviewerWeight: envFloat("SCORE_VERIFIED_REVIEWER_WEIGHT", 1.5),
+		TrustedReviewerWeight:  levels.Trusted.Privileges.ReviewWeight,
+		ExpertReviewerWeight:   levels.Expert.Privileges.ReviewWeight,
+	}
+
+	if os.Getenv("SCORE_PRIOR_SCOPE") == PriorScopeJobPosition {
+		config.PriorScope = PriorScopeJobPosition
+	}
+
+	return config
+}
+
+// envFloat returns the float value of an environment variable or def.
+func envFloat(key string, def float64) float64 {
+	if val, err := strconv.ParseFloat(os.Getenv(key), 64); err == nil {
+		return val
+	}
+	return def
+}
+
+// envBool returns the boolean value of an environment variable or def.
+func envBool(key string, def bool) bool {
+	if val, err := strconv.ParseBool(os.Getenv(key)); err == nil {
+		return val
+	}
+	return def
+}
+
+// VerificationUploadDir is where employment documents are stored. It must not
+// be under ./public, the documents are only served to moderators.
+func VerificationUploadDir() string {
+	dir := os.Getenv("VERIFICATION_UPLOAD_DIR")
+	if dir == "" {
+		dir = "uploads/verifications"
+	}
+	if err := os.MkdirAll(dir, 0750); err != nil {
+		log.Printf("failed to create verification upload dir %s: %v", dir, err)
+	}
+	return dir
+}
+
+const (
+	// MailDriverSMTP delivers emails through an SMTP server.
+	MailDriverSMTP = "smtp"
+	// MailDriverLog only logs the recipient and subject of emails; nothing is
+	// delivered, so it is meant for local development.
+	MailDriverLog = "log"
+)
+
+// MailConfig selects how transactional emails are sent.
+type MailConfig struct {
+	Driver   string // smtp | log
+	Host     string
+	Port     int
+	Username string // empty = no SMTP authentication
+	Password string
+	From     string
+}
+
+// LoadMailConfig reads MAIL_DRIVER, SMTP_HOST, SMTP_PORT, SMTP_USERNAME,
+// SMTP_PASSWORD and MAIL_FROM.
+func LoadMailConfig() MailConfig {
+	config := MailConfig{
+		Driver:   strings.ToLower(os.Getenv("MAIL_DRIVER")),
+		Host:     os.Getenv("SMTP_HOST"),
+		Port:     envInt("SMTP_PORT", 587),
+		Username: os.Getenv("SMTP_USERNAME"),
+		Password: os.Getenv("SMTP_PASSWORD"),
+		From:     os.Getenv("MAIL_FROM"),
+	}
+	if config.Driver == "" {
+		config.Driver = MailDriverSMTP
+	}
+	return config
+}
+
+// ReportPolicy controls user reports on rates and badges.
+type ReportPolicy struct {
+	HideThreshold     float64 // summed reporter credibility that hides a rate until reviewed
+	UpheldCredibility float32 // credibility added to reporters of an upheld report
+	DismissedPenalty  float32 // credibility removed from reporters of a dismissed report
+}
+
+// LoadReportPolicy reads REPORT_HIDE_THRESHOLD, REPORT_UPHELD_CREDIBILITY and
+// REPORT_DISMISSED_PENALTY.
+func LoadReportPolicy() ReportPolicy {
+	return ReportPolicy{
+		HideThreshold:     envFloat("REPORT_HIDE_THRESHOLD", 3),
+		UpheldCredibility: float32(envFloat("REPORT_UPHELD_CREDIBILITY", 0.1)),
+		DismissedPenalty:  float32(envFloat("REPORT_DISMISSED_PENALTY", 0.2)),
+	}
+}
+
+// FraudConfig tunes review fraud scoring.
+type FraudConfig struct {
+	RiskThreshold int // rates scoring at or above it are left out of averages until reviewed
+	RescoreDays   int // how far back the rescore-fraud job looks
+}
+
+// LoadFraudConfig reads FRAUD_RISK_THRESHOLD and FRAUD_RESCORE_DAYS.
+func LoadFraudConfig() FraudConfig {
+	return FraudConfig{
+		RiskThreshold: envInt("FRAUD_RISK_THRESHOLD", 60),
+		RescoreDays:   envInt("FRAUD_RESCORE_DAYS", 7),
+	}
+}
+
+// BadgeWorkerConfig controls the background badge evaluation worker.
+type BadgeWorkerConfig struct {
+	Enabled         bool
+	PollInterval    time.Duration // how often the queue is checked
+	FullRunInterval time.Duration // scheduled re-evaluation of every profile; 0 disables
+	StaleMinutes    int           // running jobs without progress for this long are failed
+}
+
+// LoadBadgeWorkerConfig reads BADGE_WORKER_ENABLED, BADGE_WORKER_POLL_SECONDS,
+// BADGE_FULL_RUN_HOURS and BADGE_JOB_STALE_MINUTES.
+func LoadBadgeWorkerConfig() BadgeWorkerConfig {
+	return BadgeWorkerConfig{
+		Enabled:         os.Getenv("BADGE_WORKER_ENABLED") != "false",
+		PollInterval:    time.Duration(max(envInt("BADGE_WORKER_POLL_SECONDS", 5), 1)) * time.Second,
+		FullRunInterval: time.Duration(envInt("BADGE_FULL_RUN_HOURS", 24)) * time.Hour,
+		StaleMinutes:    envInt("BADGE_JOB_STALE_MINUTES", 30),
+	}
+}
+
+// ListenAddr is the address the API listens on.
+const ListenAddr = ":8080"
+
+// PublicBaseURL reads PUBLIC_BASE_URL, the URL the API is reachable at from
+// outside, used in links that leave the platform. Without it the links point
+// at the local listen address.
+func PublicBaseURL() string {
+	if url := strings.TrimRight(os.Getenv("PUBLIC_BASE_URL"), "/"); url != "" {
+		return url
+	}
+	return "http://localhost" + ListenAddr
+}
+
+// OpenBadgesConfig describes the issuer of the Open Badges 2.0 assertions.
+type OpenBadgesConfig struct {
+	BaseURL        string // public URL the documents are served from
+	IssuerName     string
+	IssuerURL      string
+	IssuerEmail    string
+	SigningKeyFile string // RSA private key in PEM; empty = hosted assertions only
+}
+
+// LoadOpenBadgesConfig reads PUBLIC_BASE_URL, OPEN_BADGES_ISSUER_NAME,
+// OPEN_BADGES_ISSUER_URL, OPEN_BADGES_ISSUER_EMAIL and
+// OPEN_BADGES_SIGNING_KEY_FILE.
+func LoadOpenBadgesConfig() OpenBadgesConfig {
+	config := OpenBadgesConfig{
+		BaseURL:        PublicBaseURL(),
+		IssuerName:     os.Getenv("OPEN_BADGES_ISSUER_NAME"),
+		IssuerURL:      os.Getenv("OPEN_BADGES_ISSUER_URL"),
+		IssuerEmail:    os.Getenv("OPEN_BADGES_ISSUER_EMAIL"),
+		SigningKeyFile: os.Getenv("OPEN_BADGES_SIGNING_KEY_FILE"),
+	}
+
+	if config.IssuerName == "" {
+		config.IssuerName = "Doneally"
+	}
+	if config.IssuerURL == "" {
+		config.IssuerURL = config.BaseURL
+	}
+
+	return config
+}
+
+// EmbedConfig controls the embeddable rating widgets.
+type EmbedConfig struct {
+	BaseURL  string
+	CacheTTL time.Duration // rendered widgets are kept this long unless the profile changes first
+	MaxAge   int           // Cache-Control max-age in seconds; browsers revalidate with the ETag after it
+}
+
+// LoadEmbedConfig reads EMBED_CACHE_MINUTES and EMBED_MAX_AGE_SECONDS.
+func LoadEmbedConfig() EmbedConfig {
+	return EmbedConfig{
+		BaseURL:  PublicBaseURL(),
+		CacheTTL: time.Duration(envInt("EMBED_CACHE_MINUTES", 30)) * time.Minute,
+		MaxAge:   envInt("EMBED_MAX_AGE_SECONDS", 300),
+	}
+}
+
+// LeaderboardConfig controls the leaderboards and their periodic snapshots.
+type LeaderboardConfig struct {
+	SnapshotInterval time.Duration // how often every board is snapshotted; 0 disables
+	SnapshotSize     int           // entries kept per scope value in a snapshot
+}
+
+// LoadLeaderboardConfig reads LEADERBOARD_SNAPSHOT_HOURS and
+// LEADERBOARD_SNAPSHOT_SIZE.
+func LoadLeaderboardConfig() LeaderboardConfig {
+	return LeaderboardConfig{
+		SnapshotInterval: time.Duration(envInt("LEADERBOARD_SNAPSHOT_HOURS", 24)) * time.Hour,
+		SnapshotSize:     max(envInt("LEADERBOARD_SNAPSHOT_SIZE", 100), 1),
+	}
+}
+
+// LevelPrivileges is what a contributor level unlocks.
+type LevelPrivileges struct {
+	ReviewWeight       float64 // multiplies the weight of their rates in weighted_rate
+	ReportWeight       float64 // multiplies the weight of their reports
+	SkipModerationHold bool    // rates the review checks would hold are published right away; rejections still apply
+}
+
+// LevelRequirement is what a contributor needs to reach a level, with what
+// the level unlocks.
+type LevelRequirement struct {
+	Points         int     // points ledger, without the points for casting votes
+	Reviews        int     // published rates
+	MinHelpfulness float64 // share of helpful votes on their rates, once they have LevelConfig.MinVotes votes
+	MaxRejected    int     // rates rejected by moderation
+	Verified       bool    // a verified account or at least one verified rate
+	Privileges     LevelPrivileges
+}
+
+// LevelConfig sets the contributor levels above newcomer (trusted, expert).
+type LevelConfig struct {
+	Trusted  LevelRequirement
+	Expert   LevelRequirement
+	MinVotes int // helpfulness is only judged from this many votes on
+}
+
+// LoadLevelConfig reads LEVEL_<TRUSTED|EXPERT>_POINTS, _REVIEWS, _HELPFULNESS,
+// _MAX_REJECTED, _VERIFIED, _REVIEW_WEIGHT, _REPORT_WEIGHT and _SKIP_HOLD, and
+// LEVEL_HELPFULNESS_MIN_VOTES.
+func LoadLevelConfig() LevelConfig {
+	return LevelConfig{
+		Trusted: loadLevelRequirement("TRUSTED", LevelRequirement{Points: 50, Reviews: 3, MinHelpfulness: 0.5, MaxRejected: 2,
+			Privileges: LevelPrivileges{ReviewWeight: 1.25, ReportWeight: 1.5}}),
+		Expert: loadLevelRequirement("EXPERT", LevelRequirement{Points: 250, Reviews: 10, MinHelpfulness: 0.7, MaxRejected: 2, Verified: true,
+			Privileges: LevelPrivileges{ReviewWeight: 1.5, ReportWeight: 2, SkipModerationHold: true}}),
+		MinVotes: envInt("LEVEL_HELPFULNESS_MIN_VOTES", 10),
+	}
+}
+
+func loadLevelRequirement(level string, def LevelRequirement) LevelRequirement {
+	prefix := "LEVEL_" + level + "_"
+	return LevelRequirement{
+		Points:         envInt(prefix+"POINTS", def.Points),
+		Reviews:        envInt(prefix+"REVIEWS", def.Reviews),
+		MinHelpfulness: envFloat(prefix+"HELPFULNESS", def.MinHelpfulness),
+		MaxRejected:    envInt(prefix+"MAX_REJECTED", def.MaxRejected),
+		Verified:       envBool(prefix+"VERIFIED", def.Verified),
+		Privileges: LevelPrivileges{
+			ReviewWeight:       envFloat(prefix+"REVIEW_WEIGHT", def.Privileges.ReviewWeight),
+			ReportWeight:       envFloat(prefix+"REPORT_WEIGHT", def.Privileges.ReportWeight),
+			SkipModerationHold: envBool(prefix+"SKIP_HOLD", def.Privileges.SkipModerationHold),
+		},
+	}
+}
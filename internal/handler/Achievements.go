@@ -0,0 +1,53 @@
+package handler
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/rs/zerolog"
+	mylogger "githup.ahmedramadan.4cashier/internal/mylogger"
+	"githup.ahmedramadan.4cashier/internal/repos"
+	"githup.ahmedramadan.4cashier/internal/service"
+)
+
+// AchievementHandler serves employee achievements and streaks.
+type AchievementHandler struct {
+	Logger  zerolog.Logger
+	Service *service.AchievementService
+}
+
+// NewAchievementHandler creates a new instance of AchievementHandler.
+func NewAchievementHandler(logger zerolog.Logger, serv *service.AchievementService) *AchievementHandler {
+	return &AchievementHandler{
+		Logger:  logger.With().Str("layer", "handler").Str("component", "AchievementHandler").Logger(),
+		Service: serv,
+	}
+}
+
+// ------------------------------------------------------------------
+// GET /achievements (كل الإنجازات الممكنة ونقاطها)
+// ------------------------------------------------------------------
+func (h *AchievementHandler) GetCatalog(ctx fiber.Ctx) error {
+	return ctx.JSON(fiber.Map{"items": h.Service.Catalog()})
+}
+
+// ------------------------------------------------------------------
+// GET /employees/:employee_id/achievements (المكتسبة، والتقدم نحو الباقي، وسلسلة الأسابيع)
+// ------------------------------------------------------------------
+func (h *AchievementHandler) GetEmployeeAchievements(ctx fiber.Ctx) error {
+	employeeID, err := strconv.Atoi(ctx.Params("employee_id"))
+	if err != nil || employeeID <= 0 {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid employee ID"})
+	}
+
+	achievements, err := h.Service.GetEmployeeAchievements(ctx.Context(), employeeID)
+	if errors.Is(err, repos.ErrEmployeeNotFound) {
+		return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Employee not found"})
+	}
+	if err != nil {
+		mylogger.HandleLogging(h.Logger, err, "Failed to fetch achievements")
+		return ctx.Status(500).JSON(fiber.Map{"error": "Failed to fetch achievements"})
+	}
+	return ctx.JSON(achievements)
+}
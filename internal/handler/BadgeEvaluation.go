@@ -0,0 +1,93 @@
+package handler
+
+import (
+	"database/sql"
+	"errors"
+	"strconv"
+
+	"github.com/gofiber/fiber/v3"
+	"githup.ahmedramadan.4cashier/internal/bootstrap"
+	"githup.ahmedramadan.4cashier/internal/models"
+	mylogger "githup.ahmedramadan.4cashier/internal/mylogger"
+	"githup.ahmedramadan.4cashier/internal/repos"
+)
+
+// ------------------------------------------------------------------
+// GET /api/admin/badge-jobs (مهام تقييم الشارات، ?status=running)
+// ------------------------------------------------------------------
+func (h *HRHandler) GetBadgeEvaluationJobs(ctx fiber.Ctx) error {
+	jobs, err := h.Service.GetBadgeEvaluationJobs(ctx.Context(), ctx.Query("status"), bootstrap.GetPagination(ctx))
+	if err != nil {
+		mylogger.HandleLogging(h.Logger, err, "Failed to fetch badge evaluation jobs")
+		return ctx.Status(500).JSON(fiber.Map{"error": "Failed to fetch badge evaluation jobs"})
+	}
+
+	return ctx.JSON(fiber.Map{"items": jobs})
+}
+
+// ------------------------------------------------------------------
+// GET /api/admin/badge-jobs/:id (تقدم مهمة تقييم)
+// ------------------------------------------------------------------
+func (h *HRHandler) GetBadgeEvaluationJob(ctx fiber.Ctx) error {
+	jobID, err := strconv.ParseInt(ctx.Params("id"), 10, 64)
+	if err != nil {
+		return ctx.Status(400).JSON(fiber.Map{"error": "Invalid job ID"})
+	}
+
+	job, err := h.Service.GetBadgeEvaluationJob(ctx.Context(), jobID)
+	if errors.Is(err, repos.ErrBadgeJobNotFound) {
+		return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+	}
+	if err != nil {
+		mylogger.HandleLogging(h.Logger, err, "Failed to fetch badge evaluation job")
+		return ctx.Status(500).JSON(fiber.Map{"error": "Failed to fetch badge evaluation job"})
+	}
+
+	return ctx.JSON(job)
+}
+
+// ------------------------------------------------------------------
+// POST /api/admin/badge-jobs (إعادة تقييم شارات كل البروفايلات)
+// ------------------------------------------------------------------
+func (h *HRHandler) QueueBadgeBackfill(ctx fiber.Ctx) error {
+	job, err := h.Service.QueueBadgeBackfill(ctx.Context(), models.BadgeTriggerManual)
+	if err != nil {
+		mylogger.HandleLogging(h.Logger, err, "Failed to queue badge re-evaluation")
+		return ctx.Status(500).JSON(fiber.Map{"error": "Failed to queue badge re-evaluation"})
+	}
+
+	return ctx.Status(fiber.StatusAccepted).JSON(job)
+}
+
+// ------------------------------------------------------------------
+// PUT /api/admin/hr-profiles/:id/verified (توثيق بروفايل HR - للمشرف)
+// ------------------------------------------------------------------
+func (h *HRHandler) SetProfileVerified(ctx fiber.Ctx) error {
+	user, ok := currentUser(ctx)
+	if !ok {
+		return ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+
+	profileID, err := strconv.Atoi(ctx.Params("id"))
+	if err != nil {
+		return ctx.Status(400).JSON(fiber.Map{"error": "Invalid HR profile ID"})
+	}
+
+	var req struct {
+		Verified bool `json:"verified"`
+	}
+	if err := ctx.Bind().Body(&req); err != nil {
+		return ctx.Status(400).JSON(fiber.Map{"error": "Invalid request"})
+	}
+
+	err = h.Service.SetProfileVerified(ctx.Context(), user.UserID, profileID, req.Verified)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "HR profile not found"})
+	}
+	if err != nil {
+		mylogger.HandleLogging(h.Logger, err, "Failed to update HR profile verification")
+		return ctx.Status(500).JSON(fiber.Map{"error": "Failed to update HR profile verification"})
+	}
+
+	return ctx.SendStatus(204)
+}
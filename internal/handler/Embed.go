@@ -0,0 +1,85 @@
+package handler
+
+import (
+	"database/sql"
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/rs/zerolog"
+	mylogger "githup.ahmedramadan.4cashier/internal/mylogger"
+	"githup.ahmedramadan.4cashier/internal/service"
+	"githup.ahmedramadan.4cashier/internal/widget"
+)
+
+// EmbedHandler serves the rating widgets companies embed on their careers
+// pages.
+type EmbedHandler struct {
+	Logger  zerolog.Logger
+	Service *service.EmbedService
+}
+
+// NewEmbedHandler creates a new instance of EmbedHandler.
+func NewEmbedHandler(logger zerolog.Logger, serv *service.EmbedService) *EmbedHandler {
+	return &EmbedHandler{
+		Logger:  logger.With().Str("layer", "handler").Str("component", "EmbedHandler").Logger(),
+		Service: serv,
+	}
+}
+
+// ------------------------------------------------------------------
+// GET /embed/hr/:id/badge.svg (?theme=light|dark&lang=ar|en&size=small|medium|large)
+// ------------------------------------------------------------------
+func (h *EmbedHandler) GetBadgeSVG(ctx fiber.Ctx) error {
+	return h.serve(ctx, service.EmbedSVG)
+}
+
+// ------------------------------------------------------------------
+// GET /embed/hr/:id/widget (صفحة HTML للتضمين داخل iframe، نفس الخيارات)
+// ------------------------------------------------------------------
+func (h *EmbedHandler) GetWidget(ctx fiber.Ctx) error {
+	// يُسمح بتضمين الصفحة في أي موقع
+	ctx.Set("Content-Security-Policy", "frame-ancestors *")
+	return h.serve(ctx, service.EmbedHTML)
+}
+
+func (h *EmbedHandler) serve(ctx fiber.Ctx, format string) error {
+	hrID, err := strconv.Atoi(ctx.Params("id"))
+	if err != nil {
+		return ctx.Status(400).JSON(fiber.Map{"error": "Invalid HR ID"})
+	}
+
+	options := widget.Options{Theme: ctx.Query("theme"), Lang: ctx.Query("lang"), Size: ctx.Query("size")}
+	rendered, err := h.Service.Render(ctx.Context(), hrID, format, options)
+	switch {
+	case errors.Is(err, service.ErrInvalidEmbedOptions):
+		return ctx.Status(400).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, sql.ErrNoRows):
+		return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "HR profile not found"})
+	case err != nil:
+		mylogger.HandleLogging(h.Logger, err, "Failed to render widget")
+		return ctx.Status(500).JSON(fiber.Map{"error": "Failed to render widget"})
+	}
+
+	ctx.Set(fiber.HeaderETag, rendered.ETag)
+	ctx.Set(fiber.HeaderCacheControl, "public, max-age="+strconv.Itoa(h.Service.MaxAge()))
+	ctx.Set(fiber.HeaderXContentTypeOptions, "nosniff")
+	if etagMatches(ctx.Get(fiber.HeaderIfNoneMatch), rendered.ETag) {
+		return ctx.SendStatus(fiber.StatusNotModified)
+	}
+
+	ctx.Set(fiber.HeaderContentType, rendered.ContentType)
+	return ctx.Send(rendered.Body)
+}
+
+// etagMatches reports whether an If-None-Match header lists etag.
+func etagMatches(header, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == etag || candidate == "*" {
+			return true
+		}
+	}
+	return false
+}
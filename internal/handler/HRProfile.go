@@ -1,13 +1,19 @@
 package handler
 
 import (
+	"database/sql"
+	"errors"
+	"fmt"
 	"log"
 	"strconv"
 
 	"github.com/gofiber/fiber/v3"
 	"github.com/rs/zerolog"
+	"githup.ahmedramadan.4cashier/internal/badgerules"
 	"githup.ahmedramadan.4cashier/internal/bootstrap"
 	"githup.ahmedramadan.4cashier/internal/models"
+	"githup.ahmedramadan.4cashier/internal/moderation"
+	"githup.ahmedramadan.4cashier/internal/repos"
 	mylogger "githup.ahmedramadan.4cashier/internal/mylogger"
 	"githup.ahmedramadan.4cashier/internal/service" 
 )
@@ -40,6 +46,7 @@ func (h *HRHandler) GetHRProfiles(ctx fiber.Ctx) error {
 		"company_name": ctx.Query("company_name"),
 		"job_position": ctx.Query("job_position"),
 		"verified":     parseBoolOrDefault(ctx.Query("verified"), false),
+		"sort":         ctx.Query("sort"), // e.g. rate:desc (weighted), raw_rate:desc, total_rates_count
 	}
 
 	log.Println("Filters:", filters)
@@ -55,6 +62,221 @@ func (h *HRHandler) GetHRProfiles(ctx fiber.Ctx) error {
 	})
 }
 
+// ------------------------------------------------------------------
+// GET /hr/:id (تفاصيل بروفايل HR مع متوسط كل معيار)
+// ------------------------------------------------------------------
+func (h *HRHandler) GetHRProfile(ctx fiber.Ctx) error {
+	hrID, err := strconv.Atoi(ctx.Params("id"))
+	if err != nil {
+		return ctx.Status(400).JSON(fiber.Map{"error": "Invalid HR ID"})
+	}
+
+	profile, err := h.Service.GetHRProfile(ctx.Context(), hrID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "HR profile not found"})
+	}
+	if err != nil {
+		mylogger.HandleLogging(h.Logger, err, "Failed to fetch HR profile")
+		return ctx.Status(500).JSON(fiber.Map{"error": "Failed to fetch HR profile"})
+	}
+
+	return ctx.JSON(profile)
+}
+
+// ------------------------------------------------------------------
+// GET /hr/:id/ratings/summary (توزيع النجوم والاتجاه الشهري، ?months=12)
+// ------------------------------------------------------------------
+func (h *HRHandler) GetRatingSummary(ctx fiber.Ctx) error {
+	hrID, err := strconv.Atoi(ctx.Params("id"))
+	if err != nil {
+		return ctx.Status(400).JSON(fiber.Map{"error": "Invalid HR ID"})
+	}
+
+	months := parseIntOrDefault(ctx.Query("months"), 12)
+	if months < 1 || months > 60 {
+		return ctx.Status(400).JSON(fiber.Map{"error": "months must be between 1 and 60"})
+	}
+
+	summary, err := h.Service.GetRatingSummary(ctx.Context(), hrID, months)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "HR profile not found"})
+	}
+	if err != nil {
+		mylogger.HandleLogging(h.Logger, err, "Failed to fetch rating summary")
+		return ctx.Status(500).JSON(fiber.Map{"error": "Failed to fetch rating summary"})
+	}
+
+	return ctx.JSON(summary)
+}
+
+// ------------------------------------------------------------------
+// GET /hr/aspects (الجوانب التي يكتشفها تحليل المشاعر)
+// ------------------------------------------------------------------
+func (h *HRHandler) GetAspects(ctx fiber.Ctx) error {
+	return ctx.JSON(fiber.Map{"items": h.Service.GetAspects()})
+}
+
+// ------------------------------------------------------------------
+// GET /hr/aspects/:aspect/leaders (الأكثر مدحاً في جانب، ?order=criticized للأكثر انتقاداً)
+// ------------------------------------------------------------------
+func (h *HRHandler) GetAspectLeaders(ctx fiber.Ctx) error {
+	pagination := bootstrap.GetPagination(ctx)
+
+	order := ctx.Query("order", "praised")
+	if order != "praised" && order != "criticized" {
+		return ctx.Status(400).JSON(fiber.Map{"error": "order must be praised or criticized"})
+	}
+	minRates := parseIntOrDefault(ctx.Query("min_rates"), 3)
+	if minRates < 1 {
+		return ctx.Status(400).JSON(fiber.Map{"error": "min_rates must be at least 1"})
+	}
+
+	items, err := h.Service.GetAspectLeaders(ctx.Context(), ctx.Params("aspect"), order == "criticized", minRates, pagination)
+	if errors.Is(err, service.ErrUnknownAspect) {
+		return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+	}
+	if err != nil {
+		mylogger.HandleLogging(h.Logger, err, "Failed to rank profiles by aspect")
+		return ctx.Status(500).JSON(fiber.Map{"error": "Failed to rank profiles by aspect"})
+	}
+
+	return ctx.JSON(fiber.Map{"items": items})
+}
+
+// ------------------------------------------------------------------
+// GET /hr/rating-criteria (معايير التقييم، ?context= لمعايير سياق معين)
+// ------------------------------------------------------------------
+func (h *HRHandler) GetRatingCriteria(ctx fiber.Ctx) error {
+	criteria, err := h.Service.GetRatingCriteria(ctx.Context(), !parseBoolOrDefault(ctx.Query("all"), false), ctx.Query("context"))
+	if err != nil {
+		mylogger.HandleLogging(h.Logger, err, "Failed to fetch rating criteria")
+		return ctx.Status(500).JSON(fiber.Map{"error": "Failed to fetch rating criteria"})
+	}
+
+	return ctx.JSON(fiber.Map{"items": criteria})
+}
+
+// ------------------------------------------------------------------
+// POST /api/admin/rating-criteria (إضافة معيار - للمشرف)
+// ------------------------------------------------------------------
+func (h *HRHandler) CreateRatingCriterion(ctx fiber.Ctx) error {
+	criterion := models.RatingCriterion{IsActive: true}
+	if err := ctx.Bind().Body(&criterion); err != nil {
+		return ctx.Status(400).JSON(fiber.Map{"error": "Invalid criterion data"})
+	}
+	if err := models.Validate.Struct(criterion); err != nil {
+		return ctx.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	id, err := h.Service.CreateRatingCriterion(ctx.Context(), &criterion)
+	if errors.Is(err, repos.ErrUnknownContextCode) {
+		return ctx.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+	if err != nil {
+		mylogger.HandleLogging(h.Logger, err, "Failed to create rating criterion")
+		return ctx.Status(500).JSON(fiber.Map{"error": "Failed to create rating criterion"})
+	}
+
+	return ctx.Status(fiber.StatusCreated).JSON(fiber.Map{"id": id})
+}
+
+// ------------------------------------------------------------------
+// PUT /api/admin/rating-criteria/:id (تعديل معيار - للمشرف)
+// ------------------------------------------------------------------
+func (h *HRHandler) UpdateRatingCriterion(ctx fiber.Ctx) error {
+	criterionID, err := strconv.Atoi(ctx.Params("id"))
+	if err != nil {
+		return ctx.Status(400).JSON(fiber.Map{"error": "Invalid criterion ID"})
+	}
+
+	var criterion models.RatingCriterion
+	if err := ctx.Bind().Body(&criterion); err != nil {
+		return ctx.Status(400).JSON(fiber.Map{"error": "Invalid criterion data"})
+	}
+	criterion.ID = criterionID
+	if err := models.Validate.Struct(criterion); err != nil {
+		return ctx.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	err = h.Service.UpdateRatingCriterion(ctx.Context(), &criterion)
+	if errors.Is(err, repos.ErrCriterionNotFound) {
+		return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Criterion not found"})
+	}
+	if errors.Is(err, repos.ErrUnknownContextCode) {
+		return ctx.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+	if err != nil {
+		mylogger.HandleLogging(h.Logger, err, "Failed to update rating criterion")
+		return ctx.Status(500).JSON(fiber.Map{"error": "Failed to update rating criterion"})
+	}
+
+	return ctx.SendStatus(204)
+}
+
+// ------------------------------------------------------------------
+// GET /hr/rating-contexts (سياقات التقييم)
+// ------------------------------------------------------------------
+func (h *HRHandler) GetRatingContexts(ctx fiber.Ctx) error {
+	contexts, err := h.Service.GetRatingContexts(ctx.Context(), !parseBoolOrDefault(ctx.Query("all"), false))
+	if err != nil {
+		mylogger.HandleLogging(h.Logger, err, "Failed to fetch rating contexts")
+		return ctx.Status(500).JSON(fiber.Map{"error": "Failed to fetch rating contexts"})
+	}
+
+	return ctx.JSON(fiber.Map{"items": contexts})
+}
+
+// ------------------------------------------------------------------
+// POST /api/admin/rating-contexts (إضافة سياق - للمشرف)
+// ------------------------------------------------------------------
+func (h *HRHandler) CreateRatingContext(ctx fiber.Ctx) error {
+	ratingContext := models.RatingContext{IsActive: true}
+	if err := ctx.Bind().Body(&ratingContext); err != nil {
+		return ctx.Status(400).JSON(fiber.Map{"error": "Invalid rating context data"})
+	}
+	if err := models.Validate.Struct(ratingContext); err != nil {
+		return ctx.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	id, err := h.Service.CreateRatingContext(ctx.Context(), &ratingContext)
+	if err != nil {
+		mylogger.HandleLogging(h.Logger, err, "Failed to create rating context")
+		return ctx.Status(500).JSON(fiber.Map{"error": "Failed to create rating context"})
+	}
+
+	return ctx.Status(fiber.StatusCreated).JSON(fiber.Map{"id": id})
+}
+
+// ------------------------------------------------------------------
+// PUT /api/admin/rating-contexts/:id (تعديل سياق - للمشرف)
+// ------------------------------------------------------------------
+func (h *HRHandler) UpdateRatingContext(ctx fiber.Ctx) error {
+	contextID, err := strconv.Atoi(ctx.Params("id"))
+	if err != nil {
+		return ctx.Status(400).JSON(fiber.Map{"error": "Invalid rating context ID"})
+	}
+
+	var ratingContext models.RatingContext
+	if err := ctx.Bind().Body(&ratingContext); err != nil {
+		return ctx.Status(400).JSON(fiber.Map{"error": "Invalid rating context data"})
+	}
+	ratingContext.ID = contextID
+	if err := models.Validate.Struct(ratingContext); err != nil {
+		return ctx.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	err = h.Service.UpdateRatingContext(ctx.Context(), &ratingContext)
+	if errors.Is(err, repos.ErrContextNotFound) {
+		return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Rating context not found"})
+	}
+	if err != nil {
+		mylogger.HandleLogging(h.Logger, err, "Failed to update rating context")
+		return ctx.Status(500).JSON(fiber.Map{"error": "Failed to update rating context"})
+	}
+
+	return ctx.SendStatus(204)
+}
+
 // ------------------------------------------------------------------
 // GET /rates (عرض التقييمات)
 // ------------------------------------------------------------------
@@ -67,15 +289,27 @@ func (h *HRHandler) GetRates(ctx fiber.Ctx) error {
 		"employee_id":   parseIntOrDefault(ctx.Query("employee_id"), 0),
 		"min_rate":      parseFloat32OrDefault(ctx.Query("min_rate"), 0.0), // Changed to float32
 		"max_rate":      parseFloat32OrDefault(ctx.Query("max_rate"), 5.0), // Changed to float32
-		"is_verified":   parseBoolOrDefault(ctx.Query("is_verified"), false),
 		"review_text":   ctx.Query("review_text"),
-		"sort_column":   ctx.Query("sort_column"),
+		"verification_method": ctx.Query("verification_method"), // work_email | document | invite_token
+		"rating_context": ctx.Query("rating_context"),            // rating_contexts.code
+		"sort":          ctx.Query("sort"), // newest | oldest | most_helpful | highest | lowest
+		"sentiment":     ctx.Query("sentiment"),        // positive | negative | neutral
+		"aspect":        ctx.Query("aspect"),           // salary | communication | respect | delays
+		"aspect_sentiment": ctx.Query("aspect_sentiment"), // positive | negative, with aspect
+	}
+	// only filter by verification when asked, otherwise verified rates would be hidden
+	if isVerified := ctx.Query("is_verified"); isVerified != "" {
+		filters["is_verified"] = parseBoolOrDefault(isVerified, false)
+	}
+	// moderators only: stars that contradict the text
+	if contradiction := ctx.Query("sentiment_contradiction"); contradiction != "" {
+		filters["sentiment_contradiction"] = parseBoolOrDefault(contradiction, false)
 	}
 
 	log.Println("Filters:", filters)
 
 	// items will be []models.RateWithDetails
-	items, err := h.Service.GetRates(ctx.Context(), pagination, filters)
+	items, err := h.Service.GetRates(ctx.Context(), viewerFrom(ctx), pagination, filters)
 	if err != nil {
 		mylogger.HandleLogging(h.Logger, err, "Failed to fetch rates")
 		return ctx.Status(500).JSON(fiber.Map{"error": "Failed to fetch rates"})
@@ -90,39 +324,321 @@ func (h *HRHandler) GetRates(ctx fiber.Ctx) error {
 // POST /hr/rate (تقييم HR)
 // ------------------------------------------------------------------
 func (h *HRHandler) RateHR(ctx fiber.Ctx) error {
-	var rate models.Rate
-	if err := ctx.Bind().Body(&rate); err != nil {
+	user, ok := currentUser(ctx)
+	if !ok {
+		return ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+
+	var req CreateRateRequest
+	if err := ctx.Bind().Body(&req); err != nil {
 		return ctx.Status(400).JSON(fiber.Map{"error": "Invalid rate data"})
 	}
+	if err := models.Validate.Struct(req); err != nil {
+		return ctx.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+	// the author always comes from the token, never from the body
+	rate := models.Rate{
+		HRProfileID:   req.HRProfileID,
+		EmployeeID:    user.UserID,
+		ReviewText:    req.ReviewText,
+		RateValue:     req.RateValue,
+		RatingContext: req.RatingContext,
+		RatingContextNote: req.RatingContextNote,
+		IsAnonymous:   req.IsAnonymous,
+		Scores:        req.Scores,
+	}
+	// request data for fraud scoring
+	clientIP := ctx.IP()
+	rate.ClientIP = &clientIP
+	if fingerprint := ctx.Get("X-Device-Fingerprint"); fingerprint != "" && len(fingerprint) <= 128 {
+		rate.DeviceFingerprint = &fingerprint
+	}
 
 	// ⭐️ ENHANCEMENT: RateHR service returns the full HRProfile (potentially with new badges)
 	profile, err := h.Service.RateHR(ctx.Context(), &rate) 
 	if err != nil {
-		mylogger.HandleLogging(h.Logger, err, "Failed to rate HR")
-		return ctx.Status(500).JSON(fiber.Map{"error": "Failed to rate HR"})
+		var conflict *service.RateConflictError
+		if errors.As(err, &conflict) {
+			body := fiber.Map{
+				"error":        "You have already rated this HR",
+				"available_at": conflict.AvailableAt,
+			}
+			// a deleted rate still counts for the cooldown but cannot be linked to
+			if conflict.ExistingRateID > 0 {
+				body["existing_rate_id"] = conflict.ExistingRateID
+				body["existing_rate_url"] = fmt.Sprintf("/hr/rate/%d", conflict.ExistingRateID)
+			}
+			return ctx.Status(fiber.StatusConflict).JSON(body)
+		}
+		return h.rateErrorResponse(ctx, err, "Failed to rate HR")
+	}
+
+	// held or rejected by moderation: the profile did not change, tell the author why
+	if rate.Status != moderation.StatusPublished {
+		return ctx.Status(fiber.StatusAccepted).JSON(fiber.Map{
+			"rate_id":            rate.ID,
+			"status":             rate.Status,
+			"moderation_reasons": rate.ModerationReasons,
+		})
 	}
 
 	// Return the updated profile which includes the new average rate and potentially badges
 	return ctx.JSON(profile)
 }
 
+// CreateRateRequest is a new rate. Verification and the HR response are not
+// part of it: only their own flows set them.
+type CreateRateRequest struct {
+	HRProfileID int `json:"hr_profile_id" validate:"required,gt=0"`
+	UpdateRateRequest
+}
+
+// UpdateRateRequest is the editable part of a rate.
+type UpdateRateRequest struct {
+	ReviewText    string  `json:"review_text" validate:"required,min=5,max=2000"`
+	RateValue     float32 `json:"rate_value" validate:"gte=0,lte=5"`
+	RatingContext *string `json:"rating_context"`
+	RatingContextNote *string `json:"rating_context_note" validate:"omitempty,max=500"`
+	IsAnonymous   bool    `json:"is_anonymous"`
+
+	Scores []models.RateScore `json:"scores" validate:"omitempty,dive"`
+}
+
+// rateErrorResponse maps repository rate errors to HTTP responses.
+func (h *HRHandler) rateErrorResponse(ctx fiber.Ctx, err error, message string) error {
+	switch {
+	case errors.Is(err, repos.ErrRateNotFound):
+		return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Rate not found"})
+	case errors.Is(err, repos.ErrRateForbidden):
+		return ctx.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "You can only change your own rates"})
+	case errors.Is(err, repos.ErrNotRateOwner):
+		return ctx.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "You can only respond to rates about your profile"})
+	case errors.Is(err, repos.ErrSelfVote):
+		return ctx.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, service.ErrInvalidRateScores), errors.Is(err, repos.ErrUnknownCriterion), errors.Is(err, repos.ErrDuplicateCriterion),
+		errors.Is(err, service.ErrUnknownRatingContext),
+		errors.Is(err, service.ErrInvalidHRResponse), errors.Is(err, service.ErrInvalidModerationStatus):
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	mylogger.HandleLogging(h.Logger, err, message)
+	return ctx.Status(500).JSON(fiber.Map{"error": message})
+}
+
+// ------------------------------------------------------------------
+// GET /hr/rate/:id (عرض تقييم واحد)
+// ------------------------------------------------------------------
+func (h *HRHandler) GetRate(ctx fiber.Ctx) error {
+	rateID, err := strconv.Atoi(ctx.Params("id"))
+	if err != nil {
+		return ctx.Status(400).JSON(fiber.Map{"error": "Invalid rate ID"})
+	}
+
+	rate, err := h.Service.GetRate(ctx.Context(), viewerFrom(ctx), rateID)
+	if err != nil {
+		return h.rateErrorResponse(ctx, err, "Failed to fetch rate")
+	}
+
+	return ctx.JSON(rate)
+}
+
+// ------------------------------------------------------------------
+// PUT /hr/rate/:id (تعديل تقييم)
+// ------------------------------------------------------------------
+func (h *HRHandler) UpdateRate(ctx fiber.Ctx) error {
+	user, ok := currentUser(ctx)
+	if !ok {
+		return ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+
+	rateID, err := strconv.Atoi(ctx.Params("id"))
+	if err != nil {
+		return ctx.Status(400).JSON(fiber.Map{"error": "Invalid rate ID"})
+	}
+
+	var req UpdateRateRequest
+	if err := ctx.Bind().Body(&req); err != nil {
+		return ctx.Status(400).JSON(fiber.Map{"error": "Invalid rate data"})
+	}
+	if err := models.Validate.Struct(req); err != nil {
+		return ctx.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	rate := models.Rate{
+		ID:            rateID,
+		EmployeeID:    user.UserID,
+		ReviewText:    req.ReviewText,
+		RateValue:     req.RateValue,
+		RatingContext: req.RatingContext,
+		RatingContextNote: req.RatingContextNote,
+		IsAnonymous:   req.IsAnonymous,
+		Scores:        req.Scores,
+	}
+
+	profile, err := h.Service.UpdateRate(ctx.Context(), &rate)
+	if err != nil {
+		return h.rateErrorResponse(ctx, err, "Failed to update rate")
+	}
+
+	return ctx.JSON(fiber.Map{"rate": rate, "profile": profile})
+}
+
+// ------------------------------------------------------------------
+// DELETE /hr/rate/:id (حذف تقييم)
+// ------------------------------------------------------------------
+func (h *HRHandler) DeleteRate(ctx fiber.Ctx) error {
+	user, ok := currentUser(ctx)
+	if !ok {
+		return ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+
+	rateID, err := strconv.Atoi(ctx.Params("id"))
+	if err != nil {
+		return ctx.Status(400).JSON(fiber.Map{"error": "Invalid rate ID"})
+	}
+
+	profile, err := h.Service.DeleteRate(ctx.Context(), rateID, user.UserID)
+	if err != nil {
+		return h.rateErrorResponse(ctx, err, "Failed to delete rate")
+	}
+
+	return ctx.JSON(profile)
+}
+
+// ------------------------------------------------------------------
+// GET /hr/rate/:id/revisions (سجل تعديلات التقييم - للكاتب وصاحب البروفايل)
+// ------------------------------------------------------------------
+func (h *HRHandler) GetRateRevisions(ctx fiber.Ctx) error {
+	user, ok := currentUser(ctx)
+	if !ok {
+		return ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+
+	rateID, err := strconv.Atoi(ctx.Params("id"))
+	if err != nil {
+		return ctx.Status(400).JSON(fiber.Map{"error": "Invalid rate ID"})
+	}
+
+	rate, err := h.Service.GetRate(ctx.Context(), viewerFrom(ctx), rateID)
+	if err != nil {
+		return h.rateErrorResponse(ctx, err, "Failed to fetch rate")
+	}
+
+	isAuthor := user.Role == "employee" && user.UserID == rate.EmployeeID
+	isOwner := user.Role == "hr" && user.UserID == rate.HRProfileID
+	if !isAuthor && !isOwner && user.Role != "admin" {
+		return ctx.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Access denied"})
+	}
+
+	revisions, err := h.Service.GetRateRevisions(ctx.Context(), rateID)
+	if err != nil {
+		return h.rateErrorResponse(ctx, err, "Failed to fetch rate revisions")
+	}
+
+	return ctx.JSON(fiber.Map{
+		"rate":      rate,
+		"edited":    rate.EditedAt != nil,
+		"revisions": revisions,
+	})
+}
+
+// ------------------------------------------------------------------
+// PUT /hr/rate/:id/response (رد الـ HR على التقييم - إضافة أو تعديل)
+// ------------------------------------------------------------------
+func (h *HRHandler) RespondToRate(ctx fiber.Ctx) error {
+	user, ok := currentUser(ctx)
+	if !ok {
+		return ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+
+	rateID, err := strconv.Atoi(ctx.Params("id"))
+	if err != nil {
+		return ctx.Status(400).JSON(fiber.Map{"error": "Invalid rate ID"})
+	}
+
+	var req struct {
+		Response string `json:"response"`
+	}
+	if err := ctx.Bind().Body(&req); err != nil {
+		return ctx.Status(400).JSON(fiber.Map{"error": "Invalid response data"})
+	}
+
+	rate, err := h.Service.RespondToRate(ctx.Context(), user.UserID, rateID, req.Response)
+	if err != nil {
+		return h.rateErrorResponse(ctx, err, "Failed to save response")
+	}
+
+	return ctx.JSON(rate)
+}
+
+// ------------------------------------------------------------------
+// DELETE /hr/rate/:id/response (حذف رد الـ HR)
+// ------------------------------------------------------------------
+func (h *HRHandler) DeleteRateResponse(ctx fiber.Ctx) error {
+	user, ok := currentUser(ctx)
+	if !ok {
+		return ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+
+	rateID, err := strconv.Atoi(ctx.Params("id"))
+	if err != nil {
+		return ctx.Status(400).JSON(fiber.Map{"error": "Invalid rate ID"})
+	}
+
+	if err := h.Service.DeleteRateResponse(ctx.Context(), user.UserID, rateID); err != nil {
+		return h.rateErrorResponse(ctx, err, "Failed to delete response")
+	}
+
+	return ctx.SendStatus(204)
+}
+
 // ------------------------------------------------------------------
 // POST /hr/rate/like (إعجاب/عدم إعجاب بتقييم)
 // ------------------------------------------------------------------
 func (h *HRHandler) LikeRate(ctx fiber.Ctx) error {
+	user, ok := currentUser(ctx)
+	if !ok {
+		return ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+
 	var like models.RateLike
 	if err := ctx.Bind().Body(&like); err != nil {
 		return ctx.Status(400).JSON(fiber.Map{"error": "Invalid like data"})
 	}
+	// the voter always comes from the token, never from the body
+	like.EmployeeID = user.UserID
 
-	// The service returns the new likes count, useful for frontend update
-	newLikesCount, err := h.Service.LikeRate(ctx.Context(), &like)
+	// The service returns the new counters, useful for frontend update
+	result, err := h.Service.LikeRate(ctx.Context(), &like)
 	if err != nil {
-		mylogger.HandleLogging(h.Logger, err, "Failed to like rate")
-		return ctx.Status(500).JSON(fiber.Map{"error": "Failed to like rate"})
+		return h.rateErrorResponse(ctx, err, "Failed to like rate")
 	}
 
-	return ctx.JSON(fiber.Map{"likes_count": newLikesCount})
+	return ctx.JSON(result)
+}
+
+// ------------------------------------------------------------------
+// DELETE /hr/rate/:id/like (سحب الإعجاب/عدم الإعجاب)
+// ------------------------------------------------------------------
+func (h *HRHandler) DeleteRateVote(ctx fiber.Ctx) error {
+	user, ok := currentUser(ctx)
+	if !ok {
+		return ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+
+	rateID, err := strconv.Atoi(ctx.Params("id"))
+	if err != nil {
+		return ctx.Status(400).JSON(fiber.Map{"error": "Invalid rate ID"})
+	}
+
+	result, err := h.Service.RemoveRateVote(ctx.Context(), rateID, user.UserID)
+	if err != nil {
+		if errors.Is(err, repos.ErrVoteNotFound) {
+			return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+		}
+		return h.rateErrorResponse(ctx, err, "Failed to remove vote")
+	}
+
+	return ctx.JSON(result)
 }
 
 // ------------------------------------------------------------------
@@ -173,32 +689,116 @@ func (h *HRHandler) AddJobRoles(ctx fiber.Ctx) error {
 // POST /hr/badge/like (إعجاب/عدم إعجاب بشارة)
 // ------------------------------------------------------------------
 func (h *HRHandler) LikeBadge(ctx fiber.Ctx) error {
+	user, ok := currentUser(ctx)
+	if !ok {
+		return ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+
 	var like models.BadgeLike
 	if err := ctx.Bind().Body(&like); err != nil {
 		return ctx.Status(400).JSON(fiber.Map{"error": "Invalid like data"})
 	}
+	// the voter always comes from the token, never from the body
+	like.EmployeeID = user.UserID
+	if err := models.Validate.Struct(like); err != nil {
+		return ctx.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
 
-	// Assuming LikeBadge service returns error only
-	if err := h.Service.LikeBadge(ctx.Context(), &like); err != nil {
+	result, err := h.Service.LikeBadge(ctx.Context(), &like)
+	if errors.Is(err, repos.ErrBadgeNotFound) {
+		return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Badge not found"})
+	}
+	if err != nil {
 		mylogger.HandleLogging(h.Logger, err, "Failed to like badge")
 		return ctx.Status(500).JSON(fiber.Map{"error": "Failed to like badge"})
 	}
 
-	return ctx.SendStatus(204)
+	return ctx.JSON(result)
 }
 
 // ------------------------------------------------------------------
-// POST /hr/badge (منح شارة يدوياً - اختياري)
+// DELETE /badges/:id/like (سحب الإعجاب/عدم الإعجاب على شارة)
+// ------------------------------------------------------------------
+func (h *HRHandler) DeleteBadgeVote(ctx fiber.Ctx) error {
+	user, ok := currentUser(ctx)
+	if !ok {
+		return ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+
+	badgeID, err := strconv.Atoi(ctx.Params("id"))
+	if err != nil {
+		return ctx.Status(400).JSON(fiber.Map{"error": "Invalid badge ID"})
+	}
+
+	result, err := h.Service.RemoveBadgeVote(ctx.Context(), badgeID, user.UserID)
+	switch {
+	case errors.Is(err, repos.ErrBadgeNotFound):
+		return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Badge not found"})
+	case errors.Is(err, repos.ErrVoteNotFound):
+		return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "You have not voted on this badge"})
+	case err != nil:
+		mylogger.HandleLogging(h.Logger, err, "Failed to remove badge vote")
+		return ctx.Status(500).JSON(fiber.Map{"error": "Failed to remove badge vote"})
+	}
+
+	return ctx.JSON(result)
+}
+
+// ------------------------------------------------------------------
+// GET /badges/:id (تفاصيل الشارة مع عدد الإعجابات وتصويت المستخدم)
+// ------------------------------------------------------------------
+func (h *HRHandler) GetBadge(ctx fiber.Ctx) error {
+	badgeID, err := strconv.Atoi(ctx.Params("id"))
+	if err != nil {
+		return ctx.Status(400).JSON(fiber.Map{"error": "Invalid badge ID"})
+	}
+
+	badge, err := h.Service.GetBadge(ctx.Context(), badgeID, viewerFrom(ctx))
+	if errors.Is(err, repos.ErrBadgeNotFound) {
+		return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Badge not found"})
+	}
+	if err != nil {
+		mylogger.HandleLogging(h.Logger, err, "Failed to fetch badge")
+		return ctx.Status(500).JSON(fiber.Map{"error": "Failed to fetch badge"})
+	}
+
+	return ctx.JSON(badge)
+}
+
+// ------------------------------------------------------------------
+// GET /badges/recent (آخر الشارات الممنوحة)
+// ------------------------------------------------------------------
+func (h *HRHandler) GetRecentBadges(ctx fiber.Ctx) error {
+	badges, err := h.Service.GetRecentBadges(ctx.Context(), bootstrap.GetPagination(ctx), viewerFrom(ctx))
+	if err != nil {
+		mylogger.HandleLogging(h.Logger, err, "Failed to fetch recent badges")
+		return ctx.Status(500).JSON(fiber.Map{"error": "Failed to fetch recent badges"})
+	}
+
+	return ctx.JSON(fiber.Map{"items": badges})
+}
+
+// ------------------------------------------------------------------
+// POST /api/admin/badges (منح شارة يدوياً - للمشرف)
 // ------------------------------------------------------------------
 func (h *HRHandler) AwardBadge(ctx fiber.Ctx) error {
 	var badge models.Badge
 	if err := ctx.Bind().Body(&badge); err != nil {
 		return ctx.Status(400).JSON(fiber.Map{"error": "Invalid badge data"})
 	}
+	if err := models.Validate.Struct(badge); err != nil {
+		return ctx.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
 
 	// Note: AwardBadge is usually done by the system (RateHR), 
 	// but this manual handler remains for admin use.
 	id, err := h.Service.AwardBadge(ctx.Context(), &badge)
+	if errors.Is(err, service.ErrUnknownBadgeDefinition) {
+		return ctx.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+	if errors.Is(err, repos.ErrBadgeAlreadyAwarded) {
+		return ctx.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+	}
 	if err != nil {
 		mylogger.HandleLogging(h.Logger, err, "Failed to award badge")
 		return ctx.Status(500).JSON(fiber.Map{"error": "Failed to award badge"})
@@ -207,6 +807,194 @@ func (h *HRHandler) AwardBadge(ctx fiber.Ctx) error {
 	return ctx.JSON(fiber.Map{"id": id, "message": "Badge awarded manually"})
 }
 
+// ------------------------------------------------------------------
+// GET /hr/badge-definitions (كتالوج الشارات، ?all=true يشمل المعطلة)
+// ------------------------------------------------------------------
+func (h *HRHandler) GetBadgeDefinitions(ctx fiber.Ctx) error {
+	definitions, err := h.Service.GetBadgeDefinitions(ctx.Context(), !parseBoolOrDefault(ctx.Query("all"), false))
+	if err != nil {
+		mylogger.HandleLogging(h.Logger, err, "Failed to fetch badge definitions")
+		return ctx.Status(500).JSON(fiber.Map{"error": "Failed to fetch badge definitions"})
+	}
+
+	return ctx.JSON(fiber.Map{"items": definitions})
+}
+
+// ------------------------------------------------------------------
+// POST /api/admin/badge-definitions (إضافة شارة للكتالوج - للمشرف)
+// ------------------------------------------------------------------
+func (h *HRHandler) CreateBadgeDefinition(ctx fiber.Ctx) error {
+	definition := models.BadgeDefinition{IsActive: true, Tier: "bronze", Category: "general"}
+	if err := ctx.Bind().Body(&definition); err != nil {
+		return ctx.Status(400).JSON(fiber.Map{"error": "Invalid badge definition data"})
+	}
+	if err := models.Validate.Struct(definition); err != nil {
+		return ctx.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	id, err := h.Service.CreateBadgeDefinition(ctx.Context(), &definition)
+	if errors.Is(err, service.ErrInvalidBadgeRule) || errors.Is(err, service.ErrInvalidBadgeAvailability) {
+		return ctx.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+	if errors.Is(err, repos.ErrBadgeDefinitionExists) {
+		return ctx.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+	}
+	if err != nil {
+		mylogger.HandleLogging(h.Logger, err, "Failed to create badge definition")
+		return ctx.Status(500).JSON(fiber.Map{"error": "Failed to create badge definition"})
+	}
+
+	return ctx.Status(fiber.StatusCreated).JSON(fiber.Map{"id": id})
+}
+
+// ------------------------------------------------------------------
+// PUT /api/admin/badge-definitions/:id (تعديل شارة في الكتالوج - للمشرف)
+// ------------------------------------------------------------------
+func (h *HRHandler) UpdateBadgeDefinition(ctx fiber.Ctx) error {
+	definitionID, err := strconv.Atoi(ctx.Params("id"))
+	if err != nil {
+		return ctx.Status(400).JSON(fiber.Map{"error": "Invalid badge definition ID"})
+	}
+
+	var definition models.BadgeDefinition
+	if err := ctx.Bind().Body(&definition); err != nil {
+		return ctx.Status(400).JSON(fiber.Map{"error": "Invalid badge definition data"})
+	}
+	definition.ID = definitionID
+	if err := models.Validate.Struct(definition); err != nil {
+		return ctx.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	err = h.Service.UpdateBadgeDefinition(ctx.Context(), &definition)
+	switch {
+	case errors.Is(err, service.ErrInvalidBadgeRule), errors.Is(err, service.ErrInvalidBadgeAvailability):
+		return ctx.Status(400).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, repos.ErrBadgeDefinitionNotFound):
+		return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Badge definition not found"})
+	case errors.Is(err, repos.ErrBadgeDefinitionExists):
+		return ctx.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+	case err != nil:
+		mylogger.HandleLogging(h.Logger, err, "Failed to update badge definition")
+		return ctx.Status(500).JSON(fiber.Map{"error": "Failed to update badge definition"})
+	}
+
+	return ctx.SendStatus(204)
+}
+
+// ------------------------------------------------------------------
+// DELETE /api/admin/badge-definitions/:id (حذف شارة لم تُمنح بعد - للمشرف)
+// ------------------------------------------------------------------
+func (h *HRHandler) DeleteBadgeDefinition(ctx fiber.Ctx) error {
+	definitionID, err := strconv.Atoi(ctx.Params("id"))
+	if err != nil {
+		return ctx.Status(400).JSON(fiber.Map{"error": "Invalid badge definition ID"})
+	}
+
+	err = h.Service.DeleteBadgeDefinition(ctx.Context(), definitionID)
+	switch {
+	case errors.Is(err, repos.ErrBadgeDefinitionNotFound):
+		return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Badge definition not found"})
+	case errors.Is(err, repos.ErrBadgeDefinitionInUse):
+		return ctx.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+	case err != nil:
+		mylogger.HandleLogging(h.Logger, err, "Failed to delete badge definition")
+		return ctx.Status(500).JSON(fiber.Map{"error": "Failed to delete badge definition"})
+	}
+
+	return ctx.SendStatus(204)
+}
+
+// ------------------------------------------------------------------
+// POST /api/admin/badge-definitions/dry-run (من سيستحق الشارة دون منحها - للمشرف)
+// ------------------------------------------------------------------
+func (h *HRHandler) DryRunBadgeRule(ctx fiber.Ctx) error {
+	var req struct {
+		Rule         string `json:"rule"`
+		DefinitionID *int   `json:"definition_id"`
+		Limit        int    `json:"limit"`
+	}
+	if err := ctx.Bind().Body(&req); err != nil {
+		return ctx.Status(400).JSON(fiber.Map{"error": "Invalid dry-run data"})
+	}
+
+	result, err := h.Service.DryRunBadgeRule(ctx.Context(), req.Rule, req.DefinitionID, req.Limit)
+	switch {
+	case errors.Is(err, service.ErrInvalidBadgeRule):
+		return ctx.Status(400).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, repos.ErrBadgeDefinitionNotFound):
+		return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Badge definition not found"})
+	case err != nil:
+		mylogger.HandleLogging(h.Logger, err, "Failed to dry-run badge rule")
+		return ctx.Status(500).JSON(fiber.Map{"error": "Failed to dry-run badge rule"})
+	}
+
+	return ctx.JSON(result)
+}
+
+// ------------------------------------------------------------------
+// GET /api/admin/badge-rules/metrics (المقاييس المتاحة لكتابة القواعد)
+// ------------------------------------------------------------------
+func (h *HRHandler) GetBadgeRuleMetrics(ctx fiber.Ctx) error {
+	return ctx.JSON(fiber.Map{"items": badgerules.Specs, "max_window_days": badgerules.MaxWindowDays})
+}
+
+// ------------------------------------------------------------------
+// GET /hr/:id/badges (الشارات الفعالة وسجل الشارات المنتهية والمسحوبة)
+// ------------------------------------------------------------------
+func (h *HRHandler) GetProfileBadges(ctx fiber.Ctx) error {
+	hrID, err := strconv.Atoi(ctx.Params("id"))
+	if err != nil {
+		return ctx.Status(400).JSON(fiber.Map{"error": "Invalid HR ID"})
+	}
+
+	badges, err := h.Service.GetProfileBadges(ctx.Context(), hrID, viewerFrom(ctx))
+	if errors.Is(err, sql.ErrNoRows) {
+		return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "HR profile not found"})
+	}
+	if err != nil {
+		mylogger.HandleLogging(h.Logger, err, "Failed to fetch HR badges")
+		return ctx.Status(500).JSON(fiber.Map{"error": "Failed to fetch HR badges"})
+	}
+
+	return ctx.JSON(badges)
+}
+
+// ------------------------------------------------------------------
+// POST /api/admin/badges/:id/revoke (سحب شارة مع ذكر السبب - للمشرف)
+// ------------------------------------------------------------------
+func (h *HRHandler) RevokeBadge(ctx fiber.Ctx) error {
+	user, ok := currentUser(ctx)
+	if !ok {
+		return ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+
+	badgeID, err := strconv.Atoi(ctx.Params("id"))
+	if err != nil {
+		return ctx.Status(400).JSON(fiber.Map{"error": "Invalid badge ID"})
+	}
+
+	var req struct {
+		Reason string `json:"reason" validate:"required,min=3,max=500"`
+	}
+	if err := ctx.Bind().Body(&req); err != nil {
+		return ctx.Status(400).JSON(fiber.Map{"error": "Invalid revocation data"})
+	}
+	if err := models.Validate.Struct(req); err != nil {
+		return ctx.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	badge, err := h.Service.RevokeBadge(ctx.Context(), user.UserID, badgeID, req.Reason)
+	if errors.Is(err, repos.ErrBadgeNotFound) {
+		return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+	}
+	if err != nil {
+		mylogger.HandleLogging(h.Logger, err, "Failed to revoke badge")
+		return ctx.Status(500).JSON(fiber.Map{"error": "Failed to revoke badge"})
+	}
+
+	return ctx.JSON(badge)
+}
+
 // ------------------------------------------------------------------
 // ⭐️ Helper Functions (تم إضافة Float32)
 // ------------------------------------------------------------------
@@ -244,12 +1032,35 @@ func (h *HRHandler) GetEmployeeStats(c fiber.Ctx) error {
     }
 
     // 2. جلب الإحصائيات من Repository
-    stats, err := h.Service.GetEmployeeStats(c.Context(), employeeID)
+    stats, err := h.Service.GetEmployeeStats(c.Context(), viewerFrom(c), employeeID)
+    if errors.Is(err, repos.ErrEmployeeNotFound) {
+        return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Employee not found"})
+    }
     if err != nil {
-        // ... (تسجيل الخطأ)
+        mylogger.HandleLogging(h.Logger, err, "Failed to retrieve stats")
         return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to retrieve stats"})
     }
 
     // 3. الإرجاع إلى العميل
     return c.JSON(stats)
-}
\ No newline at end of file
+}
+// ------------------------------------------------------------------
+// GET /employees/me/points (رصيد النقاط وسجل حركاتها)
+// ------------------------------------------------------------------
+func (h *HRHandler) GetMyPoints(ctx fiber.Ctx) error {
+	user, ok := currentUser(ctx)
+	if !ok {
+		return ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+
+	history, err := h.Service.GetPointsHistory(ctx.Context(), user.UserID, bootstrap.GetPagination(ctx))
+	if errors.Is(err, repos.ErrEmployeeNotFound) {
+		return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Employee not found"})
+	}
+	if err != nil {
+		mylogger.HandleLogging(h.Logger, err, "Failed to fetch points history")
+		return ctx.Status(500).JSON(fiber.Map{"error": "Failed to fetch points history"})
+	}
+
+	return ctx.JSON(history)
+}
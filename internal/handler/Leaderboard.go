@@ -0,0 +1,110 @@
+package handler
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/rs/zerolog"
+	"githup.ahmedramadan.4cashier/internal/models"
+	mylogger "githup.ahmedramadan.4cashier/internal/mylogger"
+	"githup.ahmedramadan.4cashier/internal/repos"
+	"githup.ahmedramadan.4cashier/internal/service"
+)
+
+// LeaderboardHandler serves the contributor and HR leaderboards.
+type LeaderboardHandler struct {
+	Logger  zerolog.Logger
+	Service *service.LeaderboardService
+}
+
+// NewLeaderboardHandler creates a new instance of LeaderboardHandler.
+func NewLeaderboardHandler(logger zerolog.Logger, serv *service.LeaderboardService) *LeaderboardHandler {
+	return &LeaderboardHandler{
+		Logger:  logger.With().Str("layer", "handler").Str("component", "LeaderboardHandler").Logger(),
+		Service: serv,
+	}
+}
+
+// leaderboardErrorResponse maps leaderboard errors to HTTP responses.
+func (h *LeaderboardHandler) leaderboardErrorResponse(ctx fiber.Ctx, err error, message string) error {
+	switch {
+	case errors.Is(err, service.ErrInvalidLeaderboard), errors.Is(err, repos.ErrInvalidLeaderboard):
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, repos.ErrSnapshotNotFound):
+		return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+	}
+	mylogger.HandleLogging(h.Logger, err, message)
+	return ctx.Status(500).JSON(fiber.Map{"error": message})
+}
+
+// leaderboardParams reads ?scope=&value=&period=all|month&month=YYYY-MM&limit=
+func leaderboardParams(ctx fiber.Ctx, board string) service.LeaderboardParams {
+	limit, _ := strconv.Atoi(ctx.Query("limit"))
+	return service.LeaderboardParams{
+		Board:  board,
+		Scope:  ctx.Query("scope"),
+		Value:  ctx.Query("value"),
+		Period: ctx.Query("period"),
+		Month:  ctx.Query("month"),
+		Limit:  limit,
+	}
+}
+
+// ------------------------------------------------------------------
+// GET /leaderboards/contributors (?scope=overall|city|job_field&value=&period=all|month&month=YYYY-MM&limit=)
+// ------------------------------------------------------------------
+func (h *LeaderboardHandler) GetContributors(ctx fiber.Ctx) error {
+	return h.getLeaderboard(ctx, models.BoardContributors)
+}
+
+// ------------------------------------------------------------------
+// GET /leaderboards/hrs (?scope=overall|company|job_position&value=&period=all|month&month=YYYY-MM&limit=)
+// ------------------------------------------------------------------
+func (h *LeaderboardHandler) GetHRs(ctx fiber.Ctx) error {
+	return h.getLeaderboard(ctx, models.BoardHRs)
+}
+
+func (h *LeaderboardHandler) getLeaderboard(ctx fiber.Ctx, board string) error {
+	leaderboard, err := h.Service.GetLeaderboard(ctx.Context(), leaderboardParams(ctx, board))
+	if err != nil {
+		return h.leaderboardErrorResponse(ctx, err, "Failed to fetch leaderboard")
+	}
+	return ctx.JSON(leaderboard)
+}
+
+// ------------------------------------------------------------------
+// GET /leaderboards/:board/history (?at=YYYY-MM-DD + نفس خيارات اللوحة)
+// الترتيب كما كان في آخر لقطة قبل التاريخ المطلوب
+// ------------------------------------------------------------------
+func (h *LeaderboardHandler) GetHistory(ctx fiber.Ctx) error {
+	at := ctx.Query("at", time.Now().UTC().Format(time.DateOnly))
+	leaderboard, err := h.Service.GetLeaderboardAt(ctx.Context(), leaderboardParams(ctx, ctx.Params("board")), at)
+	if err != nil {
+		return h.leaderboardErrorResponse(ctx, err, "Failed to fetch leaderboard history")
+	}
+	return ctx.JSON(leaderboard)
+}
+
+// ------------------------------------------------------------------
+// GET /leaderboards/:board/snapshots (?scope=&period=&month=&limit=)
+// ------------------------------------------------------------------
+func (h *LeaderboardHandler) GetSnapshots(ctx fiber.Ctx) error {
+	snapshots, err := h.Service.ListSnapshots(ctx.Context(), leaderboardParams(ctx, ctx.Params("board")))
+	if err != nil {
+		return h.leaderboardErrorResponse(ctx, err, "Failed to fetch leaderboard snapshots")
+	}
+	return ctx.JSON(fiber.Map{"items": snapshots})
+}
+
+// ------------------------------------------------------------------
+// POST /api/admin/leaderboards/snapshots (أخذ لقطة لكل اللوحات الآن)
+// ------------------------------------------------------------------
+func (h *LeaderboardHandler) TakeSnapshots(ctx fiber.Ctx) error {
+	snapshots, err := h.Service.TakeSnapshots(ctx.Context(), time.Now())
+	if err != nil {
+		return h.leaderboardErrorResponse(ctx, err, "Failed to take leaderboard snapshots")
+	}
+	return ctx.Status(fiber.StatusCreated).JSON(fiber.Map{"items": snapshots})
+}
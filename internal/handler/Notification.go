@@ -0,0 +1,69 @@
+package handler
+
+import (
+	"strconv"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/rs/zerolog"
+	"githup.ahmedramadan.4cashier/internal/bootstrap"
+	mylogger "githup.ahmedramadan.4cashier/internal/mylogger"
+	"githup.ahmedramadan.4cashier/internal/service"
+)
+
+// NotificationHandler exposes the notifications of the signed-in user.
+type NotificationHandler struct {
+	Logger  zerolog.Logger
+	Service *service.NotificationService
+}
+
+// NewNotificationHandler creates a new instance of NotificationHandler.
+func NewNotificationHandler(logger zerolog.Logger, serv *service.NotificationService) *NotificationHandler {
+	return &NotificationHandler{
+		Logger:  logger.With().Str("layer", "handler").Str("component", "NotificationHandler").Logger(),
+		Service: serv,
+	}
+}
+
+// ------------------------------------------------------------------
+// GET /notifications (تنبيهات المستخدم الحالي)
+// ------------------------------------------------------------------
+func (h *NotificationHandler) GetNotifications(ctx fiber.Ctx) error {
+	user, ok := currentUser(ctx)
+	if !ok {
+		return ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+
+	items, err := h.Service.GetNotifications(ctx.Context(), user.Role, user.UserID, bootstrap.GetPagination(ctx))
+	if err != nil {
+		mylogger.HandleLogging(h.Logger, err, "Failed to fetch notifications")
+		return ctx.Status(500).JSON(fiber.Map{"error": "Failed to fetch notifications"})
+	}
+
+	return ctx.JSON(fiber.Map{"items": items})
+}
+
+// ------------------------------------------------------------------
+// POST /notifications/:id/read (تعليم التنبيه كمقروء)
+// ------------------------------------------------------------------
+func (h *NotificationHandler) MarkNotificationRead(ctx fiber.Ctx) error {
+	user, ok := currentUser(ctx)
+	if !ok {
+		return ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+
+	notificationID, err := strconv.Atoi(ctx.Params("id"))
+	if err != nil {
+		return ctx.Status(400).JSON(fiber.Map{"error": "Invalid notification ID"})
+	}
+
+	rowsAffected, err := h.Service.MarkNotificationRead(ctx.Context(), user.Role, user.UserID, notificationID)
+	if err != nil {
+		mylogger.HandleLogging(h.Logger, err, "Failed to mark notification as read")
+		return ctx.Status(500).JSON(fiber.Map{"error": "Failed to mark notification as read"})
+	}
+	if rowsAffected == 0 {
+		return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Notification not found"})
+	}
+
+	return ctx.SendStatus(204)
+}
@@ -0,0 +1,126 @@
+package handler
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/rs/zerolog"
+	mylogger "githup.ahmedramadan.4cashier/internal/mylogger"
+	"githup.ahmedramadan.4cashier/internal/repos"
+	"githup.ahmedramadan.4cashier/internal/service"
+)
+
+// Open Badges documents are JSON-LD.
+const jsonLD = "application/ld+json"
+
+// OpenBadgesHandler serves the public Open Badges 2.0 documents verifiers and
+// sites like LinkedIn fetch.
+type OpenBadgesHandler struct {
+	Logger  zerolog.Logger
+	Service *service.OpenBadgeService
+}
+
+// NewOpenBadgesHandler creates a new instance of OpenBadgesHandler.
+func NewOpenBadgesHandler(logger zerolog.Logger, serv *service.OpenBadgeService) *OpenBadgesHandler {
+	return &OpenBadgesHandler{
+		Logger:  logger.With().Str("layer", "handler").Str("component", "OpenBadgesHandler").Logger(),
+		Service: serv,
+	}
+}
+
+// openBadgesErrorResponse maps Open Badges errors to HTTP responses.
+func (h *OpenBadgesHandler) openBadgesErrorResponse(ctx fiber.Ctx, err error, message string) error {
+	switch {
+	case errors.Is(err, repos.ErrBadgeNotFound), errors.Is(err, repos.ErrBadgeDefinitionNotFound),
+		errors.Is(err, service.ErrSigningDisabled):
+		return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, service.ErrAssertionRevoked):
+		return ctx.Status(fiber.StatusGone).JSON(fiber.Map{"error": err.Error()})
+	}
+	mylogger.HandleLogging(h.Logger, err, message)
+	return ctx.Status(500).JSON(fiber.Map{"error": message})
+}
+
+// ------------------------------------------------------------------
+// GET /openbadges/issuer (الجهة المانحة)
+// ------------------------------------------------------------------
+func (h *OpenBadgesHandler) GetIssuer(ctx fiber.Ctx) error {
+	return ctx.JSON(h.Service.Issuer(), jsonLD)
+}
+
+// ------------------------------------------------------------------
+// GET /openbadges/issuer/key (المفتاح العام للشهادات الموقعة)
+// ------------------------------------------------------------------
+func (h *OpenBadgesHandler) GetPublicKey(ctx fiber.Ctx) error {
+	key, err := h.Service.PublicKey()
+	if err != nil {
+		return h.openBadgesErrorResponse(ctx, err, "Failed to fetch public key")
+	}
+	return ctx.JSON(key, jsonLD)
+}
+
+// ------------------------------------------------------------------
+// GET /openbadges/revocations (قائمة الشهادات المسحوبة)
+// ------------------------------------------------------------------
+func (h *OpenBadgesHandler) GetRevocationList(ctx fiber.Ctx) error {
+	list, err := h.Service.GetRevocationList(ctx.Context())
+	if err != nil {
+		return h.openBadgesErrorResponse(ctx, err, "Failed to fetch revocation list")
+	}
+	return ctx.JSON(list, jsonLD)
+}
+
+// ------------------------------------------------------------------
+// GET /openbadges/badges/:id (تعريف الشارة BadgeClass)
+// ------------------------------------------------------------------
+func (h *OpenBadgesHandler) GetBadgeClass(ctx fiber.Ctx) error {
+	definitionID, err := strconv.Atoi(ctx.Params("id"))
+	if err != nil {
+		return ctx.Status(400).JSON(fiber.Map{"error": "Invalid badge definition ID"})
+	}
+
+	badgeClass, err := h.Service.GetBadgeClass(ctx.Context(), definitionID)
+	if err != nil {
+		return h.openBadgesErrorResponse(ctx, err, "Failed to fetch badge class")
+	}
+	return ctx.JSON(badgeClass, jsonLD)
+}
+
+// ------------------------------------------------------------------
+// GET /openbadges/assertions/:id (الشهادة المستضافة للشارة)
+// ------------------------------------------------------------------
+func (h *OpenBadgesHandler) GetAssertion(ctx fiber.Ctx) error {
+	badgeID, err := strconv.Atoi(ctx.Params("id"))
+	if err != nil {
+		return ctx.Status(400).JSON(fiber.Map{"error": "Invalid badge ID"})
+	}
+
+	assertion, err := h.Service.GetAssertion(ctx.Context(), badgeID)
+	if err != nil {
+		return h.openBadgesErrorResponse(ctx, err, "Failed to fetch badge assertion")
+	}
+
+	// الشهادة المسحوبة تُرجع 410 مع revoked وسبب السحب كما تتوقع أدوات التحقق
+	if assertion.Revoked {
+		ctx.Status(fiber.StatusGone)
+	}
+	return ctx.JSON(assertion, jsonLD)
+}
+
+// ------------------------------------------------------------------
+// GET /openbadges/assertions/:id/signed (الشهادة موقعة JWS)
+// ------------------------------------------------------------------
+func (h *OpenBadgesHandler) GetSignedAssertion(ctx fiber.Ctx) error {
+	badgeID, err := strconv.Atoi(ctx.Params("id"))
+	if err != nil {
+		return ctx.Status(400).JSON(fiber.Map{"error": "Invalid badge ID"})
+	}
+
+	token, err := h.Service.SignAssertion(ctx.Context(), badgeID)
+	if err != nil {
+		return h.openBadgesErrorResponse(ctx, err, "Failed to sign badge assertion")
+	}
+	ctx.Set(fiber.HeaderContentType, fiber.MIMETextPlainCharsetUTF8)
+	return ctx.SendString(token)
+}
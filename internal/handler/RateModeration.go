@@ -0,0 +1,142 @@
+package handler
+
+import (
+	"strconv"
+
+	"github.com/gofiber/fiber/v3"
+	"githup.ahmedramadan.4cashier/internal/bootstrap"
+	"githup.ahmedramadan.4cashier/internal/models"
+	"githup.ahmedramadan.4cashier/internal/moderation"
+)
+
+// ModerationDecisionRequest is a moderator's verdict on a held rate or response.
+type ModerationDecisionRequest struct {
+	Approve bool    `json:"approve"`
+	Note    *string `json:"note" validate:"omitempty,max=500"`
+}
+
+// ------------------------------------------------------------------
+// GET /moderation/rates (طابور التقييمات المعلقة)
+// ------------------------------------------------------------------
+func (h *HRHandler) GetModerationRates(ctx fiber.Ctx) error {
+	items, err := h.Service.GetModerationRates(ctx.Context(), ctx.Query("status", moderation.StatusHeld), bootstrap.GetPagination(ctx))
+	if err != nil {
+		return h.rateErrorResponse(ctx, err, "Failed to fetch moderation queue")
+	}
+
+	return ctx.JSON(fiber.Map{"items": items})
+}
+
+// ------------------------------------------------------------------
+// POST /moderation/rates/:id/decision (نشر أو رفض تقييم)
+// ------------------------------------------------------------------
+func (h *HRHandler) DecideRate(ctx fiber.Ctx) error {
+	user, ok := currentUser(ctx)
+	if !ok {
+		return ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+
+	rateID, err := strconv.Atoi(ctx.Params("id"))
+	if err != nil {
+		return ctx.Status(400).JSON(fiber.Map{"error": "Invalid rate ID"})
+	}
+
+	var req ModerationDecisionRequest
+	if err := ctx.Bind().Body(&req); err != nil {
+		return ctx.Status(400).JSON(fiber.Map{"error": "Invalid decision"})
+	}
+	if err := models.Validate.Struct(req); err != nil {
+		return ctx.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	rate, err := h.Service.DecideRate(ctx.Context(), user.UserID, rateID, req.Approve, req.Note)
+	if err != nil {
+		return h.rateErrorResponse(ctx, err, "Failed to moderate rate")
+	}
+
+	return ctx.JSON(rate)
+}
+
+// ------------------------------------------------------------------
+// GET /moderation/responses (ردود الـ HR المعلقة)
+// ------------------------------------------------------------------
+func (h *HRHandler) GetHeldResponses(ctx fiber.Ctx) error {
+	items, err := h.Service.GetHeldResponses(ctx.Context(), bootstrap.GetPagination(ctx))
+	if err != nil {
+		return h.rateErrorResponse(ctx, err, "Failed to fetch held responses")
+	}
+
+	return ctx.JSON(fiber.Map{"items": items})
+}
+
+// ------------------------------------------------------------------
+// POST /moderation/responses/:id/decision (نشر أو رفض رد الـ HR، id = رقم التقييم)
+// ------------------------------------------------------------------
+func (h *HRHandler) DecideHRResponse(ctx fiber.Ctx) error {
+	user, ok := currentUser(ctx)
+	if !ok {
+		return ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+
+	rateID, err := strconv.Atoi(ctx.Params("id"))
+	if err != nil {
+		return ctx.Status(400).JSON(fiber.Map{"error": "Invalid rate ID"})
+	}
+
+	var req ModerationDecisionRequest
+	if err := ctx.Bind().Body(&req); err != nil {
+		return ctx.Status(400).JSON(fiber.Map{"error": "Invalid decision"})
+	}
+	if err := models.Validate.Struct(req); err != nil {
+		return ctx.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if err := h.Service.DecideHRResponse(ctx.Context(), user.UserID, rateID, req.Approve); err != nil {
+		return h.rateErrorResponse(ctx, err, "Failed to moderate response")
+	}
+
+	return ctx.SendStatus(204)
+}
+
+// ------------------------------------------------------------------
+// GET /moderation/risky-rates (التقييمات المشبوهة حسب درجة الاحتيال)
+// ------------------------------------------------------------------
+func (h *HRHandler) GetRiskyRates(ctx fiber.Ctx) error {
+	items, err := h.Service.GetRiskyRates(ctx.Context(), bootstrap.GetPagination(ctx))
+	if err != nil {
+		return h.rateErrorResponse(ctx, err, "Failed to fetch risky rates")
+	}
+
+	return ctx.JSON(fiber.Map{"items": items})
+}
+
+// ------------------------------------------------------------------
+// POST /moderation/rates/:id/risk-decision (اعتماد التقييم المشبوه أو رفضه)
+// ------------------------------------------------------------------
+func (h *HRHandler) ReviewRateRisk(ctx fiber.Ctx) error {
+	user, ok := currentUser(ctx)
+	if !ok {
+		return ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+
+	rateID, err := strconv.Atoi(ctx.Params("id"))
+	if err != nil {
+		return ctx.Status(400).JSON(fiber.Map{"error": "Invalid rate ID"})
+	}
+
+	// approve = the rate is genuine and counts again; otherwise it is rejected
+	var req ModerationDecisionRequest
+	if err := ctx.Bind().Body(&req); err != nil {
+		return ctx.Status(400).JSON(fiber.Map{"error": "Invalid decision"})
+	}
+	if err := models.Validate.Struct(req); err != nil {
+		return ctx.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	rate, err := h.Service.ReviewRateRisk(ctx.Context(), user.UserID, rateID, req.Approve, req.Note)
+	if err != nil {
+		return h.rateErrorResponse(ctx, err, "Failed to review rate risk")
+	}
+
+	return ctx.JSON(rate)
+}
@@ -0,0 +1,184 @@
+package handler
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/rs/zerolog"
+	"githup.ahmedramadan.4cashier/internal/bootstrap"
+	"githup.ahmedramadan.4cashier/internal/models"
+	mylogger "githup.ahmedramadan.4cashier/internal/mylogger"
+	"githup.ahmedramadan.4cashier/internal/repos"
+	"githup.ahmedramadan.4cashier/internal/service"
+)
+
+// ReportHandler handles user reports, the moderator report queue and the
+// moderation audit log.
+type ReportHandler struct {
+	Logger  zerolog.Logger
+	Service *service.ReportService
+	Audit   *service.AuditTrail
+}
+
+// NewReportHandler creates a new instance of ReportHandler.
+func NewReportHandler(logger zerolog.Logger, serv *service.ReportService, audit *service.AuditTrail) *ReportHandler {
+	return &ReportHandler{
+		Logger:  logger.With().Str("layer", "handler").Str("component", "ReportHandler").Logger(),
+		Service: serv,
+		Audit:   audit,
+	}
+}
+
+// ReportRequest is the body of a report on a rate or a badge.
+type ReportRequest struct {
+	Reason  string  `json:"reason" validate:"required,oneof=harassment false_information spam personal_data conflict_of_interest other"`
+	Details *string `json:"details" validate:"omitempty,max=1000"`
+}
+
+// reportErrorResponse maps report errors to HTTP responses.
+func (h *ReportHandler) reportErrorResponse(ctx fiber.Ctx, err error, message string) error {
+	switch {
+	case errors.Is(err, repos.ErrRateNotFound), errors.Is(err, repos.ErrReportNotFound),
+		errors.Is(err, repos.ErrReportTargetMissing):
+		return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, repos.ErrAlreadyReported), errors.Is(err, service.ErrReportResolved):
+		return ctx.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, service.ErrCannotReportOwn):
+		return ctx.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": err.Error()})
+	}
+	mylogger.HandleLogging(h.Logger, err, message)
+	return ctx.Status(500).JSON(fiber.Map{"error": message})
+}
+
+// bindReport reads the reporter, the target id and the body of a report.
+func (h *ReportHandler) bindReport(ctx fiber.Ctx) (*models.Report, error) {
+	user, ok := currentUser(ctx)
+	if !ok {
+		return nil, ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+
+	targetID, err := strconv.Atoi(ctx.Params("id"))
+	if err != nil {
+		return nil, ctx.Status(400).JSON(fiber.Map{"error": "Invalid ID"})
+	}
+
+	var req ReportRequest
+	if err := ctx.Bind().Body(&req); err != nil {
+		return nil, ctx.Status(400).JSON(fiber.Map{"error": "Invalid report data"})
+	}
+	if err := models.Validate.Struct(req); err != nil {
+		return nil, ctx.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return &models.Report{TargetID: targetID, ReporterID: user.UserID, Reason: req.Reason, Details: req.Details}, nil
+}
+
+// ------------------------------------------------------------------
+// POST /rates/:id/report (الإبلاغ عن تقييم)
+// ------------------------------------------------------------------
+func (h *ReportHandler) ReportRate(ctx fiber.Ctx) error {
+	report, err := h.bindReport(ctx)
+	if report == nil {
+		return err
+	}
+
+	report, err = h.Service.ReportRate(ctx.Context(), report)
+	if err != nil {
+		return h.reportErrorResponse(ctx, err, "Failed to report rate")
+	}
+
+	return ctx.Status(fiber.StatusCreated).JSON(report)
+}
+
+// ------------------------------------------------------------------
+// POST /badges/:id/report (الإبلاغ عن شارة)
+// ------------------------------------------------------------------
+func (h *ReportHandler) ReportBadge(ctx fiber.Ctx) error {
+	report, err := h.bindReport(ctx)
+	if report == nil {
+		return err
+	}
+
+	report, err = h.Service.ReportBadge(ctx.Context(), report)
+	if err != nil {
+		return h.reportErrorResponse(ctx, err, "Failed to report badge")
+	}
+
+	return ctx.Status(fiber.StatusCreated).JSON(report)
+}
+
+// ------------------------------------------------------------------
+// GET /moderation/reports (طابور البلاغات - للمشرفين)
+// ------------------------------------------------------------------
+func (h *ReportHandler) GetReports(ctx fiber.Ctx) error {
+	filters := map[string]interface{}{
+		"status":      ctx.Query("status", "open"),
+		"target_type": ctx.Query("target_type"),
+	}
+	if targetID, err := strconv.Atoi(ctx.Query("target_id")); err == nil {
+		filters["target_id"] = targetID
+	}
+
+	items, err := h.Service.GetReports(ctx.Context(), bootstrap.GetPagination(ctx), filters)
+	if err != nil {
+		return h.reportErrorResponse(ctx, err, "Failed to fetch reports")
+	}
+
+	return ctx.JSON(fiber.Map{"items": items})
+}
+
+// ------------------------------------------------------------------
+// POST /moderation/reports/:id/decision (قبول أو رفض البلاغ)
+// ------------------------------------------------------------------
+func (h *ReportHandler) DecideReport(ctx fiber.Ctx) error {
+	user, ok := currentUser(ctx)
+	if !ok {
+		return ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+
+	reportID, err := strconv.Atoi(ctx.Params("id"))
+	if err != nil {
+		return ctx.Status(400).JSON(fiber.Map{"error": "Invalid report ID"})
+	}
+
+	var req struct {
+		Uphold bool    `json:"uphold"`
+		Note   *string `json:"note" validate:"omitempty,max=500"`
+	}
+	if err := ctx.Bind().Body(&req); err != nil {
+		return ctx.Status(400).JSON(fiber.Map{"error": "Invalid decision"})
+	}
+	if err := models.Validate.Struct(req); err != nil {
+		return ctx.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if err := h.Service.DecideReport(ctx.Context(), user.UserID, reportID, req.Uphold, req.Note); err != nil {
+		return h.reportErrorResponse(ctx, err, "Failed to resolve report")
+	}
+
+	return ctx.SendStatus(204)
+}
+
+// ------------------------------------------------------------------
+// GET /moderation/audit-log (سجل إجراءات الإشراف)
+// ------------------------------------------------------------------
+func (h *ReportHandler) GetAuditLog(ctx fiber.Ctx) error {
+	filters := map[string]interface{}{
+		"target_type": ctx.Query("target_type"),
+		"action":      ctx.Query("action"),
+	}
+	if targetID, err := strconv.Atoi(ctx.Query("target_id")); err == nil {
+		filters["target_id"] = targetID
+	}
+	if actorID, err := strconv.Atoi(ctx.Query("actor_id")); err == nil {
+		filters["actor_id"] = actorID
+	}
+
+	items, err := h.Audit.GetAuditLog(ctx.Context(), bootstrap.GetPagination(ctx), filters)
+	if err != nil {
+		return h.reportErrorResponse(ctx, err, "Failed to fetch audit log")
+	}
+
+	return ctx.JSON(fiber.Map{"items": items})
+}
@@ -0,0 +1,300 @@
+package handler
+
+import (
+	"errors"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/rs/zerolog"
+	"githup.ahmedramadan.4cashier/internal/bootstrap"
+	mylogger "githup.ahmedramadan.4cashier/internal/mylogger"
+	"githup.ahmedramadan.4cashier/internal/repos"
+	"githup.ahmedramadan.4cashier/internal/service"
+)
+
+// allowedDocumentTypes are the file extensions accepted as employment proof.
+var allowedDocumentTypes = map[string]bool{".pdf": true, ".jpg": true, ".jpeg": true, ".png": true}
+
+// VerificationHandler handles proof-of-employment for rates.
+type VerificationHandler struct {
+	Logger  zerolog.Logger
+	Service *service.VerificationService
+}
+
+// NewVerificationHandler creates a new instance of VerificationHandler.
+func NewVerificationHandler(logger zerolog.Logger, serv *service.VerificationService) *VerificationHandler {
+	return &VerificationHandler{
+		Logger:  logger.With().Str("layer", "handler").Str("component", "VerificationHandler").Logger(),
+		Service: serv,
+	}
+}
+
+// verificationErrorResponse maps verification errors to HTTP responses.
+func (h *VerificationHandler) verificationErrorResponse(ctx fiber.Ctx, err error, message string) error {
+	switch {
+	case errors.Is(err, repos.ErrRateNotFound), errors.Is(err, repos.ErrVerificationNotFound):
+		return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, repos.ErrRateForbidden):
+		return ctx.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "You can only verify your own rates"})
+	case errors.Is(err, service.ErrAlreadyVerified), errors.Is(err, repos.ErrWorkEmailTaken):
+		return ctx.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, service.ErrNoCompanyDomain), errors.Is(err, service.ErrEmailDomainMismatch),
+		errors.Is(err, service.ErrInvalidVerifyCode), errors.Is(err, repos.ErrInviteTokenInvalid),
+		errors.Is(err, service.ErrNotDocumentReview):
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, service.ErrTooManyVerifyAttempts), errors.Is(err, service.ErrTooManyVerifyCodes):
+		return ctx.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{"error": err.Error()})
+	}
+	mylogger.HandleLogging(h.Logger, err, message)
+	return ctx.Status(500).JSON(fiber.Map{"error": message})
+}
+
+// ------------------------------------------------------------------
+// POST /hr/rate/:id/verification/email (إرسال رمز إلى بريد العمل)
+// ------------------------------------------------------------------
+func (h *VerificationHandler) StartEmailVerification(ctx fiber.Ctx) error {
+	user, ok := currentUser(ctx)
+	if !ok {
+		return ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+
+	rateID, err := strconv.Atoi(ctx.Params("id"))
+	if err != nil {
+		return ctx.Status(400).JSON(fiber.Map{"error": "Invalid rate ID"})
+	}
+
+	var req struct {
+		Email string `json:"email"`
+	}
+	if err := ctx.Bind().Body(&req); err != nil || req.Email == "" {
+		return ctx.Status(400).JSON(fiber.Map{"error": "Invalid email"})
+	}
+
+	if err := h.Service.StartEmailVerification(ctx.Context(), user.UserID, rateID, req.Email); err != nil {
+		return h.verificationErrorResponse(ctx, err, "Failed to start email verification")
+	}
+
+	return ctx.Status(fiber.StatusAccepted).JSON(fiber.Map{"message": "Verification code sent"})
+}
+
+// ------------------------------------------------------------------
+// POST /hr/rate/:id/verification/email/confirm (تأكيد الرمز)
+// ------------------------------------------------------------------
+func (h *VerificationHandler) ConfirmEmailVerification(ctx fiber.Ctx) error {
+	user, ok := currentUser(ctx)
+	if !ok {
+		return ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+
+	rateID, err := strconv.Atoi(ctx.Params("id"))
+	if err != nil {
+		return ctx.Status(400).JSON(fiber.Map{"error": "Invalid rate ID"})
+	}
+
+	var req struct {
+		Code string `json:"code"`
+	}
+	if err := ctx.Bind().Body(&req); err != nil {
+		return ctx.Status(400).JSON(fiber.Map{"error": "Invalid code"})
+	}
+
+	if err := h.Service.ConfirmEmailVerification(ctx.Context(), user.UserID, rateID, req.Code); err != nil {
+		return h.verificationErrorResponse(ctx, err, "Failed to confirm email verification")
+	}
+
+	return ctx.JSON(fiber.Map{"verified": true, "verification_method": "work_email"})
+}
+
+// ------------------------------------------------------------------
+// POST /hr/rate/:id/verification/document (رفع مستند لمراجعة المشرف)
+// ------------------------------------------------------------------
+func (h *VerificationHandler) SubmitDocument(ctx fiber.Ctx) error {
+	user, ok := currentUser(ctx)
+	if !ok {
+		return ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+
+	rateID, err := strconv.Atoi(ctx.Params("id"))
+	if err != nil {
+		return ctx.Status(400).JSON(fiber.Map{"error": "Invalid rate ID"})
+	}
+
+	file, err := ctx.FormFile("document")
+	if err != nil {
+		return ctx.Status(400).JSON(fiber.Map{"error": "Missing document"})
+	}
+	if !allowedDocumentTypes[strings.ToLower(filepath.Ext(file.Filename))] {
+		return ctx.Status(400).JSON(fiber.Map{"error": "Document must be a PDF, JPG or PNG"})
+	}
+
+	verification, err := h.Service.SubmitDocument(ctx.Context(), user.UserID, rateID, file.Filename, func(dst string) error {
+		return ctx.SaveFile(file, dst)
+	})
+	if err != nil {
+		return h.verificationErrorResponse(ctx, err, "Failed to submit verification document")
+	}
+
+	return ctx.Status(fiber.StatusAccepted).JSON(fiber.Map{"id": verification.ID, "status": verification.Status})
+}
+
+// ------------------------------------------------------------------
+// POST /hr/rate/:id/verification/invite (توثيق برمز دعوة من الـ HR)
+// ------------------------------------------------------------------
+func (h *VerificationHandler) RedeemInviteToken(ctx fiber.Ctx) error {
+	user, ok := currentUser(ctx)
+	if !ok {
+		return ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+
+	rateID, err := strconv.Atoi(ctx.Params("id"))
+	if err != nil {
+		return ctx.Status(400).JSON(fiber.Map{"error": "Invalid rate ID"})
+	}
+
+	var req struct {
+		Token string `json:"token"`
+	}
+	if err := ctx.Bind().Body(&req); err != nil || req.Token == "" {
+		return ctx.Status(400).JSON(fiber.Map{"error": "Invalid token"})
+	}
+
+	if err := h.Service.RedeemInviteToken(ctx.Context(), user.UserID, rateID, req.Token); err != nil {
+		return h.verificationErrorResponse(ctx, err, "Failed to redeem invite token")
+	}
+
+	return ctx.JSON(fiber.Map{"verified": true, "verification_method": "invite_token"})
+}
+
+// ------------------------------------------------------------------
+// GET /moderation/verifications (طابور المستندات للمشرفين)
+// ------------------------------------------------------------------
+func (h *VerificationHandler) GetVerifications(ctx fiber.Ctx) error {
+	items, err := h.Service.GetVerifications(ctx.Context(), ctx.Query("status", "pending"), bootstrap.GetPagination(ctx))
+	if err != nil {
+		mylogger.HandleLogging(h.Logger, err, "Failed to fetch verifications")
+		return ctx.Status(500).JSON(fiber.Map{"error": "Failed to fetch verifications"})
+	}
+
+	return ctx.JSON(fiber.Map{"items": items})
+}
+
+// ------------------------------------------------------------------
+// GET /moderation/verifications/:id/document (تنزيل المستند)
+// ------------------------------------------------------------------
+func (h *VerificationHandler) GetVerificationDocument(ctx fiber.Ctx) error {
+	verificationID, err := strconv.Atoi(ctx.Params("id"))
+	if err != nil {
+		return ctx.Status(400).JSON(fiber.Map{"error": "Invalid verification ID"})
+	}
+
+	verification, err := h.Service.GetVerification(ctx.Context(), verificationID)
+	if err != nil {
+		return h.verificationErrorResponse(ctx, err, "Failed to fetch verification")
+	}
+	if verification.DocumentPath == nil {
+		return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Verification has no document"})
+	}
+
+	return ctx.SendFile(*verification.DocumentPath)
+}
+
+// ------------------------------------------------------------------
+// POST /moderation/verifications/:id/decision (قبول أو رفض المستند)
+// ------------------------------------------------------------------
+func (h *VerificationHandler) ReviewDocument(ctx fiber.Ctx) error {
+	user, ok := currentUser(ctx)
+	if !ok {
+		return ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+
+	verificationID, err := strconv.Atoi(ctx.Params("id"))
+	if err != nil {
+		return ctx.Status(400).JSON(fiber.Map{"error": "Invalid verification ID"})
+	}
+
+	var req struct {
+		Approve bool    `json:"approve"`
+		Note    *string `json:"note"`
+	}
+	if err := ctx.Bind().Body(&req); err != nil {
+		return ctx.Status(400).JSON(fiber.Map{"error": "Invalid decision"})
+	}
+
+	if err := h.Service.ReviewDocument(ctx.Context(), user.UserID, verificationID, req.Approve, req.Note); err != nil {
+		return h.verificationErrorResponse(ctx, err, "Failed to review verification")
+	}
+
+	return ctx.SendStatus(204)
+}
+
+// ------------------------------------------------------------------
+// POST /hr/invite-tokens (إصدار رمز دعوة - للـ HR)
+// ------------------------------------------------------------------
+func (h *VerificationHandler) CreateInviteToken(ctx fiber.Ctx) error {
+	user, ok := currentUser(ctx)
+	if !ok {
+		return ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+
+	var req struct {
+		MaxUses       int `json:"max_uses"`
+		ExpiresInDays int `json:"expires_in_days"`
+	}
+	if err := ctx.Bind().Body(&req); err != nil {
+		return ctx.Status(400).JSON(fiber.Map{"error": "Invalid invite token data"})
+	}
+
+	token, err := h.Service.CreateInviteToken(ctx.Context(), user.UserID, req.MaxUses, req.ExpiresInDays)
+	if err != nil {
+		mylogger.HandleLogging(h.Logger, err, "Failed to create invite token")
+		return ctx.Status(500).JSON(fiber.Map{"error": "Failed to create invite token"})
+	}
+
+	return ctx.Status(fiber.StatusCreated).JSON(token)
+}
+
+// ------------------------------------------------------------------
+// GET /hr/invite-tokens (رموز الدعوة الخاصة بالـ HR)
+// ------------------------------------------------------------------
+func (h *VerificationHandler) GetInviteTokens(ctx fiber.Ctx) error {
+	user, ok := currentUser(ctx)
+	if !ok {
+		return ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+
+	items, err := h.Service.GetInviteTokens(ctx.Context(), user.UserID)
+	if err != nil {
+		mylogger.HandleLogging(h.Logger, err, "Failed to fetch invite tokens")
+		return ctx.Status(500).JSON(fiber.Map{"error": "Failed to fetch invite tokens"})
+	}
+
+	return ctx.JSON(fiber.Map{"items": items})
+}
+
+// ------------------------------------------------------------------
+// DELETE /hr/invite-tokens/:id (إلغاء رمز دعوة)
+// ------------------------------------------------------------------
+func (h *VerificationHandler) RevokeInviteToken(ctx fiber.Ctx) error {
+	user, ok := currentUser(ctx)
+	if !ok {
+		return ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+
+	tokenID, err := strconv.Atoi(ctx.Params("id"))
+	if err != nil {
+		return ctx.Status(400).JSON(fiber.Map{"error": "Invalid token ID"})
+	}
+
+	rowsAffected, err := h.Service.RevokeInviteToken(ctx.Context(), user.UserID, tokenID)
+	if err != nil {
+		mylogger.HandleLogging(h.Logger, err, "Failed to revoke invite token")
+		return ctx.Status(500).JSON(fiber.Map{"error": "Failed to revoke invite token"})
+	}
+	if rowsAffected == 0 {
+		return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Invite token not found"})
+	}
+
+	return ctx.SendStatus(204)
+}
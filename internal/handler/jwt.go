@@ -101,6 +101,28 @@ func SignIn(db *sqlx.DB) fiber.Handler {
 	}
 }
 
+// parseToken validates a "Bearer <jwt>" Authorization header.
+func parseToken(authHeader string) (*UserClaims, bool) {
+	if authHeader == "" || !strings.HasPrefix(authHeader, "Bearer ") {
+		return nil, false
+	}
+
+	tokenString := strings.TrimPrefix(authHeader, "Bearer ")
+
+	claims := &UserClaims{}
+	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fiber.ErrUnauthorized
+		}
+		return []byte(jwtSecret), nil
+	})
+
+	if err != nil || !token.Valid {
+		return nil, false
+	}
+	return claims, true
+}
+
 func JWTAuthMiddleware() fiber.Handler {
 	return func(c fiber.Ctx) error {
 		authHeader := c.Get("Authorization")
@@ -108,17 +130,8 @@ func JWTAuthMiddleware() fiber.Handler {
 			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Missing or invalid Authorization header"})
 		}
 
-		tokenString := strings.TrimPrefix(authHeader, "Bearer ")
-
-		claims := &UserClaims{}
-		token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, fiber.ErrUnauthorized
-			}
-			return []byte(jwtSecret), nil
-		})
-
-		if err != nil || !token.Valid {
+		claims, ok := parseToken(authHeader)
+		if !ok {
 			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid token"})
 		}
 
@@ -128,6 +141,35 @@ func JWTAuthMiddleware() fiber.Handler {
 	}
 }
 
+// OptionalJWTMiddleware stores the claims when a valid token is sent and lets
+// guests through, for public endpoints whose response depends on the caller.
+func OptionalJWTMiddleware() fiber.Handler {
+	return func(c fiber.Ctx) error {
+		if authHeader := c.Get("Authorization"); authHeader != "" {
+			claims, ok := parseToken(authHeader)
+			if !ok {
+				return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid token"})
+			}
+			c.Locals("user", claims)
+		}
+		return c.Next()
+	}
+}
+
+// currentUser returns the claims stored by JWTAuthMiddleware, if any.
+func currentUser(c fiber.Ctx) (*UserClaims, bool) {
+	claims, ok := c.Locals("user").(*UserClaims)
+	return claims, ok && claims != nil
+}
+
+// viewerFrom returns the caller as a models.Viewer (a guest when not signed in).
+func viewerFrom(c fiber.Ctx) models.Viewer {
+	if claims, ok := currentUser(c); ok {
+		return models.Viewer{UserID: claims.UserID, Role: claims.Role}
+	}
+	return models.Viewer{}
+}
+
 func HasRolesMiddleware(requiredRoles ...string) fiber.Handler {
 	return func(c fiber.Ctx) error {
 		userClaims, ok := c.Locals("user").(*UserClaims)
@@ -0,0 +1,440 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/rs/zerolog"
+	"githup.ahmedramadan.4cashier/internal/bootstrap"
+	"githup.ahmedramadan.4cashier/internal/models"
+	"githup.ahmedramadan.4cashier/internal/repos"
+	"githup.ahmedramadan.4cashier/internal/service"
+)
+
+const (
+	testAuthorID    = 5
+	testHRProfileID = 7
+)
+
+// privacyRepo serves one anonymous rate written by testAuthorID about
+// testHRProfileID. Methods the endpoints do not reach stay unimplemented.
+type privacyRepo struct {
+	repos.HRRepository
+	status string
+
+	ratesFilters     map[string]interface{}
+	includeAnonymous *bool
+	leaderboardQuery *models.LeaderboardQuery
+}
+
+func (r *privacyRepo) rate() models.Rate {
+	return models.Rate{
+		ID:                     1,
+		HRProfileID:            testHRProfileID,
+		EmployeeID:             testAuthorID,
+		ReviewText:             "Slow replies",
+		IsAnonymous:            true,
+		Status:                 r.status,
+		ModerationReasons:      models.JSONB(`[{"kind":"profanity"}]`),
+		SentimentContradiction: true,
+	}
+}
+
+func (r *privacyRepo) GetRate(ctx context.Context, rateID int) (*models.Rate, error) {
+	rate := r.rate()
+	return &rate, nil
+}
+
+func (r *privacyRepo) GetRates(ctx context.Context, pagination bootstrap.Pagination, filters map[string]interface{}) ([]models.RateWithDetails, error) {
+	r.ratesFilters = filters
+	name, image := "Sara", "sara.png"
+	return []models.RateWithDetails{{Rate: r.rate(), EmployeeName: &name, EmployeeImage: &image}}, nil
+}
+
+func (r *privacyRepo) GetRateRevisions(ctx context.Context, rateID int) ([]models.RateRevision, error) {
+	return []models.RateRevision{{ID: 1, RateID: rateID, Action: "edit", IsAnonymous: true}}, nil
+}
+
+func (r *privacyRepo) GetLeaderboard(ctx context.Context, query models.LeaderboardQuery) ([]models.LeaderboardEntry, error) {
+	r.leaderboardQuery = &query
+	name, reviews := "Sara", 1
+	return []models.LeaderboardEntry{{Rank: 1, SubjectID: testAuthorID, Name: &name, Reviews: &reviews}}, nil
+}
+
+func (r *privacyRepo) GetContributorStandings(ctx context.Context) ([]models.ContributorStanding, error) {
+	return nil, nil
+}
+
+func (r *privacyRepo) GetContributorLevel(ctx context.Context, employeeID int) (string, error) {
+	return models.LevelNewcomer, nil
+}
+
+func (r *privacyRepo) GetHRProfileByID(ctx context.Context, hrID int) (*models.HRProfile, error) {
+	name, rate := "Huda", float32(2)
+	return &models.HRProfile{ID: hrID, Name: &name, Rate: &rate, TotalRatesCount: 1}, nil
+}
+
+func (r *privacyRepo) GetProfileBadges(ctx context.Context, profileID int, viewerEmployeeID int) ([]models.Badge, error) {
+	return nil, nil
+}
+
+func (r *privacyRepo) GetEmployeeStats(ctx context.Context, employeeID int, includeAnonymous bool) (models.EmployeeStats, error) {
+	r.includeAnonymous = &includeAnonymous
+	return models.EmployeeStats{}, nil
+}
+
+// privacyModerationRepo accepts every report without hiding anything.
+type privacyModerationRepo struct {
+	repos.ModerationRepository
+}
+
+func (r *privacyModerationRepo) CreateReport(ctx context.Context, report *models.Report, levelWeight float64) (int, error) {
+	report.ID = 1
+	return report.ID, nil
+}
+
+func (r *privacyModerationRepo) GetOpenReportWeight(ctx context.Context, targetType string, targetID int) (float64, error) {
+	return 0, nil
+}
+
+// privacyViewers are the callers every endpoint returning rates is checked
+// against; nil claims is a guest. The HR viewers cover the rated profile and an
+// HR whose id happens to equal the author's, since ids of different roles live
+// in different tables.
+var privacyViewers = []struct {
+	name              string
+	claims            *UserClaims
+	seesReviewer      bool
+	seesContradiction bool
+}{
+	{"guest", nil, false, false},
+	{"other employee", &UserClaims{UserID: 8, Role: "employee"}, false, false},
+	{"HR owner", &UserClaims{UserID: testHRProfileID, Role: "hr"}, false, false},
+	{"HR with the author's id", &UserClaims{UserID: testAuthorID, Role: "hr"}, false, false},
+	{"author", &UserClaims{UserID: testAuthorID, Role: "employee"}, true, false},
+	{"moderator", &UserClaims{UserID: 99, Role: "moderator"}, true, true},
+	{"admin", &UserClaims{UserID: 1, Role: "admin"}, true, true},
+}
+
+// newPrivacyApp serves the rate endpoints as claims, the way the JWT
+// middlewares would after checking a token.
+func newPrivacyApp(repo *privacyRepo, claims *UserClaims) *fiber.App {
+	log := zerolog.Nop()
+	embedService := service.NewEmbedService(log, repo, bootstrap.EmbedConfig{BaseURL: "https://example.com"})
+	levels := service.NewContributorLevels(log, repo, bootstrap.LevelConfig{}, nil, embedService)
+	h := NewHRHandler(log, service.NewHRService(log, repo, bootstrap.RatePolicy{}, bootstrap.FraudConfig{}, nil, nil, embedService, levels, nil))
+	reports := NewReportHandler(log, service.NewReportService(log, &privacyModerationRepo{}, repo, nil, nil, bootstrap.ReportPolicy{HideThreshold: 3}, levels, embedService), nil)
+	embeds := NewEmbedHandler(log, embedService)
+	leaderboards := NewLeaderboardHandler(log, service.NewLeaderboardService(log, repo, bootstrap.LeaderboardConfig{}))
+
+	app := fiber.New()
+	app.Use(func(c fiber.Ctx) error {
+		if claims != nil {
+			c.Locals("user", claims)
+		}
+		return c.Next()
+	})
+	app.Get("/hr/rates", h.GetRates)
+	app.Get("/hr/rate/:id", h.GetRate)
+	app.Get("/hr/rate/:id/revisions", h.GetRateRevisions)
+	app.Get("/hr/:employee_id/stats", h.GetEmployeeStats)
+	app.Post("/rates/:id/report", reports.ReportRate)
+	app.Get("/leaderboards/contributors", leaderboards.GetContributors)
+	app.Get("/embed/hr/:id/widget", embeds.GetWidget)
+	app.Get("/embed/hr/:id/badge.svg", embeds.GetBadgeSVG)
+	return app
+}
+
+func getJSON(t *testing.T, app *fiber.App, path string, out interface{}) int {
+	t.Helper()
+	return sendJSON(t, app, http.MethodGet, path, nil, out)
+}
+
+// sendJSON sends body as JSON and decodes a successful response into out.
+func sendJSON(t *testing.T, app *fiber.App, method, path string, body, out interface{}) int {
+	t.Helper()
+	var payload bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&payload).Encode(body); err != nil {
+			t.Fatalf("%s %s: encoding body: %v", method, path, err)
+		}
+	}
+	req := httptest.NewRequest(method, path, &payload)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatalf("%s %s: %v", method, path, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < http.StatusMultipleChoices && out != nil {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			t.Fatalf("%s %s: decoding body: %v", method, path, err)
+		}
+	}
+	return resp.StatusCode
+}
+
+// checkReviewer fails unless a serialized rate names its author exactly when
+// the viewer may see them.
+func checkReviewer(t *testing.T, rate map[string]interface{}, seesReviewer bool) {
+	t.Helper()
+	want := 0.0
+	if seesReviewer {
+		want = testAuthorID
+	}
+	if rate["employee_id"] != want {
+		t.Errorf("employee_id = %v, want %v", rate["employee_id"], want)
+	}
+}
+
+// checkContradiction fails unless the sentiment contradiction hint is served
+// exactly to moderators.
+func checkContradiction(t *testing.T, rate map[string]interface{}, seesContradiction bool) {
+	t.Helper()
+	if _, ok := rate["sentiment_contradiction"]; ok != seesContradiction {
+		t.Errorf("sentiment_contradiction present = %v, want %v", ok, seesContradiction)
+	}
+}
+
+func TestGetRateHidesAnonymousReviewer(t *testing.T) {
+	for _, tc := range privacyViewers {
+		t.Run(tc.name, func(t *testing.T) {
+			app := newPrivacyApp(&privacyRepo{status: "published"}, tc.claims)
+
+			var body map[string]interface{}
+			if status := getJSON(t, app, "/hr/rate/1", &body); status != http.StatusOK {
+				t.Fatalf("status = %d, want 200", status)
+			}
+			checkReviewer(t, body, tc.seesReviewer)
+			if _, ok := body["moderation_reasons"]; ok != tc.seesReviewer {
+				t.Errorf("moderation_reasons present = %v, want %v", ok, tc.seesReviewer)
+			}
+			checkContradiction(t, body, tc.seesContradiction)
+		})
+	}
+}
+
+func TestGetRateHidesUnpublishedRates(t *testing.T) {
+	for _, tc := range privacyViewers {
+		t.Run(tc.name, func(t *testing.T) {
+			app := newPrivacyApp(&privacyRepo{status: "held"}, tc.claims)
+
+			want := http.StatusNotFound
+			if tc.seesReviewer {
+				want = http.StatusOK
+			}
+			if status := getJSON(t, app, "/hr/rate/1", nil); status != want {
+				t.Errorf("status = %d, want %d", status, want)
+			}
+		})
+	}
+}
+
+func TestGetRatesPseudonymizesAnonymousReviewer(t *testing.T) {
+	for _, tc := range privacyViewers {
+		t.Run(tc.name, func(t *testing.T) {
+			app := newPrivacyApp(&privacyRepo{status: "published"}, tc.claims)
+
+			var body struct {
+				Items []map[string]interface{} `json:"items"`
+			}
+			if status := getJSON(t, app, "/hr/rates?hr_profile_id=7", &body); status != http.StatusOK {
+				t.Fatalf("status = %d, want 200", status)
+			}
+			if len(body.Items) != 1 {
+				t.Fatalf("got %d items, want 1", len(body.Items))
+			}
+
+			item := body.Items[0]
+			checkReviewer(t, item, tc.seesReviewer)
+			checkContradiction(t, item, tc.seesContradiction)
+			if tc.seesReviewer {
+				if item["employee_name"] != "Sara" {
+					t.Errorf("employee_name = %v, want the author", item["employee_name"])
+				}
+			} else {
+				if item["employee_image"] != nil {
+					t.Errorf("employee_image = %v, want none", item["employee_image"])
+				}
+				if name, _ := item["employee_name"].(string); name == "Sara" || name == "" {
+					t.Errorf("employee_name = %q, want a pseudonym", name)
+				}
+			}
+		})
+	}
+}
+
+func TestGetRatesOfAnEmployeeSkipsTheirAnonymousRates(t *testing.T) {
+	for _, tc := range privacyViewers {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := &privacyRepo{status: "published"}
+			app := newPrivacyApp(repo, tc.claims)
+
+			if status := getJSON(t, app, "/hr/rates?employee_id=5", nil); status != http.StatusOK {
+				t.Fatalf("status = %d, want 200", status)
+			}
+
+			// listing someone's rates must not tell which anonymous rates are theirs
+			_, filtered := repo.ratesFilters["is_anonymous"]
+			if filtered == tc.seesReviewer {
+				t.Errorf("is_anonymous filter set = %v, want %v", filtered, !tc.seesReviewer)
+			}
+		})
+	}
+}
+
+func TestGetRateRevisionsHidesAnonymousReviewer(t *testing.T) {
+	for _, tc := range privacyViewers {
+		t.Run(tc.name, func(t *testing.T) {
+			app := newPrivacyApp(&privacyRepo{status: "published"}, tc.claims)
+
+			var body struct {
+				Rate map[string]interface{} `json:"rate"`
+			}
+			status := getJSON(t, app, "/hr/rate/1/revisions", &body)
+			switch {
+			case tc.claims == nil:
+				if status != http.StatusUnauthorized {
+					t.Errorf("status = %d, want 401", status)
+				}
+			case status == http.StatusOK:
+				checkReviewer(t, body.Rate, tc.seesReviewer)
+			case tc.claims.Role == "hr" && tc.claims.UserID == testHRProfileID:
+				// the rated HR reads the history without learning the reviewer
+				t.Errorf("status = %d, want 200", status)
+			case status != http.StatusForbidden:
+				t.Errorf("status = %d, want 200 or 403", status)
+			}
+		})
+	}
+}
+
+func TestReportRateDoesNotRevealTheReviewer(t *testing.T) {
+	for _, tc := range privacyViewers {
+		if tc.claims == nil || tc.claims.Role != "employee" {
+			continue // only employees may report
+		}
+		t.Run(tc.name, func(t *testing.T) {
+			app := newPrivacyApp(&privacyRepo{status: "published"}, tc.claims)
+
+			var body map[string]interface{}
+			status := sendJSON(t, app, http.MethodPost, "/rates/1/report", fiber.Map{"reason": "spam"}, &body)
+			if tc.seesReviewer {
+				if status != http.StatusForbidden {
+					t.Errorf("status = %d, want 403 for the author", status)
+				}
+				return
+			}
+			if status != http.StatusCreated {
+				t.Fatalf("status = %d, want 201", status)
+			}
+			if _, ok := body["employee_id"]; ok {
+				t.Errorf("report names the reviewer: %v", body)
+			}
+		})
+	}
+}
+
+func TestWidgetDoesNotRevealReviewers(t *testing.T) {
+	for _, path := range []string{"/embed/hr/7/widget", "/embed/hr/7/badge.svg"} {
+		var rendered string
+		for _, tc := range privacyViewers {
+			t.Run(path+"/"+tc.name, func(t *testing.T) {
+				app := newPrivacyApp(&privacyRepo{status: "published"}, tc.claims)
+
+				resp, err := app.Test(httptest.NewRequest(http.MethodGet, path, nil))
+				if err != nil {
+					t.Fatalf("GET %s: %v", path, err)
+				}
+				defer resp.Body.Close()
+				if resp.StatusCode != http.StatusOK {
+					t.Fatalf("status = %d, want 200", resp.StatusCode)
+				}
+				var body strings.Builder
+				if _, err := io.Copy(&body, resp.Body); err != nil {
+					t.Fatalf("reading body: %v", err)
+				}
+
+				// widgets are public and cached, so they must look the same to everyone
+				if strings.Contains(body.String(), "Slow replies") || strings.Contains(body.String(), "Sara") {
+					t.Errorf("widget shows a review or its reviewer: %s", body.String())
+				}
+				if rendered == "" {
+					rendered = body.String()
+				} else if body.String() != rendered {
+					t.Errorf("widget differs from the one served to %s", privacyViewers[0].name)
+				}
+			})
+		}
+	}
+}
+
+func TestContributorLeaderboardIsTheSameForEveryViewer(t *testing.T) {
+	var guestQuery *models.LeaderboardQuery
+	var guestBody map[string]interface{}
+	for _, tc := range privacyViewers {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := &privacyRepo{status: "published"}
+			app := newPrivacyApp(repo, tc.claims)
+
+			var body map[string]interface{}
+			if status := getJSON(t, app, "/leaderboards/contributors", &body); status != http.StatusOK {
+				t.Fatalf("status = %d, want 200", status)
+			}
+
+			// a ranking that counted anonymous rates for some viewers would let
+			// them be told apart by comparing boards
+			if guestQuery == nil {
+				guestQuery, guestBody = repo.leaderboardQuery, body
+				return
+			}
+			if !reflect.DeepEqual(repo.leaderboardQuery, guestQuery) {
+				t.Errorf("query = %+v, want the guest's %+v", repo.leaderboardQuery, guestQuery)
+			}
+			if !reflect.DeepEqual(body, guestBody) {
+				t.Errorf("board = %v, want the guest's %v", body, guestBody)
+			}
+		})
+	}
+}
+
+func TestReportRateHidesUnpublishedRates(t *testing.T) {
+	for _, tc := range privacyViewers {
+		if tc.claims == nil || tc.claims.Role != "employee" {
+			continue // only employees may report
+		}
+		t.Run(tc.name, func(t *testing.T) {
+			app := newPrivacyApp(&privacyRepo{status: "held"}, tc.claims)
+
+			status := sendJSON(t, app, http.MethodPost, "/rates/1/report", fiber.Map{"reason": "spam"}, nil)
+			if status != http.StatusNotFound {
+				t.Errorf("status = %d, want 404", status)
+			}
+		})
+	}
+}
+
+func TestGetEmployeeStatsCountsAnonymousRatesOnlyForTheAuthor(t *testing.T) {
+	for _, tc := range privacyViewers {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := &privacyRepo{status: "published"}
+			app := newPrivacyApp(repo, tc.claims)
+
+			if status := getJSON(t, app, "/hr/5/stats", nil); status != http.StatusOK {
+				t.Fatalf("status = %d, want 200", status)
+			}
+			if repo.includeAnonymous == nil || *repo.includeAnonymous != tc.seesReviewer {
+				t.Errorf("includeAnonymous = %v, want %v", repo.includeAnonymous, tc.seesReviewer)
+			}
+		})
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"time"
 
 	"github.com/go-playground/validator/v10"
+	"github.com/lib/pq"
 )
 
 var Validate *validator.Validate
@@ -12,6 +13,17 @@ func init() {
 	Validate = validator.New()
 }
 
+// Viewer is the caller a response is shaped for. The zero value is a guest.
+type Viewer struct {
+	UserID int
+	Role   string // employee | hr | admin | moderator
+}
+
+// IsModerator reports whether the viewer may moderate content.
+func (v Viewer) IsModerator() bool {
+	return v.Role == "admin" || v.Role == "moderator"
+}
+
 // Experience history for HR profiles
 type Experience struct {
 	Name        *string    `json:"name"`
@@ -41,7 +53,8 @@ type HRProfile struct {
 	JobPosition      *string      `db:"job_position" json:"job_position,omitempty" validate:"omitempty,min=2,max=100"`
 	Experience       []Experience `db:"experience" json:"experience,omitempty"`
 	JobRoles         []JobRole    `db:"job_roles" json:"job_roles,omitempty"`
-	Rate             *float32     `db:"rate" json:"rate,omitempty"`
+	Rate             *float32     `db:"rate" json:"rate,omitempty"` // overall score
+	WeightedRate     *float32     `db:"weighted_rate" json:"weighted_rate,omitempty"` // confidence-adjusted score used for ranking
 	TotalRatesCount  int          `db:"total_rates_count" json:"total_rates_count"`
 	Verified         bool         `db:"verified_profile" json:"verified_profile"`
 	CreatedAt        time.Time    `db:"created_at" json:"created_at"`
@@ -49,6 +62,135 @@ type HRProfile struct {
 
 
 	Badges []Badge `db:"-" json:"badges,omitempty"`
+	CriteriaScores []CriterionAverage `db:"-" json:"criteria_scores,omitempty"`
+	ContextScores  []ContextAverage   `db:"-" json:"context_scores,omitempty"`
+	AspectScores   []AspectAverage    `db:"-" json:"aspect_scores,omitempty"`
+}
+
+// RatingCriterion is one dimension an HR can be rated on (communication, fairness...).
+type RatingCriterion struct {
+	ID        int       `db:"id" json:"id"`
+	Code      string    `db:"code" json:"code" validate:"required,min=2,max=50"`
+	NameEn    string    `db:"name_en" json:"name_en" validate:"required,min=2,max=100"`
+	NameAr    string    `db:"name_ar" json:"name_ar" validate:"required,min=2,max=100"`
+	IsActive  bool      `db:"is_active" json:"is_active"`
+	SortOrder int       `db:"sort_order" json:"sort_order"`
+	Contexts  pq.StringArray `db:"context_codes" json:"contexts"` // rating context codes it applies to; empty = all
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt time.Time `db:"updated_at" json:"updated_at"`
+}
+
+// RatingContext is a kind of interaction with an HR (interview, onboarding...).
+type RatingContext struct {
+	ID          int       `db:"id" json:"id"`
+	Code        string    `db:"code" json:"code" validate:"required,min=2,max=50"`
+	NameEn      string    `db:"name_en" json:"name_en" validate:"required,min=2,max=100"`
+	NameAr      string    `db:"name_ar" json:"name_ar" validate:"required,min=2,max=100"`
+	Description *string   `db:"description" json:"description,omitempty"`
+	IsActive    bool      `db:"is_active" json:"is_active"`
+	SortOrder   int       `db:"sort_order" json:"sort_order"`
+	CreatedAt   time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt   time.Time `db:"updated_at" json:"updated_at"`
+}
+
+// ContextAverage is the average of an HR profile for one rating context.
+type ContextAverage struct {
+	Code       string  `db:"code" json:"code"`
+	NameEn     string  `db:"name_en" json:"name_en"`
+	NameAr     string  `db:"name_ar" json:"name_ar"`
+	Average    float32 `db:"average" json:"average"`
+	RatesCount int     `db:"rates_count" json:"rates_count"`
+}
+
+// RateAspect is one aspect (salary, communication...) detected in a review.
+type RateAspect struct {
+	RateID   int     `db:"rate_id" json:"-"`
+	Aspect   string  `db:"aspect" json:"aspect"`
+	Score    float32 `db:"score" json:"score"` // -1..1
+	Mentions int     `db:"mentions" json:"mentions"`
+}
+
+// AspectAverage is how the reviews of a profile talk about one aspect.
+type AspectAverage struct {
+	Aspect        string  `db:"aspect" json:"aspect"`
+	Average       float32 `db:"average" json:"average"` // -1..1
+	RatesCount    int     `db:"rates_count" json:"rates_count"`
+	PositiveCount int     `db:"positive_count" json:"positive_count"`
+	NegativeCount int     `db:"negative_count" json:"negative_count"`
+}
+
+// AspectLeader is a profile ranked by what its reviews say about an aspect.
+type AspectLeader struct {
+	HRProfileID  int      `db:"hr_profile_id" json:"hr_profile_id"`
+	Name         *string  `db:"name" json:"name"`
+	Image        *string  `db:"image" json:"image"`
+	CompanyName  *string  `db:"company_name" json:"company_name"`
+	JobPosition  *string  `db:"job_position" json:"job_position"`
+	Rate         *float32 `db:"rate" json:"rate"`
+	AspectAverage
+}
+
+// RatingSummary is the star histogram and score trend of an HR profile. It is
+// built from the same rates as hr_profiles.rate, so the counts add up to
+// TotalRatesCount.
+
+type RatingSummary struct {
+	HRProfileID     int                 `json:"hr_profile_id"`
+	Rate            *float32            `json:"rate"`
+	TotalRatesCount int                 `json:"total_rates_count"`
+	Distribution    []StarBucket        `json:"distribution"`
+	Monthly         []MonthlyAverage    `json:"monthly"`
+	Recent          PeriodComparison    `json:"recent"`
+	Position        *PositionPercentile `json:"position,omitempty"`
+}
+
+// StarBucket is one bar of the 1-5 star histogram.
+type StarBucket struct {
+	Stars      int     `db:"stars" json:"stars"`
+	RatesCount int     `db:"rates_count" json:"rates_count"`
+	Share      float32 `db:"share" json:"share"` // 0..1 of all counted rates
+}
+
+// MonthlyAverage is the average of the rates created in one calendar month.
+type MonthlyAverage struct {
+	Month      string  `db:"month" json:"month"` // YYYY-MM
+	Average    float32 `db:"average" json:"average"`
+	RatesCount int     `db:"rates_count" json:"rates_count"`
+}
+
+// PeriodComparison compares the recent window against the lifetime score.
+type PeriodComparison struct {
+	WindowDays      int      `db:"-" json:"window_days"`
+	RecentAverage   *float32 `db:"recent_average" json:"recent_average"`
+	RecentCount     int      `db:"recent_count" json:"recent_count"`
+	LifetimeAverage *float32 `db:"lifetime_average" json:"lifetime_average"`
+	LifetimeCount   int      `db:"lifetime_count" json:"lifetime_count"`
+	Delta           *float32 `db:"-" json:"delta"` // recent - lifetime, nil without recent rates
+}
+
+// PositionPercentile places a profile among rated profiles with the same job position.
+type PositionPercentile struct {
+	JobPosition   string  `db:"job_position" json:"job_position"`
+	Percentile    float32 `db:"percentile" json:"percentile"` // 0..100, share of peers rated lower
+	ProfilesCount int     `db:"profiles_count" json:"profiles_count"`
+}
+
+// RateScore is the score given to a single criterion inside a rate.
+type RateScore struct {
+	RateID      int     `db:"rate_id" json:"-"`
+	CriterionID int     `db:"criterion_id" json:"criterion_id"`
+	Code        string  `db:"code" json:"code"`
+	Score       float32 `db:"score" json:"score" validate:"gte=0,lte=5"`
+}
+
+// CriterionAverage is the per-criterion average of an HR profile.
+type CriterionAverage struct {
+	CriterionID int     `db:"criterion_id" json:"criterion_id"`
+	Code        string  `db:"code" json:"code"`
+	NameEn      string  `db:"name_en" json:"name_en"`
+	NameAr      string  `db:"name_ar" json:"name_ar"`
+	Average     float32 `db:"average" json:"average"`
+	RatesCount  int     `db:"rates_count" json:"rates_count"`
 }
 
 // Optional legacy task model (if needed separately)
@@ -72,8 +214,11 @@ type Employee struct {
 	City         *string    `db:"city" json:"city"`
 	Email        string     `db:"email" json:"email"`
 	PasswordHash string     `db:"password_hash" json:"password_hash"`
-	ActivePoints bool       `db:"active_points" json:"active_points"`
+	PointsBalance int       `db:"points_balance" json:"points_balance"` // cached sum of points_ledger
 	IsVerified   bool       `db:"is_verified" json:"is_verified"`
+	ReportCredibility float32 `db:"report_credibility" json:"report_credibility"` // weight of this employee's reports
+	ContributorLevel string     `db:"contributor_level" json:"contributor_level"` // newcomer | trusted | expert
+	LevelUpdatedAt   *time.Time `db:"level_updated_at" json:"level_updated_at,omitempty"`
 	CreatedAt    time.Time  `db:"created_at" json:"created_at"`
 	UpdatedAt    time.Time  `db:"updated_at" json:"updated_at"`
 }
@@ -86,10 +231,70 @@ type Badge struct {
 	CreatedDate      time.Time `db:"created_date" json:"created_date"` // fixed key from created_add
 	TotalRates       int       `db:"total_rates_number" json:"total_rates_number"`
 	Rate             float32   `db:"rate" json:"rate"`
-	JobPosition      string    `db:"job_position" json:"job_position"`
-	CurrentJobRoles  string    `db:"current_job_roles" json:"current_job_roles"`
+	DefinitionID     int       `db:"definition_id" json:"definition_id" validate:"required,gt=0"` // badge_definitions.id
+	AwardNote        *string   `db:"award_note" json:"award_note,omitempty" validate:"omitempty,max=500"` // why this profile earned it
+	HiddenAt         *time.Time `db:"hidden_at" json:"-"` // set when a report on the badge is upheld
+	ExpiresAt        *time.Time `db:"expires_at" json:"expires_at,omitempty"`
+	RevokedAt        *time.Time `db:"revoked_at" json:"revoked_at,omitempty"` // set on expiry too; the badge stays in the history
+	RevokedBy        *int       `db:"revoked_by" json:"-"`
+	RevokeKind       *string    `db:"revoke_kind" json:"revoke_kind,omitempty"` // manual | criteria | expired
+	RevokeReason     *string    `db:"revoke_reason" json:"revoke_reason,omitempty"`
+	CriteriaFailingSince *time.Time `db:"criteria_failing_since" json:"-"` // start of the grace period before automatic revocation
+	LikesCount       int        `db:"likes_count" json:"likes_count"`
+	DislikesCount    int        `db:"dislikes_count" json:"dislikes_count"`
+	MyVote           *bool      `db:"my_vote" json:"my_vote,omitempty"` // the signed-in employee's like (true) or dislike (false)
+	HRProfileName    *string    `db:"hr_profile_name" json:"hr_profile_name,omitempty"` // set in the recent badges feed
+	RecipientSalt    string     `db:"recipient_salt" json:"-"` // salts the hashed recipient email of the Open Badges assertion
 	CreatedAt        time.Time `db:"created_at" json:"created_at"`
 	UpdatedAt        time.Time `db:"updated_at" json:"updated_at"`
+
+	Definition *BadgeDefinition `db:"-" json:"definition,omitempty"`
+}
+
+// Ways a badge stops being active.
+const (
+	BadgeRevokedManual   = "manual"
+	BadgeRevokedCriteria = "criteria"
+	BadgeRevokedExpired  = "expired"
+)
+
+// IsActive reports whether the badge is neither revoked nor expired at now.
+func (b *Badge) IsActive(now time.Time) bool {
+	return b.RevokedAt == nil && (b.ExpiresAt == nil || b.ExpiresAt.After(now))
+}
+
+// ProfileBadges are the badges of an HR profile split into current and past ones.
+type ProfileBadges struct {
+	HRProfileID int     `json:"hr_profile_id"`
+	Active      []Badge `json:"active"`
+	History     []Badge `json:"history"` // expired or revoked, newest first
+}
+
+// BadgeDefinition is a kind of badge in the catalog (Top Rated HR...).
+type BadgeDefinition struct {
+	ID            int       `db:"id" json:"id"`
+	Code          string    `db:"code" json:"code" validate:"required,min=2,max=50"`
+	NameEn        string    `db:"name_en" json:"name_en" validate:"required,min=2,max=100"`
+	NameAr        string    `db:"name_ar" json:"name_ar" validate:"required,min=2,max=100"`
+	DescriptionEn *string   `db:"description_en" json:"description_en,omitempty" validate:"omitempty,max=500"`
+	DescriptionAr *string   `db:"description_ar" json:"description_ar,omitempty" validate:"omitempty,max=500"`
+	Icon          *string   `db:"icon" json:"icon,omitempty" validate:"omitempty,max=255"` // icon key or URL
+	Tier          string    `db:"tier" json:"tier" validate:"required,oneof=bronze silver gold platinum"`
+	Category      string    `db:"category" json:"category" validate:"required,min=2,max=50"`
+	Rule          *string   `db:"rule" json:"rule,omitempty" validate:"omitempty,max=1000"` // awarded automatically when it holds; nil = manual only
+	AvailableFrom   *time.Time `db:"available_from" json:"available_from,omitempty"`   // seasonal badges are only awarded
+	AvailableUntil  *time.Time `db:"available_until" json:"available_until,omitempty"` // between these two dates
+	ValidityDays    *int       `db:"validity_days" json:"validity_days,omitempty" validate:"omitempty,gt=0"`           // awards expire after; nil = permanent
+	RevokeGraceDays *int       `db:"revoke_grace_days" json:"revoke_grace_days,omitempty" validate:"omitempty,gte=0"` // revoke once the rule fails this long; nil = never
+	IsActive      bool      `db:"is_active" json:"is_active"`
+	SortOrder     int       `db:"sort_order" json:"sort_order"`
+	CreatedAt     time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt     time.Time `db:"updated_at" json:"updated_at"`
+}
+
+// IsAvailable reports whether the badge can be awarded at now (seasonal window).
+func (d *BadgeDefinition) IsAvailable(now time.Time) bool {
+	return (d.AvailableFrom == nil || !now.Before(*d.AvailableFrom)) && (d.AvailableUntil == nil || now.Before(*d.AvailableUntil))
 }
 
 // Rating by employee to HR
@@ -99,16 +304,74 @@ type Rate struct {
 	EmployeeID    int       `db:"employee_id" json:"employee_id" validate:"required,gt=0"`
 	ReviewText    string    `db:"review_text" json:"review_text" validate:"required,min=5,max=2000"`
 	RateValue     float32   `db:"rate_value" json:"rate_value" validate:"gte=0,lte=5"`
-	RatingContext *string   `db:"rating_context" json:"rating_context,omitempty"`
+	RatingContext *string   `db:"rating_context" json:"rating_context,omitempty"` // rating_contexts.code
+	RatingContextNote *string `db:"rating_context_note" json:"rating_context_note,omitempty" validate:"omitempty,max=500"`
 	LikesCount    int       `db:"likes_count" json:"likes_count"`
+	DislikesCount int       `db:"dislikes_count" json:"dislikes_count"`
+	Helpfulness   float32   `db:"helpfulness" json:"helpfulness"` // Wilson lower bound of likes / votes
 	IsVerified    bool       `db:"is_verified" json:"is_verified"` 
+	VerificationMethod *string `db:"verification_method" json:"verification_method,omitempty"` // work_email | document | invite_token
 	HRResponse    *string   `db:"hr_response" json:"hr_response,omitempty"`
+	HRResponseAt  *time.Time `db:"hr_response_at" json:"hr_response_at,omitempty"`
+	HRResponseStatus *string `db:"hr_response_status" json:"-"`
 	IsAnonymous   bool      `db:"is_anonymous" json:"is_anonymous"`
 	CreatedAt     time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt     *time.Time `db:"updated_at" json:"updated_at,omitempty"`
+	EditedAt      *time.Time `db:"edited_at" json:"edited_at,omitempty"` // not nil => the author edited the review
+	DeletedAt     *time.Time `db:"deleted_at" json:"-"`
+
+	// Status is the moderation outcome: published | held | rejected. Only
+	// published rates are public and counted; the reasons are shown to the
+	// author and moderators.
+	Status            string     `db:"status" json:"status,omitempty"`
+	ModerationReasons JSONB      `db:"moderation_reasons" json:"moderation_reasons,omitempty"`
+	ModeratedBy       *int       `db:"moderated_by" json:"-"`
+	ModeratedAt       *time.Time `db:"moderated_at" json:"moderated_at,omitempty"`
+	HRResponseModerationReasons JSONB `db:"hr_response_moderation_reasons" json:"-"`
+
+	// Request data and fraud scoring; only selected for moderators.
+	ClientIP          *string    `db:"client_ip" json:"-"`
+	DeviceFingerprint *string    `db:"device_fingerprint" json:"-"`
+	RiskScore         *int       `db:"risk_score" json:"risk_score,omitempty"`
+	RiskSignals       JSONB      `db:"risk_signals" json:"risk_signals,omitempty"`
+	RiskFlagged       bool       `db:"risk_flagged" json:"risk_flagged,omitempty"` // excluded from averages until reviewed
+	RiskReviewedAt    *time.Time `db:"risk_reviewed_at" json:"risk_reviewed_at,omitempty"`
+
+	// Lexicon sentiment of the review text; nil until the rate is analyzed.
+	SentimentScore         *float32 `db:"sentiment_score" json:"sentiment_score,omitempty"` // -1..1
+	SentimentLabel         *string  `db:"sentiment_label" json:"sentiment_label,omitempty"` // positive | negative | neutral
+	SentimentContradiction bool     `db:"sentiment_contradiction" json:"sentiment_contradiction,omitempty"` // stars disagree with the text
+	Aspects                JSONB    `db:"aspects" json:"aspects,omitempty"` // [{aspect, score, mentions}]
+
+	// Scores is the per-criterion breakdown; when present, RateValue is their mean.
+	// Rates created before criteria existed have no scores.
+	Scores []RateScore `db:"-" json:"scores,omitempty"`
 
 	
 
     
+}
+
+// HeldResponse is an HR response waiting in the moderation queue.
+type HeldResponse struct {
+	RateID            int        `db:"rate_id" json:"rate_id"`
+	HRProfileID       int        `db:"hr_profile_id" json:"hr_profile_id"`
+	Response          string     `db:"hr_response" json:"response"`
+	ModerationReasons JSONB      `db:"hr_response_moderation_reasons" json:"moderation_reasons,omitempty"`
+	RespondedAt       *time.Time `db:"hr_response_at" json:"responded_at"`
+}
+
+// RateRevision is a snapshot of a rate taken before it was edited or deleted.
+type RateRevision struct {
+	ID            int       `db:"id" json:"id"`
+	RateID        int       `db:"rate_id" json:"rate_id"`
+	Action        string    `db:"action" json:"action"` // edit | delete
+	ReviewText    string    `db:"review_text" json:"review_text"`
+	RateValue     float32   `db:"rate_value" json:"rate_value"`
+	RatingContext *string   `db:"rating_context" json:"rating_context,omitempty"`
+	IsAnonymous   bool      `db:"is_anonymous" json:"is_anonymous"`
+	Scores        JSONB     `db:"scores" json:"scores,omitempty"`
+	CreatedAt     time.Time `db:"created_at" json:"created_at"`
 }
 
 type RateWithDetails struct {
@@ -125,6 +388,9 @@ type RateWithDetails struct {
 
 	BadgeID         int       `db:"badge_id" json:"badge_id"`
     BadgeRate       float32   `db:"badge_rate" json:"badge_rate"`
+	BadgeCode       *string   `db:"badge_code" json:"badge_code,omitempty"`
+	BadgeTier       *string   `db:"badge_tier" json:"badge_tier,omitempty"`
+	BadgeIcon       *string   `db:"badge_icon" json:"badge_icon,omitempty"`
 
     EmployeeName      *string    `db:"employee_name" json:"employee_name"`
     EmployeeImage *string    `db:"employee_image" json:"employee_image"`
@@ -132,8 +398,124 @@ type RateWithDetails struct {
 
 type EmployeeStats struct {
     TotalRatingsCount int     `json:"total_ratings_count"`
-    ContributorRank   string  `json:"contributor_rank"` // يمكن أن تكون "Top 10%"
+    ContributorRank   string  `json:"contributor_rank"` // "Top 3%" | Contributor | Newbie
     TotalLikesCount   int     `json:"total_likes_count"`
+    Points            int     `json:"points"`
+    Percentiles       *ContributorPercentiles `json:"percentiles,omitempty"`
+    Level             string                  `json:"level"` // newcomer | trusted | expert
+    Privileges        *ContributorPrivileges  `json:"privileges,omitempty"`
+    Achievements      []EmployeeAchievement   `json:"achievements"`
+    Streak            *ContributionStreak     `json:"streak,omitempty"`
+}
+
+// Contributor levels, lowest first. They are computed from the points ledger,
+// review quality and verification, with configurable thresholds.
+const (
+	LevelNewcomer = "newcomer"
+	LevelTrusted  = "trusted"
+	LevelExpert   = "expert"
+)
+
+// ContributorMetrics are what the level of a contributor is decided on.
+type ContributorMetrics struct {
+	EmployeeID      int    `db:"employee_id"`
+	Level           string `db:"contributor_level"` // stored level
+	Points          int    `db:"points"` // ledger points, without the points for casting votes
+	Reviews         int    `db:"reviews"` // published rates
+	RejectedReviews int    `db:"rejected_reviews"`
+	HelpfulVotes    int    `db:"helpful_votes"`
+	UnhelpfulVotes  int    `db:"unhelpful_votes"`
+	Verified        bool   `db:"verified"` // verified account or a verified published rate
+}
+
+// ContributorPrivileges is what a contributor level unlocks.
+type ContributorPrivileges struct {
+	ReviewWeight       float64 `json:"review_weight"`        // weight of their rates in the HR score
+	ReportWeight       float64 `json:"report_weight"`        // weight of their reports
+	SkipModerationHold bool    `json:"skip_moderation_hold"` // rates are not held for a moderator
+}
+
+// ContributorPercentiles place an employee among all contributors (0..100,
+// the share ranked below). Only public rates are counted.
+type ContributorPercentiles struct {
+	Points     float64 `db:"points" json:"points"`
+	Reviews    float64 `db:"reviews" json:"reviews"`
+	Likes      float64 `db:"likes" json:"likes"`
+	Overall    float64 `db:"overall" json:"overall"`
+	TopPercent float64 `db:"top_percent" json:"top_percent"` // share ranked at or above, by overall score
+}
+
+// ContributorStanding is where one employee stands among all contributors, all-time.
+type ContributorStanding struct {
+	EmployeeID  int
+	Points      int
+	Score       float64 // 0 = no contribution yet
+	Percentiles ContributorPercentiles
+}
+
+// Leaderboards and their scopes.
+const (
+	BoardContributors = "contributors"
+	BoardHRs          = "hrs"
+
+	ScopeOverall     = "overall"
+	ScopeCity        = "city"
+	ScopeJobField    = "job_field"
+	ScopeCompany     = "company"
+	ScopeJobPosition = "job_position"
+
+	PeriodAll   = "all"
+	PeriodMonth = "month"
+)
+
+// LeaderboardQuery selects a leaderboard. Value filters the scope (a city, a
+// company...); empty returns every scope value.
+type LeaderboardQuery struct {
+	Board  string
+	Scope  string
+	Value  string
+	Period string
+	Month  *time.Time // first day of the month for monthly boards
+	Limit  int        // entries per scope value
+}
+
+// LeaderboardEntry is one ranked contributor or HR. Contributors carry points,
+// reviews and likes; HRs carry rates_count and score is their rating.
+type LeaderboardEntry struct {
+	Rank       int     `db:"rank" json:"rank"`
+	SubjectID  int     `db:"subject_id" json:"id"`
+	Name       *string `db:"name" json:"name"`
+	Image      *string `db:"image" json:"image,omitempty"`
+	ScopeValue string  `db:"scope_value" json:"scope_value,omitempty"`
+	Score      float64 `db:"score" json:"score"`
+	Percentile float64 `db:"percentile" json:"percentile"`
+	TopPercent float64 `db:"top_percent" json:"top_percent"`
+	Points     *int    `db:"points" json:"points,omitempty"`
+	Reviews    *int    `db:"reviews" json:"reviews,omitempty"`
+	Likes      *int    `db:"likes" json:"likes,omitempty"`
+	RatesCount *int    `db:"rates_count" json:"rates_count,omitempty"`
+}
+
+// Leaderboard is a live ranking or a snapshot of one.
+type Leaderboard struct {
+	Board       string             `json:"board"`
+	Scope       string             `json:"scope"`
+	Period      string             `json:"period"`
+	PeriodStart *time.Time         `json:"period_start,omitempty"`
+	SnapshotID  *int64             `json:"snapshot_id,omitempty"`
+	TakenAt     *time.Time         `json:"taken_at,omitempty"` // snapshots only
+	Entries     []LeaderboardEntry `json:"entries"`
+}
+
+// LeaderboardSnapshot is a stored ranking.
+type LeaderboardSnapshot struct {
+	ID          int64      `db:"id" json:"id"`
+	Board       string     `db:"board" json:"board"`
+	Scope       string     `db:"scope" json:"scope"`
+	Period      string     `db:"period" json:"period"`
+	PeriodStart *time.Time `db:"period_start" json:"period_start,omitempty"`
+	TakenOn     time.Time  `db:"taken_on" json:"taken_on"`
+	TakenAt     time.Time  `db:"taken_at" json:"taken_at"`
 }
 
 type RateWithEmployee struct {
@@ -143,6 +525,88 @@ type RateWithEmployee struct {
     EmployeeAvatar string    `db:"employee_avatar" json:"employee_avatar"`
 }
 
+// Reasons of points ledger entries.
+const (
+	PointsReasonRateVote    = "rate_vote"    // +1 to an employee who likes a rate
+	PointsReasonHelpfulRate = "helpful_rate" // +10 to the author of a rate with 50 likes
+	PointsReasonAchievement = "achievement"  // the points of an achievement, once
+)
+
+// AchievementDefinition is an employee achievement as the catalog defines it.
+type AchievementDefinition struct {
+	Code   string `json:"code"`
+	NameEn string `json:"name_en"`
+	NameAr string `json:"name_ar"`
+	Points int    `json:"points"`
+}
+
+// EmployeeAchievement is an achievement an employee earned. Definition is
+// filled from the catalog and nil for codes it no longer has.
+type EmployeeAchievement struct {
+	ID         int                    `db:"id" json:"id"`
+	EmployeeID int                    `db:"employee_id" json:"employee_id"`
+	Code       string                 `db:"code" json:"code"`
+	Points     int                    `db:"points" json:"points"`
+	Note       *string                `db:"note" json:"note,omitempty"`
+	AwardedAt  time.Time              `db:"awarded_at" json:"awarded_at"`
+	Definition *AchievementDefinition `db:"-" json:"definition,omitempty"`
+}
+
+// AchievementStats are what employee achievements are evaluated on. Reviews
+// are published rates signed with the author's name: anonymous rates are left
+// out so an award can not date them.
+type AchievementStats struct {
+	EmployeeID      int `db:"employee_id"`
+	Reviews         int `db:"reviews"`
+	HelpfulReviews  int `db:"helpful_reviews"`
+	VerifiedReviews int `db:"verified_reviews"`
+	CurrentStreak   int `db:"current_streak"` // weeks in a row with a contribution, ending this week or the last
+	LongestStreak   int `db:"longest_streak"`
+}
+
+// ContributionStreak counts consecutive weeks in which an employee published a
+// signed review.
+type ContributionStreak struct {
+	CurrentWeeks int `json:"current_weeks"`
+	LongestWeeks int `json:"longest_weeks"`
+}
+
+// AchievementProgress is an achievement not earned yet with how far along the
+// employee is.
+type AchievementProgress struct {
+	Definition AchievementDefinition `json:"definition"`
+	Progress   int                   `json:"progress"`
+	Target     int                   `json:"target"`
+}
+
+// EmployeeAchievements is the achievements section of an employee profile.
+type EmployeeAchievements struct {
+	EmployeeID int                   `json:"employee_id"`
+	Earned     []EmployeeAchievement `json:"earned"`
+	Locked     []AchievementProgress `json:"locked"`
+	Streak     ContributionStreak    `json:"streak"`
+}
+
+// PointsEntry is one movement of an employee's points. The idempotency key
+// makes recording it twice a no-op.
+type PointsEntry struct {
+	ID             int64     `db:"id" json:"id"`
+	EmployeeID     int       `db:"employee_id" json:"employee_id"`
+	Delta          int       `db:"delta" json:"delta"`
+	Reason         string    `db:"reason" json:"reason"`
+	SourceType     *string   `db:"source_type" json:"source_type,omitempty"` // e.g. rate
+	SourceID       *int      `db:"source_id" json:"source_id,omitempty"`
+	IdempotencyKey string    `db:"idempotency_key" json:"-"`
+	CreatedAt      time.Time `db:"created_at" json:"created_at"`
+}
+
+// PointsHistory is the balance of an employee with a page of their ledger.
+type PointsHistory struct {
+	EmployeeID int           `json:"employee_id"`
+	Balance    int           `json:"balance"`
+	Items      []PointsEntry `json:"items"`
+}
+
 // Like/dislike on a rate
 type RateLike struct {
 	ID         int       `db:"id" json:"id"`
@@ -152,6 +616,89 @@ type RateLike struct {
 	CreatedAt  time.Time `db:"created_at" json:"created_at"`
 }
 
+// RateVoteResult is the state of a rate after a vote changed, with the vote the
+// employee had before (nil when they had not voted).
+type RateVoteResult struct {
+	RateID         int     `json:"rate_id"`
+	HRProfileID    int     `json:"hr_profile_id"`
+	AuthorID       int     `json:"-"`
+	PreviousVote   *bool   `json:"-"`
+	PreviousLikes  int     `json:"-"`
+	LikesCount     int     `json:"likes_count"`
+	DislikesCount  int     `json:"dislikes_count"`
+	Helpfulness    float32 `json:"helpfulness"`
+	AuthorPoints   bool    `json:"-"` // the vote moved the author's points
+}
+
+// RateVotePoints are the points a vote settles with it: Voter while the voter
+// likes the rate, HelpfulAuthor while the rate has at least HelpfulLikes likes.
+type RateVotePoints struct {
+	Voter         int
+	HelpfulAuthor int
+	HelpfulLikes  int
+}
+
+// BadgeMetrics are the rule metrics of one profile, keyed like "rates_count(30d)".
+type BadgeMetrics struct {
+	HRProfileID int                `json:"hr_profile_id"`
+	Name        *string            `json:"name"`
+	Values      map[string]float64 `json:"metrics"`
+}
+
+// BadgeDryRun is the result of evaluating a badge rule against every profile
+// without awarding anything.
+type BadgeDryRun struct {
+	Rule            string               `json:"rule"`
+	DefinitionID    *int                 `json:"definition_id,omitempty"`
+	ProfilesChecked int                  `json:"profiles_checked"`
+	QualifyingCount int                  `json:"qualifying_count"`
+	NewAwardsCount  int                  `json:"new_awards_count"` // qualifying profiles without the badge yet
+	Profiles        []BadgeDryRunProfile `json:"profiles"`         // first qualifying profiles, up to the limit
+}
+
+type BadgeDryRunProfile struct {
+	BadgeMetrics
+	AlreadyAwarded bool `json:"already_awarded"`
+}
+
+// Events that queue a badge evaluation.
+const (
+	BadgeTriggerRateCreated     = "rate_created"
+	BadgeTriggerRateLiked       = "rate_liked"
+	BadgeTriggerRateVerified    = "rate_verified"
+	BadgeTriggerProfileVerified = "profile_verified"
+	BadgeTriggerRuleChanged     = "rule_changed"
+	BadgeTriggerSchedule        = "schedule"
+	BadgeTriggerManual          = "manual"
+)
+
+// BadgeEvaluationJob is a queued badge evaluation; HRProfileID nil re-evaluates every profile.
+type BadgeEvaluationJob struct {
+	ID                int64      `db:"id" json:"id"`
+	HRProfileID       *int       `db:"hr_profile_id" json:"hr_profile_id"`
+	Trigger           string     `db:"trigger" json:"trigger"`
+	Status            string     `db:"status" json:"status"` // pending | running | done | failed
+	ProfilesTotal     int        `db:"profiles_total" json:"profiles_total"`
+	ProfilesProcessed int        `db:"profiles_processed" json:"profiles_processed"`
+	BadgesAwarded     int        `db:"badges_awarded" json:"badges_awarded"`
+	BadgesRevoked     int        `db:"badges_revoked" json:"badges_revoked"`
+	LastError         *string    `db:"last_error" json:"last_error,omitempty"`
+	StartedAt         *time.Time `db:"started_at" json:"started_at,omitempty"`
+	FinishedAt        *time.Time `db:"finished_at" json:"finished_at,omitempty"`
+	CreatedAt         time.Time  `db:"created_at" json:"created_at"`
+	UpdatedAt         time.Time  `db:"updated_at" json:"updated_at"`
+}
+
+// BadgeVoteResult is the outcome of a vote change on a badge.
+type BadgeVoteResult struct {
+	BadgeID       int   `json:"badge_id"`
+	HRProfileID   int   `json:"hr_profile_id"`
+	PreviousVote  *bool `json:"-"`
+	LikesCount    int   `json:"likes_count"`
+	DislikesCount int   `json:"dislikes_count"`
+	MyVote        *bool `json:"my_vote"`
+}
+
 // Like/dislike on a badge (fixed typo)
 type BadgeLike struct {
 	ID         int       `db:"id" json:"id"`
@@ -160,3 +707,103 @@ type BadgeLike struct {
 	IsLike     bool      `db:"is_like" json:"is_like"`
 	CreatedAt  time.Time `db:"created_at" json:"created_at"`
 }
+
+// Notification is an in-app message for an employee or an HR.
+type Notification struct {
+	ID            int        `db:"id" json:"id"`
+	RecipientType string     `db:"recipient_type" json:"recipient_type"` // employee | hr
+	RecipientID   int        `db:"recipient_id" json:"recipient_id"`
+	Kind          string     `db:"kind" json:"kind"`
+	Title         string     `db:"title" json:"title"`
+	Payload       JSONB      `db:"payload" json:"payload,omitempty"`
+	ReadAt        *time.Time `db:"read_at" json:"read_at,omitempty"`
+	CreatedAt     time.Time  `db:"created_at" json:"created_at"`
+}
+
+// Verification methods a reviewer can use to prove they worked with the HR's company.
+const (
+	VerificationWorkEmail   = "work_email"
+	VerificationDocument    = "document"
+	VerificationInviteToken = "invite_token"
+)
+
+// RateVerification is a proof-of-employment request attached to a rate.
+type RateVerification struct {
+	ID            int        `db:"id" json:"id"`
+	RateID        int        `db:"rate_id" json:"rate_id"`
+	EmployeeID    int        `db:"employee_id" json:"employee_id"`
+	Method        string     `db:"method" json:"method"`
+	Status        string     `db:"status" json:"status"` // pending | verified | rejected
+	Email         *string    `db:"email" json:"email,omitempty"`
+	CodeHash      *string    `db:"code_hash" json:"-"`
+	Attempts      int        `db:"attempts" json:"-"`
+	DocumentPath  *string    `db:"document_path" json:"document_path,omitempty"`
+	InviteTokenID *int       `db:"invite_token_id" json:"-"`
+	ExpiresAt     *time.Time `db:"expires_at" json:"expires_at,omitempty"`
+	ReviewedBy    *int       `db:"reviewed_by" json:"reviewed_by,omitempty"`
+	ReviewNote    *string    `db:"review_note" json:"review_note,omitempty"`
+	ReviewedAt    *time.Time `db:"reviewed_at" json:"reviewed_at,omitempty"`
+	VerifiedAt    *time.Time `db:"verified_at" json:"verified_at,omitempty"`
+	CreatedAt     time.Time  `db:"created_at" json:"created_at"`
+}
+
+// HRInviteToken lets an HR vouch for reviewers from their company.
+type HRInviteToken struct {
+	ID          int        `db:"id" json:"id"`
+	HRProfileID int        `db:"hr_profile_id" json:"hr_profile_id"`
+	TokenHash   string     `db:"token_hash" json:"-"`
+	MaxUses     int        `db:"max_uses" json:"max_uses"`
+	UsedCount   int        `db:"used_count" json:"used_count"`
+	ExpiresAt   *time.Time `db:"expires_at" json:"expires_at,omitempty"`
+	RevokedAt   *time.Time `db:"revoked_at" json:"revoked_at,omitempty"`
+	CreatedAt   time.Time  `db:"created_at" json:"created_at"`
+
+	Token string `db:"-" json:"token,omitempty"` // plain token, only returned once on creation
+}
+
+// Report is a user's flag on a rate or a badge.
+type Report struct {
+	ID             int        `db:"id" json:"id"`
+	TargetType     string     `db:"target_type" json:"target_type"` // rate | badge
+	TargetID       int        `db:"target_id" json:"target_id"`
+	ReporterID     int        `db:"reporter_id" json:"reporter_id"`
+	Reason         string     `db:"reason" json:"reason"` // harassment | false_information | spam | personal_data | conflict_of_interest | other
+	Details        *string    `db:"details" json:"details,omitempty"`
+	Weight         float32    `db:"weight" json:"weight"` // reporter credibility when the report was filed
+	Status         string     `db:"status" json:"status"` // open | upheld | dismissed
+	ResolvedBy     *int       `db:"resolved_by" json:"resolved_by,omitempty"`
+	ResolutionNote *string    `db:"resolution_note" json:"resolution_note,omitempty"`
+	ResolvedAt     *time.Time `db:"resolved_at" json:"resolved_at,omitempty"`
+	CreatedAt      time.Time  `db:"created_at" json:"created_at"`
+}
+
+// ReportResolution is what a moderator's decision on a reported item changed.
+type ReportResolution struct {
+	Status           string // upheld | dismissed
+	ReporterIDs      []int
+	Rate             *Rate // the reported rate, when its status changed
+	BadgeHRProfileID int   // the profile of the badge that was hidden, 0 otherwise
+}
+
+// AuditEntry records one moderation action; ActorID is nil for automatic ones.
+type AuditEntry struct {
+	ID         int64     `db:"id" json:"id"`
+	ActorID    *int      `db:"actor_id" json:"actor_id"`
+	Action     string    `db:"action" json:"action"`
+	TargetType string    `db:"target_type" json:"target_type"`
+	TargetID   int       `db:"target_id" json:"target_id"`
+	Details    JSONB     `db:"details" json:"details,omitempty"`
+	CreatedAt  time.Time `db:"created_at" json:"created_at"`
+}
+
+// FraudInputs are the raw per-rate measurements the fraud score is built from.
+type FraudInputs struct {
+	RateID               int     `db:"rate_id"`
+	AccountAgeHours      float64 `db:"account_age_hours"`
+	TargetBurst          int     `db:"target_burst"`           // similar rates on the same HR within 24h
+	AuthorVelocity       int     `db:"author_velocity"`        // rates by the author within 24h
+	SharedIPAccounts     int     `db:"shared_ip_accounts"`     // other authors rating the same HR from the same IP
+	SharedDeviceAccounts int     `db:"shared_device_accounts"` // same, by device fingerprint
+	ReciprocalLikes      int     `db:"reciprocal_likes"`       // employees who like the author's rates and are liked back
+	NearDuplicate        float64 `db:"-"`                      // highest text similarity with another author's rate (0-1)
+}
@@ -0,0 +1,303 @@
+package moderation
+
+import (
+	"bufio"
+	"embed"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+//go:embed lexicon/*.txt
+var lexiconFS embed.FS
+
+// loadWordList reads a lexicon file, skipping blank lines and # comments.
+func loadWordList(name string) []string {
+	file, err := lexiconFS.Open("lexicon/" + name)
+	if err != nil {
+		panic(fmt.Sprintf("moderation: missing lexicon %s: %v", name, err))
+	}
+	defer file.Close()
+
+	var words []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		words = append(words, Normalize(line))
+	}
+	return words
+}
+
+var arabicNormalizer = strings.NewReplacer(
+	"أ", "ا", "إ", "ا", "آ", "ا", "ٱ", "ا",
+	"ة", "ه", "ى", "ي", "ؤ", "و", "ئ", "ي",
+	"ـ", "", // tatweel
+)
+
+// Normalize lowercases text, strips Arabic diacritics and unifies letter
+// variants so "غبيّ" and "غبي" or "Idiot" and "idiot" match the same entry.
+func Normalize(text string) string {
+	text = strings.ToLower(text)
+	text = strings.Map(func(r rune) rune {
+		if r >= 0x064B && r <= 0x065F || r == 0x0670 { // harakat
+			return -1
+		}
+		return r
+	}, text)
+	return arabicNormalizer.Replace(text)
+}
+
+// Tokens splits normalized text into words (letters and digits only).
+func Tokens(text string) []string {
+	return strings.FieldsFunc(Normalize(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// =================================================================
+// ⭐️ Profanity (Arabic + English)
+// =================================================================
+
+type ProfanityCheck struct {
+	words   map[string]bool
+	phrases []string
+}
+
+func NewProfanityCheck() *ProfanityCheck {
+	check := &ProfanityCheck{words: map[string]bool{}}
+	for _, file := range []string{"profanity_en.txt", "profanity_ar.txt"} {
+		for _, entry := range loadWordList(file) {
+			if strings.Contains(entry, " ") {
+				check.phrases = append(check.phrases, entry)
+			} else {
+				check.words[entry] = true
+			}
+		}
+	}
+	return check
+}
+
+func (c *ProfanityCheck) Name() string { return "profanity" }
+
+func (c *ProfanityCheck) Apply(text string) (string, []Finding) {
+	var hits []string
+	for _, token := range Tokens(text) {
+		// Arabic attaches the article/conjunctions: "والحمار" -> "حمار"
+		if c.words[token] || c.words[strings.TrimPrefix(token, "ال")] ||
+			c.words[strings.TrimPrefix(token, "وال")] || c.words[strings.TrimPrefix(token, "و")] {
+			hits = append(hits, token)
+		}
+	}
+	normalized := Normalize(text)
+	for _, phrase := range c.phrases {
+		if strings.Contains(normalized, phrase) {
+			hits = append(hits, phrase)
+		}
+	}
+
+	if len(hits) == 0 {
+		return text, nil
+	}
+	return text, []Finding{{
+		Reason:   fmt.Sprintf("contains %d offensive term(s)", len(hits)),
+		Severity: SeverityHold,
+	}}
+}
+
+// =================================================================
+// ⭐️ PII detection with redaction
+// =================================================================
+
+type PIICheck struct {
+	patterns []piiPattern
+}
+
+type piiPattern struct {
+	kind  string
+	re    *regexp.Regexp
+	valid func(match string) bool // nil = every match is redacted
+}
+
+func NewPIICheck() *PIICheck {
+	return &PIICheck{patterns: []piiPattern{
+		{"email", regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`), nil},
+		// Saudi/Gulf national and iqama IDs: 10 digits starting with 1 or 2
+		{"national_id", regexp.MustCompile(`\b[12]\d{9}\b`), nil},
+		// 9 to 15 digits, optionally after + or (, with at most two separators
+		// between digits: "055 123 4567", "+966 55 123 4567", "+1 (555) 123-4567".
+		// Dates are too short, and " - " ends a run so "10000 - 15000" stays intact
+		{"phone", regexp.MustCompile(`(?:\+|\(|\b)\d(?:[\s\-.()]{0,2}\d){8,14}`), isPhoneNumber},
+	}}
+}
+
+// isPhoneNumber tells a phone number from other long digit runs. Numbers
+// written with +, 00, a trunk 0 or an area code in brackets are phones; other
+// runs are phones when grouped like one ("555 123 4567") or too long for an
+// amount, so "10000-15000" or "12000 15000 18000" are kept.
+func isPhoneNumber(match string) bool {
+	if strings.HasPrefix(match, "+") || strings.HasPrefix(match, "(") || strings.HasPrefix(match, "0") {
+		return true
+	}
+	groups := strings.FieldsFunc(match, func(r rune) bool { return r < '0' || r > '9' })
+	if len(groups) == 1 {
+		return len(groups[0]) >= 11
+	}
+	if len(groups) < 3 {
+		return false
+	}
+	for _, group := range groups {
+		if len(group) > 4 {
+			return false
+		}
+	}
+	return true
+}
+
+func (c *PIICheck) Name() string { return "pii" }
+
+func (c *PIICheck) Apply(text string) (string, []Finding) {
+	text = toASCIIDigits(text)
+
+	var findings []Finding
+	for _, pattern := range c.patterns {
+		count := 0
+		text = pattern.re.ReplaceAllStringFunc(text, func(match string) string {
+			if pattern.valid != nil && !pattern.valid(match) {
+				return match
+			}
+			count++
+			return "[redacted]"
+		})
+		if count == 0 {
+			continue
+		}
+		findings = append(findings, Finding{
+			Reason:   fmt.Sprintf("redacted %d %s", count, pattern.kind),
+			Severity: SeverityInfo,
+		})
+	}
+	return text, findings
+}
+
+// toASCIIDigits converts Arabic-Indic digits so numbers written in Arabic are
+// caught by the same patterns.
+func toASCIIDigits(text string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= '٠' && r <= '٩':
+			return '0' + (r - '٠')
+		case r >= '۰' && r <= '۹':
+			return '0' + (r - '۰')
+		}
+		return r
+	}, text)
+}
+
+// =================================================================
+// ⭐️ Links and spam heuristics
+// =================================================================
+
+type LinkSpamCheck struct {
+	link *regexp.Regexp
+}
+
+func NewLinkSpamCheck() *LinkSpamCheck {
+	return &LinkSpamCheck{
+		link: regexp.MustCompile(`(?i)(https?://|www\.|\b[a-z0-9\-]+\.(com|net|org|io|sa|me|ly|co)\b|t\.me/|wa\.me/)`),
+	}
+}
+
+// longestRun is the longest sequence of the same character ("ممممممتاز").
+func longestRun(text string) int {
+	longest, run := 0, 0
+	var previous rune
+	for _, r := range text {
+		if r == previous {
+			run++
+		} else {
+			previous, run = r, 1
+		}
+		if run > longest {
+			longest = run
+		}
+	}
+	return longest
+}
+
+func (c *LinkSpamCheck) Name() string { return "link_spam" }
+
+func (c *LinkSpamCheck) Apply(text string) (string, []Finding) {
+	var findings []Finding
+
+	if c.link.MatchString(text) {
+		findings = append(findings, Finding{Reason: "contains a link", Severity: SeverityHold})
+	}
+
+	if longestRun(text) >= 6 {
+		findings = append(findings, Finding{Reason: "repeated characters", Severity: SeverityHold})
+	}
+
+	tokens := Tokens(text)
+	if len(tokens) >= 6 {
+		counts := map[string]int{}
+		for _, token := range tokens {
+			counts[token]++
+		}
+		for _, n := range counts {
+			if n*2 > len(tokens) {
+				findings = append(findings, Finding{Reason: "the same word is repeated", Severity: SeverityHold})
+				break
+			}
+		}
+	}
+
+	letters, upper := 0, 0
+	for _, r := range text {
+		if unicode.IsLetter(r) && r < unicode.MaxASCII {
+			letters++
+			if unicode.IsUpper(r) {
+				upper++
+			}
+		}
+	}
+	if letters >= 20 && upper*10 > letters*8 {
+		findings = append(findings, Finding{Reason: "mostly capital letters", Severity: SeverityHold})
+	}
+
+	return text, findings
+}
+
+// =================================================================
+// ⭐️ Minimum quality
+// =================================================================
+
+type QualityCheck struct {
+	minWords int
+}
+
+func NewQualityCheck(minWords int) *QualityCheck {
+	return &QualityCheck{minWords: minWords}
+}
+
+func (c *QualityCheck) Name() string { return "quality" }
+
+func (c *QualityCheck) Apply(text string) (string, []Finding) {
+	words := 0
+	for _, token := range Tokens(text) {
+		if utf8.RuneCountInString(token) > 1 && token != "redacted" {
+			words++
+		}
+	}
+	if words < c.minWords {
+		return text, []Finding{{
+			Reason:   fmt.Sprintf("needs at least %d meaningful words", c.minWords),
+			Severity: SeverityReject,
+		}}
+	}
+	return text, nil
+}
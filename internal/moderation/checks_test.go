@@ -0,0 +1,52 @@
+package moderation
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPIICheckRedactsPhoneNumbers(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{"saudi mobile", "call me on 0551234567", "call me on [redacted]"},
+		{"saudi mobile with spaces", "call me on 055 123 4567", "call me on [redacted]"},
+		{"saudi international", "whatsapp +966551234567", "whatsapp [redacted]"},
+		{"saudi international with spaces", "whatsapp +966 55 123 4567", "whatsapp [redacted]"},
+		{"saudi with 00", "whatsapp 00966 55 123 4567", "whatsapp [redacted]"},
+		{"saudi without plus", "whatsapp 966551234567", "whatsapp [redacted]"},
+		{"arabic digits", "رقمي ٠٥٥١٢٣٤٥٦٧", "رقمي [redacted]"},
+		{"egypt", "رقمي 010-1234-5678", "رقمي [redacted]"},
+		{"uk", "ring +44 20 7946 0958 later", "ring [redacted] later"},
+		{"us with area code", "ring (555) 123-4567", "ring [redacted]"},
+		{"us international", "ring +1 (555) 123-4567", "ring [redacted]"},
+		{"us grouped", "ring 555.123.4567", "ring [redacted]"},
+
+		{"salary range", "salary 10000 - 15000 SAR", "salary 10000 - 15000 SAR"},
+		{"salary range without spaces", "salary 10000-15000 SAR", "salary 10000-15000 SAR"},
+		{"salaries", "offers of 12000 15000 18000", "offers of 12000 15000 18000"},
+		{"amount", "a bonus of 150000000", "a bonus of 150000000"},
+		{"amount with commas", "a package of 1,500,000", "a package of 1,500,000"},
+		{"year range", "worked there 2019 - 2023", "worked there 2019 - 2023"},
+		{"iso date", "left on 2023-10-19", "left on 2023-10-19"},
+		{"dotted date", "left on 19.10.2023", "left on 19.10.2023"},
+		{"slashed date", "left on 19/10/2023", "left on 19/10/2023"},
+		{"short number", "room 1234 567", "room 1234 567"},
+	}
+
+	check := NewPIICheck()
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, findings := check.Apply(tc.text)
+			if got != tc.want {
+				t.Errorf("Apply(%q) = %q, want %q", tc.text, got, tc.want)
+			}
+			redacted := strings.Contains(tc.want, "[redacted]")
+			if (len(findings) > 0) != redacted {
+				t.Errorf("Apply(%q) findings = %v, want redaction %v", tc.text, findings, redacted)
+			}
+		})
+	}
+}
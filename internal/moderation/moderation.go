@@ -0,0 +1,112 @@
+// Package moderation checks user text (reviews, HR responses) before it is
+// published. A Pipeline runs pluggable Checks; each Check may redact the text
+// and report findings, and the most severe finding decides the outcome.
+package moderation
+
+import (
+	"strings"
+)
+
+// Outcomes of a moderation run.
+const (
+	StatusPublished = "published"
+	StatusHeld      = "held"
+	StatusRejected  = "rejected"
+)
+
+// Severity of a finding, ordered from harmless to blocking.
+type Severity int
+
+const (
+	SeverityInfo   Severity = iota // recorded only (e.g. redacted PII)
+	SeverityHold                   // needs a moderator before publishing
+	SeverityReject                 // never published
+)
+
+// Finding is one reason reported by a check.
+type Finding struct {
+	Check    string   `json:"check"`
+	Reason   string   `json:"reason"`
+	Severity Severity `json:"-"`
+	Level    string   `json:"level"` // info | hold | reject
+}
+
+// Check inspects a text and returns it (possibly redacted) with its findings.
+type Check interface {
+	Name() string
+	Apply(text string) (string, []Finding)
+}
+
+// Result is the outcome of a Pipeline run.
+type Result struct {
+	Status   string    `json:"status"`
+	Text     string    `json:"-"` // text after redaction, the one to store
+	Findings []Finding `json:"findings,omitempty"`
+}
+
+// Pipeline runs checks in order; later checks see the redacted text.
+type Pipeline struct {
+	checks []Check
+}
+
+func NewPipeline(checks ...Check) *Pipeline {
+	return &Pipeline{checks: checks}
+}
+
+// NewReviewPipeline is the pipeline for employee reviews: PII redaction first,
+// so the other checks and the stored text never see phone numbers or emails.
+func NewReviewPipeline() *Pipeline {
+	return NewPipeline(
+		NewPIICheck(),
+		NewProfanityCheck(),
+		NewLinkSpamCheck(),
+		NewQualityCheck(3),
+	)
+}
+
+// NewResponsePipeline is the pipeline for HR responses, where a short "thank
+// you" is a fine answer.
+func NewResponsePipeline() *Pipeline {
+	return NewPipeline(
+		NewPIICheck(),
+		NewProfanityCheck(),
+		NewLinkSpamCheck(),
+		NewQualityCheck(1),
+	)
+}
+
+func (p *Pipeline) Run(text string) Result {
+	result := Result{Status: StatusPublished, Text: strings.TrimSpace(text)}
+	worst := SeverityInfo
+
+	for _, check := range p.checks {
+		var findings []Finding
+		result.Text, findings = check.Apply(result.Text)
+		for _, finding := range findings {
+			finding.Check = check.Name()
+			finding.Level = levelName(finding.Severity)
+			if finding.Severity > worst {
+				worst = finding.Severity
+			}
+			result.Findings = append(result.Findings, finding)
+		}
+	}
+
+	switch worst {
+	case SeverityReject:
+		result.Status = StatusRejected
+	case SeverityHold:
+		result.Status = StatusHeld
+	}
+	return result
+}
+
+func levelName(severity Severity) string {
+	switch severity {
+	case SeverityReject:
+		return "reject"
+	case SeverityHold:
+		return "hold"
+	}
+	return "info"
+}
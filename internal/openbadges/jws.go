@@ -0,0 +1,77 @@
+package openbadges
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+)
+
+// Signer signs assertions as compact JWS with RS256, the algorithm Open Badges
+// 2.0 verifiers support.
+type Signer struct {
+	key *rsa.PrivateKey
+}
+
+// ParseSigner reads an RSA private key in PEM (PKCS#1 or PKCS#8).
+func ParseSigner(pemBytes []byte) (*Signer, error) {
+	block, _ := pem.Decode(pemBytes)
+	if block == nil {
+		return nil, errors.New("no PEM block in signing key")
+	}
+
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return newSigner(key)
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse signing key: %w", err)
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("signing key is not an RSA key")
+	}
+	return newSigner(key)
+}
+
+func newSigner(key *rsa.PrivateKey) (*Signer, error) {
+	if key.N.BitLen() < 2048 {
+		return nil, fmt.Errorf("signing key has %d bits, at least 2048 are required", key.N.BitLen())
+	}
+	return &Signer{key: key}, nil
+}
+
+// PublicKeyPEM is the public half of the key, published on the issuer.
+func (s *Signer) PublicKeyPEM() (string, error) {
+	der, err := x509.MarshalPKIXPublicKey(&s.key.PublicKey)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode public key: %w", err)
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})), nil
+}
+
+// Sign returns payload as a compact JWS: header.payload.signature.
+func (s *Signer) Sign(payload any) (string, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode JWS payload: %w", err)
+	}
+
+	input := encodeSegment([]byte(`{"alg":"RS256"}`)) + "." + encodeSegment(body)
+	digest := sha256.Sum256([]byte(input))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, s.key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", fmt.Errorf("failed to sign assertion: %w", err)
+	}
+	return input + "." + encodeSegment(signature), nil
+}
+
+func encodeSegment(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
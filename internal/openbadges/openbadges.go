@@ -0,0 +1,180 @@
+// Package openbadges renders awarded badges as IMS Open Badges 2.0 documents
+// (Issuer, BadgeClass, Assertion, RevocationList) so HRs can show them on
+// their own sites and on LinkedIn, and signs assertions as JWS.
+//
+// Every document has a stable URL under the public base URL:
+//
+//	/openbadges/issuer              Issuer profile
+//	/openbadges/issuer/key          public key of signed assertions
+//	/openbadges/revocations         revoked assertions
+//	/openbadges/badges/{id}         BadgeClass of a badge definition
+//	/openbadges/assertions/{id}     hosted Assertion of an awarded badge
+//
+// The package only builds documents; loading badges is the caller's job.
+package openbadges
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Context is the JSON-LD context of every Open Badges 2.0 document.
+const Context = "https://w3id.org/openbadges/v2"
+
+// Verification types of an assertion.
+const (
+	VerificationHosted = "hosted"
+	VerificationSigned = "signed"
+)
+
+// URLs builds the public URLs of the documents.
+type URLs struct {
+	Base string // public base URL without a trailing slash
+}
+
+func (u URLs) Issuer() string {
+	return u.Base + "/openbadges/issuer"
+}
+
+func (u URLs) PublicKey() string {
+	return u.Base + "/openbadges/issuer/key"
+}
+
+func (u URLs) RevocationList() string {
+	return u.Base + "/openbadges/revocations"
+}
+
+func (u URLs) BadgeClass(definitionID int) string {
+	return fmt.Sprintf("%s/openbadges/badges/%d", u.Base, definitionID)
+}
+
+func (u URLs) Assertion(badgeID int) string {
+	return fmt.Sprintf("%s/openbadges/assertions/%d", u.Base, badgeID)
+}
+
+// Profile is the public HR profile page, the evidence of an assertion.
+func (u URLs) Profile(profileID int) string {
+	return fmt.Sprintf("%s/hr/%d", u.Base, profileID)
+}
+
+// Image is the absolute URL of a badge icon: icons that are already URLs are
+// kept, icon keys are served from ./public/images/badges.
+func (u URLs) Image(icon *string) string {
+	if icon == nil || *icon == "" {
+		return u.Base + "/images/badges/default.png"
+	}
+	if strings.HasPrefix(*icon, "http://") || strings.HasPrefix(*icon, "https://") {
+		return *icon
+	}
+	return u.Base + "/images/badges/" + *icon + ".png"
+}
+
+// Issuer is the organization awarding the badges.
+type Issuer struct {
+	Context        string `json:"@context"`
+	Type           string `json:"type"`
+	ID             string `json:"id"`
+	Name           string `json:"name"`
+	URL            string `json:"url"`
+	Email          string `json:"email,omitempty"`
+	PublicKey      string `json:"publicKey,omitempty"` // set when assertions are signed
+	RevocationList string `json:"revocationList"`
+}
+
+// CryptographicKey publishes the key that signs assertions.
+type CryptographicKey struct {
+	Context      string `json:"@context"`
+	Type         string `json:"type"`
+	ID           string `json:"id"`
+	Owner        string `json:"owner"`
+	PublicKeyPem string `json:"publicKeyPem"`
+}
+
+// Criteria describes how the badge is earned.
+type Criteria struct {
+	Narrative string `json:"narrative"`
+}
+
+// BadgeClass is a badge of the catalog.
+type BadgeClass struct {
+	Context     string   `json:"@context"`
+	Type        string   `json:"type"`
+	ID          string   `json:"id"`
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Image       string   `json:"image"`
+	Criteria    Criteria `json:"criteria"`
+	Issuer      string   `json:"issuer"`
+	Tags        []string `json:"tags,omitempty"`
+}
+
+// IdentityObject is the recipient of an assertion. Emails are only published
+// hashed with the salt.
+type IdentityObject struct {
+	Type     string `json:"type"`
+	Hashed   bool   `json:"hashed"`
+	Salt     string `json:"salt,omitempty"`
+	Identity string `json:"identity"`
+}
+
+// Verification tells a verifier how to check an assertion.
+type Verification struct {
+	Type    string `json:"type"`
+	Creator string `json:"creator,omitempty"` // public key URL of signed assertions
+}
+
+// Evidence points to the profile the badge was earned on.
+type Evidence struct {
+	ID        string `json:"id"`
+	Narrative string `json:"narrative,omitempty"`
+}
+
+// Assertion is one awarded badge.
+type Assertion struct {
+	Context          string         `json:"@context"`
+	Type             string         `json:"type"`
+	ID               string         `json:"id"`
+	Recipient        IdentityObject `json:"recipient"`
+	Badge            string         `json:"badge"`
+	IssuedOn         string         `json:"issuedOn"`
+	Expires          string         `json:"expires,omitempty"`
+	Verification     Verification   `json:"verification"`
+	Evidence         []Evidence     `json:"evidence,omitempty"`
+	Revoked          bool           `json:"revoked,omitempty"`
+	RevocationReason string         `json:"revocationReason,omitempty"`
+}
+
+// RevokedAssertion is an entry of the revocation list.
+type RevokedAssertion struct {
+	ID               string `json:"id"`
+	RevocationReason string `json:"revocationReason,omitempty"`
+}
+
+// RevocationList lists the revoked assertions of the issuer.
+type RevocationList struct {
+	Context           string             `json:"@context"`
+	Type              string             `json:"type"`
+	ID                string             `json:"id"`
+	Issuer            string             `json:"issuer"`
+	RevokedAssertions []RevokedAssertion `json:"revokedAssertions"`
+}
+
+// EmailIdentity hashes an email the way verifiers expect:
+// "sha256$" + hex(sha256(lowercase email + salt)).
+func EmailIdentity(email, salt string) IdentityObject {
+	sum := sha256.Sum256([]byte(strings.ToLower(strings.TrimSpace(email)) + salt))
+	return IdentityObject{Type: "email", Hashed: true, Salt: salt, Identity: "sha256$" + hex.EncodeToString(sum[:])}
+}
+
+// URLIdentity identifies a recipient without an email by their profile URL.
+func URLIdentity(url string) IdentityObject {
+	return IdentityObject{Type: "url", Identity: url}
+}
+
+// Timestamp formats a time as ISO 8601 in UTC.
+func Timestamp(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
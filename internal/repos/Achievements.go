@@ -0,0 +1,135 @@
+package repos
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"githup.ahmedramadan.4cashier/internal/models"
+)
+
+var ErrAchievementAlreadyAwarded = errors.New("the employee already holds this achievement")
+
+// =================================================================
+// 🏅 Employee achievements (إنجازات الموظفين)
+// =================================================================
+
+// achievementStatsSQL gathers the achievement inputs of one employee ($1). A
+// helpful review has at least $2 likes and more likes than dislikes. Streaks
+// are runs of consecutive ISO weeks with a published signed review: weeks
+// minus their row number stay constant along a run (gaps and islands).
+func achievementStatsSQL() string {
+	return `
+    WITH weeks AS (
+        SELECT DISTINCT date_trunc('week', r.created_at AT TIME ZONE 'UTC')::date AS week
+        FROM rates r
+        WHERE r.employee_id = $1 AND r.is_anonymous IS NOT TRUE AND ` + publishedRates("r") + `
+    ),
+    streaks AS (
+        SELECT MAX(week) AS last_week, COUNT(*) AS weeks
+        FROM (SELECT week, week - (ROW_NUMBER() OVER (ORDER BY week))::int * 7 AS island FROM weeks) w
+        GROUP BY island
+    )
+    SELECT e.id AS employee_id, s.reviews, s.helpful_reviews, s.verified_reviews,
+           COALESCE(st.current_streak, 0) AS current_streak,
+           COALESCE(st.longest_streak, 0) AS longest_streak
+    FROM employees e
+    CROSS JOIN (
+        SELECT COUNT(*) AS reviews,
+               COUNT(*) FILTER (WHERE COALESCE(r.likes_count, 0) >= $2
+                                  AND COALESCE(r.likes_count, 0) > r.dislikes_count) AS helpful_reviews,
+               COUNT(*) FILTER (WHERE COALESCE(r.is_verified, false)) AS verified_reviews
+        FROM rates r
+        WHERE r.employee_id = $1 AND r.is_anonymous IS NOT TRUE AND ` + publishedRates("r") + `
+    ) s
+    CROSS JOIN (
+        -- السلسلة الحالية حية إذا انتهت هذا الأسبوع أو الأسبوع الماضي
+        SELECT MAX(weeks) FILTER (WHERE last_week >= date_trunc('week', NOW() AT TIME ZONE 'UTC')::date - 7) AS current_streak,
+               MAX(weeks) AS longest_streak
+        FROM streaks
+    ) st
+    WHERE e.id = $1
+`
+}
+
+func (r *PosHRRepository) GetAchievementStats(ctx context.Context, employeeID int, helpfulLikes int) (*models.AchievementStats, error) {
+	var stats models.AchievementStats
+	err := r.DB.GetContext(ctx, &stats, achievementStatsSQL(), employeeID, helpfulLikes)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrEmployeeNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch achievement stats of employee %d: %w", employeeID, err)
+	}
+	return &stats, nil
+}
+
+func (r *PosHRRepository) GetEmployeeAchievements(ctx context.Context, employeeID int) ([]models.EmployeeAchievement, error) {
+	achievements := []models.EmployeeAchievement{}
+	query := `
+        SELECT id, employee_id, code, points, note, awarded_at
+        FROM employee_achievements
+        WHERE employee_id = $1
+        ORDER BY awarded_at DESC, id DESC
+    `
+	if err := r.DB.SelectContext(ctx, &achievements, query, employeeID); err != nil {
+		return nil, fmt.Errorf("failed to fetch achievements of employee %d: %w", employeeID, err)
+	}
+	return achievements, nil
+}
+
+// AwardAchievement stores an achievement and records its points in the same
+// transaction, so an achievement and its points exist together or not at all.
+// An achievement the employee already holds returns ErrAchievementAlreadyAwarded.
+func (r *PosHRRepository) AwardAchievement(ctx context.Context, achievement *models.EmployeeAchievement) error {
+	tx, err := r.DB.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `
+        INSERT INTO employee_achievements (employee_id, code, points, note, awarded_at)
+        VALUES ($1, $2, $3, $4, NOW())
+        ON CONFLICT (employee_id, code) DO NOTHING
+        RETURNING id, awarded_at
+    `
+	err = tx.QueryRowxContext(ctx, query, achievement.EmployeeID, achievement.Code, achievement.Points, achievement.Note).
+		Scan(&achievement.ID, &achievement.AwardedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrAchievementAlreadyAwarded
+	}
+	if err != nil {
+		return fmt.Errorf("failed to award achievement %s to employee %d: %w", achievement.Code, achievement.EmployeeID, err)
+	}
+
+	if achievement.Points != 0 {
+		sourceType := "achievement"
+		entry := &models.PointsEntry{
+			EmployeeID:     achievement.EmployeeID,
+			Delta:          achievement.Points,
+			Reason:         models.PointsReasonAchievement,
+			SourceType:     &sourceType,
+			SourceID:       &achievement.ID,
+			IdempotencyKey: fmt.Sprintf("%s:%s:%d", models.PointsReasonAchievement, achievement.Code, achievement.EmployeeID),
+		}
+		if _, err := insertPoints(ctx, tx, entry); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit achievement %s of employee %d: %w", achievement.Code, achievement.EmployeeID, err)
+	}
+	return nil
+}
+
+// GetEmployeeIDs lists every employee, for re-evaluating achievements in bulk.
+func (r *PosHRRepository) GetEmployeeIDs(ctx context.Context) ([]int, error) {
+	ids := []int{}
+	if err := r.DB.SelectContext(ctx, &ids, `SELECT id FROM employees ORDER BY id`); err != nil {
+		return nil, fmt.Errorf("failed to list employees: %w", err)
+	}
+	return ids, nil
+}
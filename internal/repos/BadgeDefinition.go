@@ -0,0 +1,131 @@
+package repos
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/lib/pq"
+	"githup.ahmedramadan.4cashier/internal/models"
+)
+
+var (
+	ErrBadgeDefinitionNotFound = errors.New("badge definition not found")
+	ErrBadgeDefinitionExists   = errors.New("a badge definition with this code already exists")
+	ErrBadgeDefinitionInUse    = errors.New("badge definition has awarded badges; deactivate it instead")
+)
+
+const badgeDefinitionColumns = `id, code, name_en, name_ar, description_en, description_ar, icon, tier, category,
+               rule, available_from, available_until, validity_days, revoke_grace_days, is_active, sort_order, created_at, updated_at`
+
+// =================================================================
+// 🏅 Badge Definitions (كتالوج الشارات)
+// =================================================================
+
+func (r *PosHRRepository) GetBadgeDefinitions(ctx context.Context, activeOnly bool) ([]models.BadgeDefinition, error) {
+	definitions := []models.BadgeDefinition{}
+	query := `
+        SELECT ` + badgeDefinitionColumns + `
+        FROM badge_definitions
+        WHERE ($1 = false OR is_active = true)
+        ORDER BY sort_order, id
+    `
+	if err := r.DB.SelectContext(ctx, &definitions, query, activeOnly); err != nil {
+		return nil, fmt.Errorf("failed to fetch badge definitions: %w", err)
+	}
+	return definitions, nil
+}
+
+func (r *PosHRRepository) GetBadgeDefinition(ctx context.Context, definitionID int) (*models.BadgeDefinition, error) {
+	var definition models.BadgeDefinition
+	query := `SELECT ` + badgeDefinitionColumns + ` FROM badge_definitions WHERE id = $1`
+	err := r.DB.GetContext(ctx, &definition, query, definitionID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrBadgeDefinitionNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch badge definition %d: %w", definitionID, err)
+	}
+	return &definition, nil
+}
+
+func (r *PosHRRepository) GetBadgeDefinitionByCode(ctx context.Context, code string) (*models.BadgeDefinition, error) {
+	var definition models.BadgeDefinition
+	query := `SELECT ` + badgeDefinitionColumns + ` FROM badge_definitions WHERE code = $1`
+	err := r.DB.GetContext(ctx, &definition, query, code)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrBadgeDefinitionNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch badge definition %s: %w", code, err)
+	}
+	return &definition, nil
+}
+
+func (r *PosHRRepository) CreateBadgeDefinition(ctx context.Context, definition *models.BadgeDefinition) (int, error) {
+	query := `
+        INSERT INTO badge_definitions (code, name_en, name_ar, description_en, description_ar, icon, tier, category,
+                                       rule, available_from, available_until, validity_days, revoke_grace_days,
+                                       is_active, sort_order, created_at, updated_at)
+        VALUES (:code, :name_en, :name_ar, :description_en, :description_ar, :icon, :tier, :category,
+                :rule, :available_from, :available_until, :validity_days, :revoke_grace_days,
+                :is_active, :sort_order, NOW(), NOW())
+        RETURNING id
+    `
+	stmt, err := r.DB.PrepareNamedContext(ctx, query)
+	if err != nil {
+		return 0, fmt.Errorf("failed to prepare badge definition insert: %w", err)
+	}
+	defer stmt.Close()
+
+	err = stmt.GetContext(ctx, &definition.ID, definition)
+	if isUniqueViolation(err, "ux_badge_definitions_code") {
+		return 0, ErrBadgeDefinitionExists
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to insert badge definition: %w", err)
+	}
+	return definition.ID, nil
+}
+
+func (r *PosHRRepository) UpdateBadgeDefinition(ctx context.Context, definition *models.BadgeDefinition) error {
+	query := `
+        UPDATE badge_definitions
+        SET code = :code, name_en = :name_en, name_ar = :name_ar,
+            description_en = :description_en, description_ar = :description_ar, icon = :icon,
+            tier = :tier, category = :category, rule = :rule,
+            available_from = :available_from, available_until = :available_until,
+            validity_days = :validity_days, revoke_grace_days = :revoke_grace_days,
+            is_active = :is_active, sort_order = :sort_order, updated_at = NOW()
+        WHERE id = :id
+    `
+	res, err := r.DB.NamedExecContext(ctx, query, definition)
+	if isUniqueViolation(err, "ux_badge_definitions_code") {
+		return ErrBadgeDefinitionExists
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update badge definition %d: %w", definition.ID, err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrBadgeDefinitionNotFound
+	}
+	return nil
+}
+
+// DeleteBadgeDefinition removes a definition that was never awarded; awarded
+// ones keep their history and can only be deactivated.
+func (r *PosHRRepository) DeleteBadgeDefinition(ctx context.Context, definitionID int) error {
+	res, err := r.DB.ExecContext(ctx, `DELETE FROM badge_definitions WHERE id = $1`, definitionID)
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23503" { // foreign_key_violation
+		return ErrBadgeDefinitionInUse
+	}
+	if err != nil {
+		return fmt.Errorf("failed to delete badge definition %d: %w", definitionID, err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrBadgeDefinitionNotFound
+	}
+	return nil
+}
@@ -0,0 +1,149 @@
+package repos
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"githup.ahmedramadan.4cashier/internal/bootstrap"
+	"githup.ahmedramadan.4cashier/internal/models"
+)
+
+var ErrBadgeJobNotFound = errors.New("badge evaluation job not found")
+
+const badgeJobColumns = `id, hr_profile_id, trigger, status, profiles_total, profiles_processed, badges_awarded, badges_revoked,
+               last_error, started_at, finished_at, created_at, updated_at`
+
+// =================================================================
+// ⏳ Badge evaluation queue (طابور تقييم الشارات في الخلفية)
+// =================================================================
+
+// EnqueueBadgeEvaluation queues an evaluation of one profile, or of every
+// profile when profileID is nil. Events for a profile that already has a
+// pending job are merged into it; the id of the pending job is returned.
+func (r *PosHRRepository) EnqueueBadgeEvaluation(ctx context.Context, profileID *int, trigger string) (int64, error) {
+	insert := `
+        INSERT INTO badge_evaluation_jobs (hr_profile_id, trigger, status, created_at, updated_at)
+        VALUES ($1, $2, 'pending', NOW(), NOW())
+        ON CONFLICT ((COALESCE(hr_profile_id, 0))) WHERE status = 'pending' DO NOTHING
+        RETURNING id
+    `
+	pending := `SELECT id FROM badge_evaluation_jobs WHERE COALESCE(hr_profile_id, 0) = COALESCE($1, 0) AND status = 'pending'`
+
+	// the pending job can be claimed between the two statements: try again
+	for attempt := 0; attempt < 3; attempt++ {
+		var jobID int64
+		err := r.DB.GetContext(ctx, &jobID, insert, profileID, trigger)
+		if errors.Is(err, sql.ErrNoRows) {
+			err = r.DB.GetContext(ctx, &jobID, pending, profileID)
+		}
+		if errors.Is(err, sql.ErrNoRows) {
+			continue
+		}
+		if err != nil {
+			return 0, fmt.Errorf("failed to enqueue badge evaluation: %w", err)
+		}
+		return jobID, nil
+	}
+	return 0, fmt.Errorf("failed to enqueue badge evaluation: queue is busy")
+}
+
+// ClaimBadgeEvaluationJob marks the oldest pending job as running and returns
+// it, or nil when the queue is empty. SKIP LOCKED lets several workers share
+// the queue.
+func (r *PosHRRepository) ClaimBadgeEvaluationJob(ctx context.Context) (*models.BadgeEvaluationJob, error) {
+	var job models.BadgeEvaluationJob
+	query := `
+        UPDATE badge_evaluation_jobs
+        SET status = 'running', started_at = NOW(), updated_at = NOW()
+        WHERE id = (
+            SELECT id FROM badge_evaluation_jobs
+            WHERE status = 'pending'
+            ORDER BY id
+            LIMIT 1
+            FOR UPDATE SKIP LOCKED
+        )
+        RETURNING ` + badgeJobColumns
+	err := r.DB.GetContext(ctx, &job, query)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim badge evaluation job: %w", err)
+	}
+	return &job, nil
+}
+
+func (r *PosHRRepository) UpdateBadgeEvaluationProgress(ctx context.Context, jobID int64, total, processed, awarded, revoked int) error {
+	query := `
+        UPDATE badge_evaluation_jobs
+        SET profiles_total = $2, profiles_processed = $3, badges_awarded = $4, badges_revoked = $5, updated_at = NOW()
+        WHERE id = $1
+    `
+	if _, err := r.DB.ExecContext(ctx, query, jobID, total, processed, awarded, revoked); err != nil {
+		return fmt.Errorf("failed to update badge evaluation job %d: %w", jobID, err)
+	}
+	return nil
+}
+
+// FinishBadgeEvaluationJob marks a job done, or failed when jobErr is set.
+func (r *PosHRRepository) FinishBadgeEvaluationJob(ctx context.Context, jobID int64, jobErr *string) error {
+	query := `
+        UPDATE badge_evaluation_jobs
+        SET status = CASE WHEN $2::text IS NULL THEN 'done' ELSE 'failed' END,
+            last_error = $2, finished_at = NOW(), updated_at = NOW()
+        WHERE id = $1
+    `
+	if _, err := r.DB.ExecContext(ctx, query, jobID, jobErr); err != nil {
+		return fmt.Errorf("failed to finish badge evaluation job %d: %w", jobID, err)
+	}
+	return nil
+}
+
+// FailStaleBadgeJobs fails running jobs that stopped reporting progress, e.g.
+// because the process running them was restarted. The next scheduled full run
+// covers their profiles.
+func (r *PosHRRepository) FailStaleBadgeJobs(ctx context.Context, staleMinutes int) (int, error) {
+	query := `
+        UPDATE badge_evaluation_jobs
+        SET status = 'failed', last_error = 'interrupted', finished_at = NOW(), updated_at = NOW()
+        WHERE status = 'running' AND updated_at < NOW() - make_interval(mins => $1)
+    `
+	res, err := r.DB.ExecContext(ctx, query, staleMinutes)
+	if err != nil {
+		return 0, fmt.Errorf("failed to fail stale badge evaluation jobs: %w", err)
+	}
+	n, _ := res.RowsAffected()
+	return int(n), nil
+}
+
+func (r *PosHRRepository) GetBadgeEvaluationJob(ctx context.Context, jobID int64) (*models.BadgeEvaluationJob, error) {
+	var job models.BadgeEvaluationJob
+	query := `SELECT ` + badgeJobColumns + ` FROM badge_evaluation_jobs WHERE id = $1`
+	err := r.DB.GetContext(ctx, &job, query, jobID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrBadgeJobNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch badge evaluation job %d: %w", jobID, err)
+	}
+	return &job, nil
+}
+
+// GetBadgeEvaluationJobs lists jobs newest first; status "" returns all.
+func (r *PosHRRepository) GetBadgeEvaluationJobs(ctx context.Context, status string, pagination bootstrap.Pagination) ([]models.BadgeEvaluationJob, error) {
+	jobs := []models.BadgeEvaluationJob{}
+	offset := (pagination.Page - 1) * pagination.Limit
+	query := `
+        SELECT ` + badgeJobColumns + `
+        FROM badge_evaluation_jobs
+        WHERE ($1 = '' OR status = $1)
+        ORDER BY id DESC
+        LIMIT $2 OFFSET $3
+    `
+	if err := r.DB.SelectContext(ctx, &jobs, query, status, pagination.Limit, offset); err != nil {
+		return nil, fmt.Errorf("failed to fetch badge evaluation jobs: %w", err)
+	}
+	return jobs, nil
+}
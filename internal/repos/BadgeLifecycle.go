@@ -0,0 +1,169 @@
+package repos
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"githup.ahmedramadan.4cashier/internal/bootstrap"
+	"githup.ahmedramadan.4cashier/internal/models"
+)
+
+var ErrBadgeNotFound = errors.New("badge not found or no longer active")
+
+// badgeColumns selects a badge aliased b.
+const badgeColumns = `b.id, b.hr_profile_id, b.created_date, b.total_rates_number, b.rate, b.definition_id, b.award_note, b.hidden_at,
+               b.expires_at, b.revoked_at, b.revoked_by, b.revoke_kind, b.revoke_reason, b.criteria_failing_since,
+               b.likes_count, b.dislikes_count, b.recipient_salt, b.created_at, b.updated_at`
+
+// activeBadges is the predicate for badges that are neither revoked nor
+// expired. Expired badges get revoked_at when the expiry sweep runs; until then
+// expires_at hides them.
+func activeBadges(alias string) string {
+	if alias != "" {
+		alias += "."
+	}
+	return fmt.Sprintf("%[1]srevoked_at IS NULL AND (%[1]sexpires_at IS NULL OR %[1]sexpires_at > NOW())", alias)
+}
+
+// =================================================================
+// ⌛ Badge lifecycle (انتهاء الصلاحية والسحب)
+// =================================================================
+
+func (r *PosHRRepository) GetBadge(ctx context.Context, badgeID int) (*models.Badge, error) {
+	var badge models.Badge
+	err := r.DB.GetContext(ctx, &badge, `SELECT `+badgeColumns+` FROM badges b WHERE b.id = $1`, badgeID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrBadgeNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch badge %d: %w", badgeID, err)
+	}
+	return &badge, nil
+}
+
+// badgeVote is the vote of employeeID on the badge aliased b (NULL for guests).
+func badgeVote(employeeArg string) string {
+	return `(SELECT is_like FROM badge_likes WHERE badge_id = b.id AND employee_id = ` + employeeArg + `) AS my_vote`
+}
+
+// GetProfileBadges returns every badge of a profile, current and past, newest
+// first, with the vote of viewerEmployeeID (0 for none). Badges hidden by
+// moderation are left out.
+func (r *PosHRRepository) GetProfileBadges(ctx context.Context, profileID int, viewerEmployeeID int) ([]models.Badge, error) {
+	badges := []models.Badge{}
+	query := `
+        SELECT ` + badgeColumns + `, ` + badgeVote("$2") + `
+        FROM badges b
+        WHERE b.hr_profile_id = $1 AND b.hidden_at IS NULL
+        ORDER BY b.created_at DESC, b.id DESC
+    `
+	if err := r.DB.SelectContext(ctx, &badges, query, profileID, viewerEmployeeID); err != nil {
+		return nil, fmt.Errorf("failed to fetch badges of HR profile %d: %w", profileID, err)
+	}
+	return badges, nil
+}
+
+// GetVisibleBadge returns a badge not hidden by moderation, active or not,
+// with the vote of viewerEmployeeID.
+func (r *PosHRRepository) GetVisibleBadge(ctx context.Context, badgeID int, viewerEmployeeID int) (*models.Badge, error) {
+	var badge models.Badge
+	query := `
+        SELECT ` + badgeColumns + `, ` + badgeVote("$2") + `, p.name AS hr_profile_name
+        FROM badges b
+        JOIN hr_profiles p ON p.id = b.hr_profile_id
+        WHERE b.id = $1 AND b.hidden_at IS NULL
+    `
+	err := r.DB.GetContext(ctx, &badge, query, badgeID, viewerEmployeeID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrBadgeNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch badge %d: %w", badgeID, err)
+	}
+	return &badge, nil
+}
+
+// GetRecentBadges is the feed of the latest active badges across all profiles.
+func (r *PosHRRepository) GetRecentBadges(ctx context.Context, pagination bootstrap.Pagination, viewerEmployeeID int) ([]models.Badge, error) {
+	badges := []models.Badge{}
+	offset := (pagination.Page - 1) * pagination.Limit
+	query := `
+        SELECT ` + badgeColumns + `, ` + badgeVote("$1") + `, p.name AS hr_profile_name
+        FROM badges b
+        JOIN hr_profiles p ON p.id = b.hr_profile_id
+        WHERE b.hidden_at IS NULL AND ` + activeBadges("b") + `
+        ORDER BY b.created_at DESC, b.id DESC
+        LIMIT $2 OFFSET $3
+    `
+	if err := r.DB.SelectContext(ctx, &badges, query, viewerEmployeeID, pagination.Limit, offset); err != nil {
+		return nil, fmt.Errorf("failed to fetch recent badges: %w", err)
+	}
+	return badges, nil
+}
+
+// ExpireBadges moves badges past their expiry date to the history and returns them.
+func (r *PosHRRepository) ExpireBadges(ctx context.Context) ([]models.Badge, error) {
+	badges := []models.Badge{}
+	query := `
+        UPDATE badges b
+        SET revoked_at = expires_at, revoke_kind = 'expired', criteria_failing_since = NULL, updated_at = NOW()
+        WHERE revoked_at IS NULL AND expires_at <= NOW()
+        RETURNING ` + badgeColumns
+	if err := r.DB.SelectContext(ctx, &badges, query); err != nil {
+		return nil, fmt.Errorf("failed to expire badges: %w", err)
+	}
+	return badges, nil
+}
+
+// SetBadgeCriteriaFailing starts (keeping an earlier start) or clears the grace
+// period of an active badge whose rule no longer holds.
+func (r *PosHRRepository) SetBadgeCriteriaFailing(ctx context.Context, badgeID int, failing bool) error {
+	query := `
+        UPDATE badges
+        SET criteria_failing_since = CASE WHEN $2 THEN COALESCE(criteria_failing_since, NOW()) END, updated_at = NOW()
+        WHERE id = $1 AND revoked_at IS NULL
+    `
+	if _, err := r.DB.ExecContext(ctx, query, badgeID, failing); err != nil {
+		return fmt.Errorf("failed to update grace period of badge %d: %w", badgeID, err)
+	}
+	return nil
+}
+
+// RevokeBadge moves an active badge to the history. revokedBy is nil for
+// automatic revocations.
+func (r *PosHRRepository) RevokeBadge(ctx context.Context, badgeID int, kind string, reason *string, revokedBy *int) (*models.Badge, error) {
+	var badge models.Badge
+	query := `
+        UPDATE badges b
+        SET revoked_at = NOW(), revoke_kind = $2, revoke_reason = $3, revoked_by = $4,
+            criteria_failing_since = NULL, updated_at = NOW()
+        WHERE id = $1 AND revoked_at IS NULL
+        RETURNING ` + badgeColumns
+	err := r.DB.GetContext(ctx, &badge, query, badgeID, kind, reason, revokedBy)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrBadgeNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to revoke badge %d: %w", badgeID, err)
+	}
+	return &badge, nil
+}
+
+// GetRevokedBadges returns the badges taken back from their holder: revoked by
+// an admin or by the rules, or hidden by moderation. Expired badges are not in
+// it, their assertion carries the expiry date.
+func (r *PosHRRepository) GetRevokedBadges(ctx context.Context) ([]models.Badge, error) {
+	badges := []models.Badge{}
+	query := `
+        SELECT ` + badgeColumns + `
+        FROM badges b
+        WHERE b.revoke_kind IN ('manual', 'criteria') OR b.hidden_at IS NOT NULL
+        ORDER BY b.id
+    `
+	if err := r.DB.SelectContext(ctx, &badges, query); err != nil {
+		return nil, fmt.Errorf("failed to fetch revoked badges: %w", err)
+	}
+	return badges, nil
+}
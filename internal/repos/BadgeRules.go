@@ -0,0 +1,107 @@
+package repos
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"githup.ahmedramadan.4cashier/internal/badgerules"
+	"githup.ahmedramadan.4cashier/internal/models"
+)
+
+// =================================================================
+// 🏅 Badge rule metrics (قيم المقاييس التي تقرأها قواعد الشارات)
+// =================================================================
+
+// badgeMetricSQL is the per-profile SQL of a rule metric (profiles aliased p).
+// Rate metrics read countedRates, like hr_profiles.rate.
+func badgeMetricSQL(metric badgerules.Metric) (string, error) {
+	counted := `FROM rates r WHERE r.hr_profile_id = p.id AND ` + countedRates("r")
+	window := fmt.Sprintf(" AND r.created_at >= NOW() - make_interval(days => %d)", metric.WindowDays)
+
+	switch metric.Name {
+	case "total_rates_count":
+		return "p.total_rates_count", nil
+	case "rate":
+		return "COALESCE(p.rate, 0)", nil
+	case "weighted_rate":
+		return "COALESCE(p.weighted_rate, 0)", nil
+	case "verified":
+		return "CASE WHEN p.verified_profile THEN 1 ELSE 0 END", nil
+	case "verified_rates_count":
+		return "(SELECT COUNT(*) " + counted + " AND r.is_verified)", nil
+	case "response_rate":
+		return "COALESCE((SELECT AVG(CASE WHEN r.hr_response_status = 'published' THEN 1 ELSE 0 END) " + counted + "), 0)", nil
+	case "rates_count":
+		return "(SELECT COUNT(*) " + counted + window + ")", nil
+	case "avg_rate":
+		return "COALESCE((SELECT AVG(r.rate_value) " + counted + window + "), 0)", nil
+	}
+	return "", fmt.Errorf("no SQL for badge metric %s", metric.Name)
+}
+
+// GetBadgeMetrics computes the given metrics for one profile, or for every
+// profile when profileID is 0.
+func (r *PosHRRepository) GetBadgeMetrics(ctx context.Context, metrics []badgerules.Metric, profileID int) ([]models.BadgeMetrics, error) {
+	columns := []string{"p.id", "p.name"}
+	for _, metric := range metrics {
+		column, err := badgeMetricSQL(metric)
+		if err != nil {
+			return nil, err
+		}
+		columns = append(columns, column)
+	}
+
+	query := `
+        SELECT ` + strings.Join(columns, ",\n               ") + `
+        FROM hr_profiles p
+        WHERE ($1 = 0 OR p.id = $1)
+        ORDER BY p.id
+    `
+	rows, err := r.DB.QueryContext(ctx, query, profileID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute badge metrics: %w", err)
+	}
+	defer rows.Close()
+
+	result := []models.BadgeMetrics{}
+	for rows.Next() {
+		row := models.BadgeMetrics{Values: make(map[string]float64, len(metrics))}
+		values := make([]sql.NullFloat64, len(metrics))
+		dest := []interface{}{&row.HRProfileID, &row.Name}
+		for i := range values {
+			dest = append(dest, &values[i])
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return nil, fmt.Errorf("failed to scan badge metrics: %w", err)
+		}
+		for i, metric := range metrics {
+			row.Values[metric.Key()] = values[i].Float64
+		}
+		result = append(result, row)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read badge metrics: %w", err)
+	}
+	return result, nil
+}
+
+// GetBadgeHolders returns the active badges of a definition keyed by profile
+// id, for one profile or every profile when profileID is 0.
+func (r *PosHRRepository) GetBadgeHolders(ctx context.Context, definitionID int, profileID int) (map[int]models.Badge, error) {
+	var badges []models.Badge
+	query := `
+        SELECT ` + badgeColumns + `
+        FROM badges b
+        WHERE b.definition_id = $1 AND b.revoked_at IS NULL AND ($2 = 0 OR b.hr_profile_id = $2)
+    `
+	if err := r.DB.SelectContext(ctx, &badges, query, definitionID, profileID); err != nil {
+		return nil, fmt.Errorf("failed to fetch holders of badge definition %d: %w", definitionID, err)
+	}
+	holders := make(map[int]models.Badge, len(badges))
+	for _, badge := range badges {
+		holders[badge.HRProfileID] = badge
+	}
+	return holders, nil
+}
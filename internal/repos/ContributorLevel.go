@@ -0,0 +1,119 @@
+package repos
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"githup.ahmedramadan.4cashier/internal/models"
+)
+
+// =================================================================
+// 🎖️ Contributor levels (مستويات المساهمين)
+// =================================================================
+
+// contributorMetricsSQL gathers the level inputs of one employee ($1) or of
+// every employee ($1 = 0). Points for casting votes are left out: anyone can
+// earn them by liking rates, so only points earned from others' engagement
+// and from achievements move a level.
+func contributorMetricsSQL() string {
+	return `
+        SELECT e.id AS employee_id, e.contributor_level, COALESCE(pl.points, 0) AS points,
+               COALESCE(s.reviews, 0) AS reviews,
+               COALESCE(s.rejected, 0) AS rejected_reviews,
+               COALESCE(s.helpful, 0) AS helpful_votes,
+               COALESCE(s.unhelpful, 0) AS unhelpful_votes,
+               (COALESCE(e.is_verified, false) OR COALESCE(s.verified, false)) AS verified
+        FROM employees e
+        LEFT JOIN (
+            SELECT r.employee_id,
+                   COUNT(*) FILTER (WHERE ` + publishedRates("r") + `) AS reviews,
+                   COUNT(*) FILTER (WHERE r.status = 'rejected') AS rejected,
+                   SUM(COALESCE(r.likes_count, 0)) FILTER (WHERE ` + publishedRates("r") + `) AS helpful,
+                   SUM(r.dislikes_count) FILTER (WHERE ` + publishedRates("r") + `) AS unhelpful,
+                   BOOL_OR(COALESCE(r.is_verified, false)) FILTER (WHERE ` + publishedRates("r") + `) AS verified
+            FROM rates r
+            WHERE r.deleted_at IS NULL AND ($1 = 0 OR r.employee_id = $1)
+            GROUP BY r.employee_id
+        ) s ON s.employee_id = e.id
+        LEFT JOIN (
+            SELECT l.employee_id, SUM(l.delta) AS points
+            FROM points_ledger l
+            WHERE l.reason <> '` + models.PointsReasonRateVote + `' AND ($1 = 0 OR l.employee_id = $1)
+            GROUP BY l.employee_id
+        ) pl ON pl.employee_id = e.id
+        WHERE ($1 = 0 OR e.id = $1)
+        ORDER BY e.id
+    `
+}
+
+func (r *PosHRRepository) GetContributorMetrics(ctx context.Context, employeeID int) (*models.ContributorMetrics, error) {
+	var metrics models.ContributorMetrics
+	err := r.DB.GetContext(ctx, &metrics, contributorMetricsSQL(), employeeID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrEmployeeNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch contributor metrics of employee %d: %w", employeeID, err)
+	}
+	return &metrics, nil
+}
+
+func (r *PosHRRepository) GetAllContributorMetrics(ctx context.Context) ([]models.ContributorMetrics, error) {
+	metrics := []models.ContributorMetrics{}
+	if err := r.DB.SelectContext(ctx, &metrics, contributorMetricsSQL(), 0); err != nil {
+		return nil, fmt.Errorf("failed to fetch contributor metrics: %w", err)
+	}
+	return metrics, nil
+}
+
+func (r *PosHRRepository) GetContributorLevel(ctx context.Context, employeeID int) (string, error) {
+	var level string
+	err := r.DB.GetContext(ctx, &level, `SELECT contributor_level FROM employees WHERE id = $1`, employeeID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", ErrEmployeeNotFound
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch contributor level of employee %d: %w", employeeID, err)
+	}
+	return level, nil
+}
+
+// SetContributorLevel stores a new level and, since the level weighs the
+// employee's rates, recomputes the weighted rate of every HR they rated. It
+// returns those profiles and whether the level changed.
+func (r *PosHRRepository) SetContributorLevel(ctx context.Context, employeeID int, level string) ([]int, bool, error) {
+	tx, err := r.DB.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, `
+        UPDATE employees SET contributor_level = $2, level_updated_at = NOW(), updated_at = NOW()
+        WHERE id = $1 AND contributor_level <> $2
+    `, employeeID, level)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to set contributor level of employee %d: %w", employeeID, err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return nil, false, nil
+	}
+
+	profileIDs := []int{}
+	query := `SELECT DISTINCT hr_profile_id FROM rates r WHERE r.employee_id = $1 AND ` + countedRates("r") + ` ORDER BY hr_profile_id`
+	if err := tx.SelectContext(ctx, &profileIDs, query, employeeID); err != nil {
+		return nil, false, fmt.Errorf("failed to list profiles rated by employee %d: %w", employeeID, err)
+	}
+	for _, profileID := range profileIDs {
+		if err := r.recalcWeightedRate(ctx, tx, profileID); err != nil {
+			return nil, false, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, false, fmt.Errorf("failed to commit contributor level of employee %d: %w", employeeID, err)
+	}
+	return profileIDs, true, nil
+}
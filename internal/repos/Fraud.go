@@ -0,0 +1,208 @@
+package repos
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"githup.ahmedramadan.4cashier/internal/bootstrap"
+	"githup.ahmedramadan.4cashier/internal/models"
+)
+
+// =================================================================
+// ⭐️ Fraud scoring (rating rings, bursts, shared devices)
+// =================================================================
+
+// fraudInputsSQL measures the signals around one rate: how old the author's
+// account was, how many similar rates hit the same HR at the same time, and
+// whether the author shares an IP, a device or a like ring with other authors.
+// source yields the rate as one row (id, employee_id, hr_profile_id,
+// rate_value, client_ip, device_fingerprint, created_at), stored or not yet.
+func fraudInputsSQL(source string) string {
+	return `
+        SELECT
+            r.id AS rate_id,
+            GREATEST(EXTRACT(EPOCH FROM (r.created_at - e.created_at)) / 3600.0, 0) AS account_age_hours,
+            (SELECT COUNT(*) FROM rates o
+              WHERE o.hr_profile_id = r.hr_profile_id AND o.id <> r.id AND ` + liveRates("o") + `
+                AND ABS(EXTRACT(EPOCH FROM (o.created_at - r.created_at))) <= 86400
+                AND ABS(o.rate_value - r.rate_value) <= 0.5) AS target_burst,
+            (SELECT COUNT(*) FROM rates o
+              WHERE o.employee_id = r.employee_id AND o.id <> r.id
+                AND ABS(EXTRACT(EPOCH FROM (o.created_at - r.created_at))) <= 86400) AS author_velocity,
+            (SELECT COUNT(DISTINCT o.employee_id) FROM rates o
+              WHERE o.hr_profile_id = r.hr_profile_id AND o.employee_id <> r.employee_id
+                AND r.client_ip IS NOT NULL AND o.client_ip = r.client_ip) AS shared_ip_accounts,
+            (SELECT COUNT(DISTINCT o.employee_id) FROM rates o
+              WHERE o.hr_profile_id = r.hr_profile_id AND o.employee_id <> r.employee_id
+                AND r.device_fingerprint IS NOT NULL AND o.device_fingerprint = r.device_fingerprint) AS shared_device_accounts,
+            (SELECT COUNT(DISTINCT given.author) FROM (
+                SELECT lr.employee_id AS author
+                FROM rate_likes l JOIN rates lr ON lr.id = l.rate_id
+                WHERE l.employee_id = r.employee_id AND l.is_like AND lr.employee_id <> r.employee_id
+             ) given
+             JOIN (
+                SELECT l.employee_id AS liker
+                FROM rate_likes l JOIN rates mr ON mr.id = l.rate_id
+                WHERE mr.employee_id = r.employee_id AND l.is_like
+             ) received ON received.liker = given.author) AS reciprocal_likes
+        FROM (` + source + `) r
+        JOIN employees e ON e.id = r.employee_id
+    `
+}
+
+func (r *PosHRRepository) GetFraudInputs(ctx context.Context, rateID int) (*models.FraudInputs, error) {
+	var inputs models.FraudInputs
+	query := fraudInputsSQL(`
+        SELECT id, employee_id, hr_profile_id, rate_value, client_ip, device_fingerprint, created_at
+        FROM rates WHERE id = $1`)
+	err := r.DB.GetContext(ctx, &inputs, query, rateID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrRateNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to measure fraud signals of rate %d: %w", rateID, err)
+	}
+	return &inputs, nil
+}
+
+// GetNewRateFraudInputs measures the signals of a rate before it is saved, so
+// a risky rate is flagged by the insert and never counts in the averages.
+func (r *PosHRRepository) GetNewRateFraudInputs(ctx context.Context, rate *models.Rate) (*models.FraudInputs, error) {
+	var inputs models.FraudInputs
+	query := fraudInputsSQL(`
+        SELECT 0 AS id, $1::int AS employee_id, $2::int AS hr_profile_id, $3::real AS rate_value,
+               CAST($4 AS INET) AS client_ip, $5::text AS device_fingerprint, NOW() AS created_at`)
+	err := r.DB.GetContext(ctx, &inputs, query, rate.EmployeeID, rate.HRProfileID, rate.RateValue, rate.ClientIP, rate.DeviceFingerprint)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrEmployeeNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to measure fraud signals of a new rate by employee %d: %w", rate.EmployeeID, err)
+	}
+	return &inputs, nil
+}
+
+// GetSimilarityCorpus returns the most recent review texts written by other
+// authors about the same HR, to look for copy-pasted reviews.
+func (r *PosHRRepository) GetSimilarityCorpus(ctx context.Context, hrProfileID int, employeeID int, limit int) ([]string, error) {
+	texts := []string{}
+	query := `
+        SELECT o.review_text
+        FROM rates o
+        WHERE o.hr_profile_id = $1 AND o.employee_id <> $2 AND ` + liveRates("o") + `
+        ORDER BY o.created_at DESC
+        LIMIT $3
+    `
+	if err := r.DB.SelectContext(ctx, &texts, query, hrProfileID, employeeID, limit); err != nil {
+		return nil, fmt.Errorf("failed to fetch similarity corpus of profile %d: %w", hrProfileID, err)
+	}
+	return texts, nil
+}
+
+// SetRateRisk stores the fraud score of a rate. A rate a moderator already
+// cleared is never flagged again. The profile is recomputed when the flag
+// changes, since flagged rates do not count in averages.
+func (r *PosHRRepository) SetRateRisk(ctx context.Context, rateID int, score int, signals models.JSONB, flagged bool) error {
+	tx, err := r.DB.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var hrProfileID int
+	var wasFlagged, isFlagged bool
+	query := `
+        UPDATE rates n
+        SET risk_score = $2,
+            risk_signals = $3,
+            risk_flagged = $4 AND n.risk_reviewed_at IS NULL
+        FROM rates o
+        WHERE n.id = $1 AND o.id = n.id
+        RETURNING n.hr_profile_id, o.risk_flagged, n.risk_flagged
+    `
+	err = tx.QueryRowxContext(ctx, query, rateID, score, signals, flagged).Scan(&hrProfileID, &wasFlagged, &isFlagged)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrRateNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to store risk of rate %d: %w", rateID, err)
+	}
+
+	if wasFlagged != isFlagged {
+		if _, err := r.recalcProfileRate(ctx, tx, hrProfileID); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// GetRecentRateIDs lists live rates created in the last sinceDays days.
+func (r *PosHRRepository) GetRecentRateIDs(ctx context.Context, sinceDays int) ([]int, error) {
+	ids := []int{}
+	query := `
+        SELECT id FROM rates
+        WHERE ` + liveRates("") + ` AND created_at >= NOW() - make_interval(days => $1)
+        ORDER BY id
+    `
+	if err := r.DB.SelectContext(ctx, &ids, query, sinceDays); err != nil {
+		return nil, fmt.Errorf("failed to list recent rates: %w", err)
+	}
+	return ids, nil
+}
+
+// GetRiskyRates lists flagged rates waiting for a moderator, riskiest first.
+func (r *PosHRRepository) GetRiskyRates(ctx context.Context, pagination bootstrap.Pagination) ([]models.Rate, error) {
+	rates := []models.Rate{}
+	query := `
+        SELECT ` + moderationRateColumns + `
+        FROM rates
+        WHERE risk_flagged AND ` + liveRates("") + `
+        ORDER BY risk_score DESC, created_at
+        LIMIT $1 OFFSET $2
+    `
+	offset := (pagination.Page - 1) * pagination.Limit
+	if err := r.DB.SelectContext(ctx, &rates, query, pagination.Limit, offset); err != nil {
+		return nil, fmt.Errorf("failed to fetch risky rates: %w", err)
+	}
+	return rates, nil
+}
+
+// ReviewRateRisk marks a flagged rate as reviewed so it counts in averages
+// again, and recomputes the profile.
+func (r *PosHRRepository) ReviewRateRisk(ctx context.Context, rateID int, moderatorID int) (*models.Rate, error) {
+	tx, err := r.DB.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var rate models.Rate
+	query := `
+        UPDATE rates
+        SET risk_flagged = FALSE, risk_reviewed_by = $2, risk_reviewed_at = NOW()
+        WHERE id = $1 AND ` + liveRates("") + `
+        RETURNING ` + moderationRateColumns + `
+    `
+	err = tx.GetContext(ctx, &rate, query, rateID, moderatorID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrRateNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to clear risk of rate %d: %w", rateID, err)
+	}
+
+	if _, err := r.recalcProfileRate(ctx, tx, rate.HRProfileID); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return &rate, nil
+}
@@ -2,14 +2,29 @@ package repos
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"githup.ahmedramadan.4cashier/internal/badgerules"
 	"githup.ahmedramadan.4cashier/internal/bootstrap"
 	"githup.ahmedramadan.4cashier/internal/models"
 )
 
+var (
+	ErrRateNotFound  = errors.New("rate not found")
+	ErrRateForbidden = errors.New("rate belongs to another employee")
+	ErrRateExists    = errors.New("employee already has an active rate for this HR profile")
+	ErrNotRateOwner  = errors.New("rate is not about this HR profile")
+	ErrVoteNotFound  = errors.New("you have not voted on this rate")
+	ErrSelfVote      = errors.New("you cannot vote on your own rate")
+	ErrBadgeAlreadyAwarded = errors.New("the HR profile already holds this badge")
+)
+
 type HRRepository interface {
 	// CRUD/Update Functions
 	AddExperience(ctx context.Context, hrID int, exp []models.Experience) error
@@ -18,8 +33,13 @@ type HRRepository interface {
 	
 	// Core Business Logic Handlers (Atomic Transactions)
 	RateHR(ctx context.Context, rate *models.Rate) (int, float32, error)
-	LikeRate(ctx context.Context, like *models.RateLike) (int, error) 
-	LikeBadge(ctx context.Context, like *models.BadgeLike) error
+	ReplaceRate(ctx context.Context, previousRateID int, rate *models.Rate) (int, float32, error)
+	LikeRate(ctx context.Context, like *models.RateLike, points models.RateVotePoints) (*models.RateVoteResult, error)
+	RemoveRateVote(ctx context.Context, rateID int, employeeID int, points models.RateVotePoints) (*models.RateVoteResult, error)
+	LikeBadge(ctx context.Context, like *models.BadgeLike) (*models.BadgeVoteResult, error)
+	RemoveBadgeVote(ctx context.Context, badgeID int, employeeID int) (*models.BadgeVoteResult, error)
+	UpdateRate(ctx context.Context, rate *models.Rate) (float32, error)
+	DeleteRate(ctx context.Context, rateID int, employeeID int) (int, error)
     
 	// Retrieval Functions
 	GetHRProfiles(ctx context.Context, pagination bootstrap.Pagination, filters map[string]interface{}) ([]models.HRProfile, error)
@@ -27,19 +47,115 @@ type HRRepository interface {
     
     // Helper Functions for Service Logic
 	GetHRProfileByID(ctx context.Context, hrID int) (*models.HRProfile, error)
-	CheckIfProfileHasBadge(ctx context.Context, profileID int, badgeName string) (bool, error)
+	CheckIfProfileHasBadge(ctx context.Context, profileID int, definitionID int) (bool, error)
 	GetRateOwner(ctx context.Context, rateID int) (int, error)
-	UpdateEmployeePoints(ctx context.Context, employeeID int, pointsToAdd int) error
-
-	GetEmployeeStats(ctx context.Context, employeeID int) (models.EmployeeStats, error)
+	GetRate(ctx context.Context, rateID int) (*models.Rate, error)
+	GetActiveRate(ctx context.Context, hrProfileID int, employeeID int) (*models.Rate, error)
+	GetLastRateTime(ctx context.Context, hrProfileID int, employeeID int) (*time.Time, error)
+	SetHRResponse(ctx context.Context, rateID int, response *string, status *string, reasons models.JSONB) (bool, error)
+	GetRateRevisions(ctx context.Context, rateID int) ([]models.RateRevision, error)
+
+	GetEmployeeStats(ctx context.Context, employeeID int, includeAnonymous bool) (models.EmployeeStats, error)
+
+	// Rating criteria
+	GetRatingCriteria(ctx context.Context, activeOnly bool, contextCode string) ([]models.RatingCriterion, error)
+	CreateRatingCriterion(ctx context.Context, criterion *models.RatingCriterion) (int, error)
+	UpdateRatingCriterion(ctx context.Context, criterion *models.RatingCriterion) error
+	GetCriteriaAverages(ctx context.Context, hrProfileID int) ([]models.CriterionAverage, error)
+
+	// Rating contexts
+	GetRatingContexts(ctx context.Context, activeOnly bool) ([]models.RatingContext, error)
+	CreateRatingContext(ctx context.Context, ratingContext *models.RatingContext) (int, error)
+	UpdateRatingContext(ctx context.Context, ratingContext *models.RatingContext) error
+	GetContextAverages(ctx context.Context, hrProfileID int) ([]models.ContextAverage, error)
+	GetRatingSummary(ctx context.Context, hrProfileID int, months int, windowDays int) (*models.RatingSummary, error)
+
+	// Badge definitions
+	GetBadgeDefinitions(ctx context.Context, activeOnly bool) ([]models.BadgeDefinition, error)
+	GetBadgeDefinition(ctx context.Context, definitionID int) (*models.BadgeDefinition, error)
+	GetBadgeDefinitionByCode(ctx context.Context, code string) (*models.BadgeDefinition, error)
+	CreateBadgeDefinition(ctx context.Context, definition *models.BadgeDefinition) (int, error)
+	UpdateBadgeDefinition(ctx context.Context, definition *models.BadgeDefinition) error
+	DeleteBadgeDefinition(ctx context.Context, definitionID int) error
+	GetBadgeMetrics(ctx context.Context, metrics []badgerules.Metric, profileID int) ([]models.BadgeMetrics, error)
+	GetBadgeHolders(ctx context.Context, definitionID int, profileID int) (map[int]models.Badge, error)
+	GetBadge(ctx context.Context, badgeID int) (*models.Badge, error)
+	GetProfileBadges(ctx context.Context, profileID int, viewerEmployeeID int) ([]models.Badge, error)
+	GetVisibleBadge(ctx context.Context, badgeID int, viewerEmployeeID int) (*models.Badge, error)
+	GetRecentBadges(ctx context.Context, pagination bootstrap.Pagination, viewerEmployeeID int) ([]models.Badge, error)
+	ExpireBadges(ctx context.Context) ([]models.Badge, error)
+	SetBadgeCriteriaFailing(ctx context.Context, badgeID int, failing bool) error
+	RevokeBadge(ctx context.Context, badgeID int, kind string, reason *string, revokedBy *int) (*models.Badge, error)
+	GetRevokedBadges(ctx context.Context) ([]models.Badge, error)
+
+	// Points ledger
+	AddPoints(ctx context.Context, entry *models.PointsEntry) error
+	GetPointsBalance(ctx context.Context, employeeID int) (int, error)
+	GetPointsHistory(ctx context.Context, employeeID int, pagination bootstrap.Pagination) ([]models.PointsEntry, error)
+	RebuildPointsBalances(ctx context.Context) (int, error)
+
+	// Contributor levels
+	GetContributorMetrics(ctx context.Context, employeeID int) (*models.ContributorMetrics, error)
+	GetAllContributorMetrics(ctx context.Context) ([]models.ContributorMetrics, error)
+	GetContributorLevel(ctx context.Context, employeeID int) (string, error)
+	SetContributorLevel(ctx context.Context, employeeID int, level string) ([]int, bool, error)
+
+	// Employee achievements
+	GetAchievementStats(ctx context.Context, employeeID int, helpfulLikes int) (*models.AchievementStats, error)
+	GetEmployeeAchievements(ctx context.Context, employeeID int) ([]models.EmployeeAchievement, error)
+	AwardAchievement(ctx context.Context, achievement *models.EmployeeAchievement) error
+	GetEmployeeIDs(ctx context.Context) ([]int, error)
+
+	// Leaderboards
+	GetLeaderboard(ctx context.Context, query models.LeaderboardQuery) ([]models.LeaderboardEntry, error)
+	GetContributorStandings(ctx context.Context) ([]models.ContributorStanding, error)
+	SaveLeaderboardSnapshot(ctx context.Context, query models.LeaderboardQuery, takenOn time.Time) (*models.LeaderboardSnapshot, error)
+	GetLeaderboardSnapshots(ctx context.Context, query models.LeaderboardQuery) ([]models.LeaderboardSnapshot, error)
+	GetLeaderboardSnapshotAt(ctx context.Context, query models.LeaderboardQuery, at time.Time) (*models.LeaderboardSnapshot, error)
+	GetLeaderboardSnapshotEntries(ctx context.Context, snapshotID int64, value string, limit int) ([]models.LeaderboardEntry, error)
+
+	// Badge evaluation queue
+	EnqueueBadgeEvaluation(ctx context.Context, profileID *int, trigger string) (int64, error)
+	ClaimBadgeEvaluationJob(ctx context.Context) (*models.BadgeEvaluationJob, error)
+	UpdateBadgeEvaluationProgress(ctx context.Context, jobID int64, total, processed, awarded, revoked int) error
+	FinishBadgeEvaluationJob(ctx context.Context, jobID int64, jobErr *string) error
+	FailStaleBadgeJobs(ctx context.Context, staleMinutes int) (int, error)
+	GetBadgeEvaluationJob(ctx context.Context, jobID int64) (*models.BadgeEvaluationJob, error)
+	GetBadgeEvaluationJobs(ctx context.Context, status string, pagination bootstrap.Pagination) ([]models.BadgeEvaluationJob, error)
+	SetProfileVerified(ctx context.Context, profileID int, verified bool) error
+
+	// Sentiment & aspects
+	SetRateSentiment(ctx context.Context, rateID int, score float32, label string, contradiction bool, version int, aspects []models.RateAspect) error
+	GetRatesForSentiment(ctx context.Context, version int, all bool, afterID int, limit int) ([]models.Rate, error)
+	GetAspectAverages(ctx context.Context, hrProfileID int) ([]models.AspectAverage, error)
+	GetAspectLeaders(ctx context.Context, aspect string, criticized bool, minRates int, pagination bootstrap.Pagination) ([]models.AspectLeader, error)
+
+	RecalculateAllScores(ctx context.Context) (int, error)
+
+	// Fraud scoring
+	GetFraudInputs(ctx context.Context, rateID int) (*models.FraudInputs, error)
+	GetNewRateFraudInputs(ctx context.Context, rate *models.Rate) (*models.FraudInputs, error)
+	GetSimilarityCorpus(ctx context.Context, hrProfileID int, employeeID int, limit int) ([]string, error)
+	SetRateRisk(ctx context.Context, rateID int, score int, signals models.JSONB, flagged bool) error
+	GetRecentRateIDs(ctx context.Context, sinceDays int) ([]int, error)
+	GetRiskyRates(ctx context.Context, pagination bootstrap.Pagination) ([]models.Rate, error)
+	ReviewRateRisk(ctx context.Context, rateID int, moderatorID int) (*models.Rate, error)
+
+	// Moderation queue
+	GetModerationRates(ctx context.Context, status string, pagination bootstrap.Pagination) ([]models.Rate, error)
+	DecideRate(ctx context.Context, rateID int, status string, moderatorID *int, note *string) (*models.Rate, error)
+	ResolveReportedItem(ctx context.Context, targetType string, targetID int, uphold bool, moderatorID int, note *string, credibilityDelta float32) (*models.ReportResolution, error)
+	GetHeldResponses(ctx context.Context, pagination bootstrap.Pagination) ([]models.HeldResponse, error)
+	DecideHRResponse(ctx context.Context, rateID int, status string) (*models.Rate, error)
 }
 
 type PosHRRepository struct {
-	DB *sqlx.DB
+	DB      *sqlx.DB
+	Scoring bootstrap.ScoringConfig
 }
 
-func NewPosHRRepository(db *sqlx.DB) HRRepository {
-	return &PosHRRepository{DB: db}
+func NewPosHRRepository(db *sqlx.DB, scoring bootstrap.ScoringConfig) HRRepository {
+	return &PosHRRepository{DB: db, Scoring: scoring}
 }
 
 
@@ -57,7 +173,7 @@ func (r *PosHRRepository) GetHRProfiles(
 
 	// البحث العام (searchText)
 	if searchText, ok := filters["searchText"].(string); ok && searchText != "" {
-		conditions = append(conditions, fmt.Sprintf("(name ILIKE $%d OR company_name ILIKE $%d OR email ILIKE $%d )", argPos, argPos))
+		conditions = append(conditions, fmt.Sprintf("(name ILIKE $%d OR company_name ILIKE $%d OR email ILIKE $%d )", argPos, argPos, argPos))
 		args = append(args, "%"+searchText+"%")
 		argPos++
 	}
@@ -93,29 +209,35 @@ func (r *PosHRRepository) GetHRProfiles(
 	
 
 	sortColumn := "created_at"
-sortOrder := "DESC"
-// يجب استخدام حقل مخصص للفرز مثل "sort" أو "orderBy"
-if sortFilter, ok := filters["sort"].(string); ok && sortFilter != "" { 
-    sortParts := strings.Split(sortFilter, ":")
-    if len(sortParts) >= 1 && sortParts[0] != "" {
-        sortColumn = sortParts[0] // عمود الفرز
-    }
-    if len(sortParts) == 2 {
-		sortColumn = sortParts[0]
-		if sortParts[1] == "asc" || sortParts[1] == "ASC" {
+	sortOrder := "DESC"
+	// يجب استخدام حقل مخصص للفرز مثل "sort" أو "orderBy"
+	// "rate" يرتب بالدرجة المرجحة (Bayesian) و "raw_rate" بالمتوسط الخام
+	sortColumns := map[string]string{
+		"created_at":        "created_at",
+		"name":              "name",
+		"total_rates_count": "total_rates_count",
+		"rate":              "weighted_rate",
+		"weighted_rate":     "weighted_rate",
+		"raw_rate":          "rate",
+	}
+	if sortFilter, ok := filters["sort"].(string); ok && sortFilter != "" {
+		sortParts := strings.Split(sortFilter, ":")
+		if column, ok := sortColumns[sortParts[0]]; ok {
+			sortColumn = column
+		}
+		if len(sortParts) == 2 && strings.EqualFold(sortParts[1], "asc") {
 			sortOrder = "ASC"
 		}
-    }
-}
-	
-	sortClause := fmt.Sprintf("ORDER BY %s %s", sortColumn, sortOrder)
+	}
+
+	sortClause := fmt.Sprintf("ORDER BY %s %s NULLS LAST, id", sortColumn, sortOrder)
 
 	// Pagination
 	offset := (pagination.Page - 1) * pagination.Limit
 	args = append(args, pagination.Limit, offset)
 
 	query := fmt.Sprintf(`
-		SELECT id, name, email,image, company_name, job_position, rate, total_rates_count, verified_profile,
+		SELECT id, name, email,image, company_name, job_position, rate, weighted_rate, total_rates_count, verified_profile,
 		 created_at, updated_at FROM hr_profiles
 		%s
 		%s
@@ -138,6 +260,11 @@ func (r *PosHRRepository) GetRates(
 	var rates []models.RateWithDetails
 	args := []interface{}{}
 	conditions := []string{}
+	if includeUnpublished, _ := filters["include_unpublished"].(bool); includeUnpublished {
+		conditions = append(conditions, liveRates("r"))
+	} else {
+		conditions = append(conditions, publishedRates("r"))
+	}
 	argPos := 1
 
 	if employeeID, ok := filters["employee_id"].(int); ok && employeeID > 0 {
@@ -152,19 +279,74 @@ func (r *PosHRRepository) GetRates(
 		argPos++
 	}
 
+	if ratingContext, ok := filters["rating_context"].(string); ok && ratingContext != "" {
+		conditions = append(conditions, fmt.Sprintf("r.rating_context = $%d", argPos))
+		args = append(args, ratingContext)
+		argPos++
+	}
+
+	if method, ok := filters["verification_method"].(string); ok && method != "" {
+		conditions = append(conditions, fmt.Sprintf("r.verification_method = $%d", argPos))
+		args = append(args, method)
+		argPos++
+	}
+
+	if isAnonymous, ok := filters["is_anonymous"].(bool); ok {
+		conditions = append(conditions, fmt.Sprintf("r.is_anonymous = $%d", argPos))
+		args = append(args, isAnonymous)
+		argPos++
+	}
+
 	if isVerified, ok := filters["is_verified"].(bool); ok {
 		conditions = append(conditions, fmt.Sprintf("r.is_verified = $%d", argPos))
 		args = append(args, isVerified)
 		argPos++
 	}
 
+	if label, ok := filters["sentiment"].(string); ok && label != "" {
+		conditions = append(conditions, fmt.Sprintf("r.sentiment_label = $%d", argPos))
+		args = append(args, label)
+		argPos++
+	}
+
+	// aspect, optionally narrowed to the reviews praising or criticizing it
+	if aspect, ok := filters["aspect"].(string); ok && aspect != "" {
+		scoreCondition := ""
+		switch filters["aspect_sentiment"] {
+		case "positive":
+			scoreCondition = fmt.Sprintf(" AND ra.score >= %g", aspectSentimentBand)
+		case "negative":
+			scoreCondition = fmt.Sprintf(" AND ra.score <= -%g", aspectSentimentBand)
+		}
+		conditions = append(conditions, fmt.Sprintf(
+			"EXISTS (SELECT 1 FROM rate_aspects ra WHERE ra.rate_id = r.id AND ra.aspect = $%d%s)", argPos, scoreCondition))
+		args = append(args, aspect)
+		argPos++
+	}
+
+	if contradiction, ok := filters["sentiment_contradiction"].(bool); ok {
+		conditions = append(conditions, fmt.Sprintf("r.sentiment_contradiction = $%d", argPos))
+		args = append(args, contradiction)
+		argPos++
+	}
+
 	whereClause := ""
 	if len(conditions) > 0 {
 		whereClause = "WHERE " + strings.Join(conditions, " AND ")
 	}
 
-	sortColumn := "r.created_at"
-	sortOrder := "DESC"
+	// whitelisted sorts; "most_helpful" ranks by the Wilson score of the votes
+	sortColumns := map[string]string{
+		"newest":       "r.created_at DESC",
+		"oldest":       "r.created_at ASC",
+		"most_helpful": "r.helpfulness DESC, r.likes_count DESC, r.created_at DESC",
+		"highest":      "r.rate_value DESC, r.created_at DESC",
+		"lowest":       "r.rate_value ASC, r.created_at DESC",
+	}
+	orderBy, ok := sortColumns[fmt.Sprint(filters["sort"])]
+	if !ok {
+		orderBy = sortColumns["newest"]
+	}
 
 	offset := (pagination.Page - 1) * pagination.Limit
 	args = append(args, pagination.Limit, offset)
@@ -174,13 +356,16 @@ func (r *PosHRRepository) GetRates(
 
 	query := fmt.Sprintf(`
 		SELECT 
-            r.id, r.hr_profile_id, r.employee_id, r.review_text, r.rate_value, 
-            r.likes_count, r.is_verified, r.is_anonymous, r.created_at,
+            r.id, r.hr_profile_id, r.employee_id, r.review_text, r.rate_value, r.rating_context, r.rating_context_note,
+            r.likes_count, r.dislikes_count, r.helpfulness, r.is_verified, r.verification_method, r.is_anonymous, r.created_at, r.updated_at, r.edited_at,
+            r.status, r.sentiment_score, r.sentiment_label, r.sentiment_contradiction, ` + rateAspectsJSON("r") + ` AS aspects,
+            ` + publicHRResponse("r") + `,
             
             p.id AS profile_id, p.name AS profile_name, p.company_name, 
             p.job_position, p.rate AS profile_rate, p.total_rates_count, p.verified_profile, 
             
-            b.id AS badge_id, b.rate AS badge_rate,
+            COALESCE(b.id, 0) AS badge_id, COALESCE(b.rate, 0) AS badge_rate,
+            bd.code AS badge_code, bd.tier AS badge_tier, bd.icon AS badge_icon,
             
             e.name AS employee_name, e.image AS employee_image
             
@@ -193,18 +378,32 @@ func (r *PosHRRepository) GetRates(
                 *,
                 ROW_NUMBER() OVER(PARTITION BY hr_profile_id ORDER BY created_at DESC) as rn
             FROM badges
+            WHERE hidden_at IS NULL AND ` + activeBadges("badges") + `
         ) b ON b.hr_profile_id = p.id AND b.rn = 1 
+        LEFT JOIN badge_definitions bd ON bd.id = b.definition_id
 
         %s
-        ORDER BY %s %s
+        ORDER BY %s, r.id DESC
         LIMIT $%d OFFSET $%d
-    `, whereClause, sortColumn, sortOrder, limitArgPos, offsetArgPos)
+    `, whereClause, orderBy, limitArgPos, offsetArgPos)
 
 	err := r.DB.SelectContext(ctx, &rates, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch rates with details: %w", err)
 	}
 
+	rateIDs := make([]int, len(rates))
+	for i := range rates {
+		rateIDs[i] = rates[i].ID
+	}
+	scores, err := r.fetchRateScores(ctx, rateIDs)
+	if err != nil {
+		return nil, err
+	}
+	for i := range rates {
+		rates[i].Scores = scores[rates[i].ID]
+	}
+
 	return rates, nil
 }
 
@@ -288,17 +487,43 @@ func (r *PosHRRepository) AddJobRoles(ctx context.Context, hrID int, newRoles []
 // =================================================================
 
 func (r *PosHRRepository) RateHR(ctx context.Context, rate *models.Rate) (int, float32, error) {
-	var newAverageRate float32
+	return r.insertRate(ctx, rate, 0)
+}
+
+// ReplaceRate supersedes the employee's previous active rate and inserts the new
+// one in the same transaction (used by the cooldown policy).
+func (r *PosHRRepository) ReplaceRate(ctx context.Context, previousRateID int, rate *models.Rate) (int, float32, error) {
+	return r.insertRate(ctx, rate, previousRateID)
+}
+
+func (r *PosHRRepository) insertRate(ctx context.Context, rate *models.Rate, supersedesRateID int) (int, float32, error) {
 	tx, err := r.DB.BeginTxx(ctx, nil)
 	if err != nil {
 		return 0, 0, fmt.Errorf("failed to begin transaction: %w", err)
 	}
 	defer tx.Rollback()
 
+	// 0. إيقاف التقييم السابق (إن وجد) قبل إضافة الجديد
+	if supersedesRateID > 0 {
+		res, err := tx.ExecContext(ctx, `
+            UPDATE rates SET superseded_at = NOW(), updated_at = NOW()
+            WHERE id = $1 AND employee_id = $2 AND deleted_at IS NULL AND superseded_at IS NULL
+        `, supersedesRateID, rate.EmployeeID)
+		if err != nil {
+			return 0, 0, fmt.Errorf("failed to supersede rate %d: %w", supersedesRateID, err)
+		}
+		if n, _ := res.RowsAffected(); n == 0 {
+			return 0, 0, ErrRateNotFound
+		}
+	}
+
 	// 1. Insert the new rate
 	queryInsertRate := `
-        INSERT INTO rates (hr_profile_id, employee_id, review_text, rate_value, rating_context, likes_count, is_verified, hr_response, is_anonymous, created_at)
-        VALUES (:hr_profile_id, :employee_id, :review_text, :rate_value, :rating_context, 0, :is_verified, :hr_response, :is_anonymous, NOW())
+        INSERT INTO rates (hr_profile_id, employee_id, review_text, rate_value, rating_context, rating_context_note, likes_count, is_anonymous,
+            status, moderation_reasons, client_ip, device_fingerprint, risk_score, risk_signals, risk_flagged, created_at)
+        VALUES (:hr_profile_id, :employee_id, :review_text, :rate_value, :rating_context, :rating_context_note, 0, :is_anonymous,
+            COALESCE(NULLIF(:status, ''), 'published'), :moderation_reasons, CAST(:client_ip AS INET), :device_fingerprint,
+            :risk_score, :risk_signals, :risk_flagged, NOW())
         RETURNING id
     `
 	// ⭐️ FIX 1: استخدام PrepareNamedContext ثم ExecContext لتسجيل البيانات في Transaction
@@ -308,33 +533,22 @@ func (r *PosHRRepository) RateHR(ctx context.Context, rate *models.Rate) (int, f
 		return 0, 0, fmt.Errorf("failed to prepare named statement: %w", err)
 	}
 	defer stmt.Close()
-	
-	rows, err := stmt.QueryxContext(ctx, rate)
-	if err != nil {
+
+	if err := stmt.GetContext(ctx, &rate.ID, rate); err != nil {
+		if isUniqueViolation(err, "ux_rates_active_hr_employee") {
+			return 0, 0, ErrRateExists
+		}
 		return 0, 0, fmt.Errorf("failed to execute named query: %w", err)
 	}
-	
-	if rows.Next() {
-		if err := rows.Scan(&rate.ID); err != nil {
-			rows.Close()
-			return 0, 0, fmt.Errorf("failed to scan new rate ID: %w", err)
-		}
+
+	if err := replaceRateScores(ctx, tx, rate); err != nil {
+		return 0, 0, err
 	}
-	rows.Close()
 
-	// 2. Update the HRProfile's average rate and count (Atomic Calculation)
-	queryUpdateProfile := `
-        UPDATE hr_profiles
-        SET 
-            total_rates_count = total_rates_count + 1,
-            rate = ((rate * total_rates_count) + $1) / (total_rates_count + 1),
-            updated_at = NOW()
-        WHERE id = $2
-        RETURNING rate
-    `
-	err = tx.GetContext(ctx, &newAverageRate, queryUpdateProfile, rate.RateValue, rate.HRProfileID)
+	// 2. Recompute the HRProfile's average rate and count from the source rows
+	newAverageRate, err := r.recalcProfileRate(ctx, tx, rate.HRProfileID)
 	if err != nil {
-		return 0, 0, fmt.Errorf("failed to update hr_profile average: %w", err)
+		return 0, 0, err
 	}
 
 	if err := tx.Commit(); err != nil {
@@ -344,15 +558,288 @@ func (r *PosHRRepository) RateHR(ctx context.Context, rate *models.Rate) (int, f
 	return rate.ID, newAverageRate, nil
 }
 
+// isUniqueViolation reports whether err is a Postgres unique violation on constraint.
+func isUniqueViolation(err error, constraint string) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505" && pqErr.Constraint == constraint
+}
+
+// publicHRResponse selects the HR response and its timestamp only once the
+// response has passed moderation.
+func publicHRResponse(alias string) string {
+	if alias != "" {
+		alias += "."
+	}
+	return fmt.Sprintf(`CASE WHEN %[1]shr_response_status = 'published' THEN %[1]shr_response END AS hr_response,
+            CASE WHEN %[1]shr_response_status = 'published' THEN %[1]shr_response_at END AS hr_response_at`, alias)
+}
+
+// liveRates is the predicate for the current rate of an author, whatever its
+// moderation status (neither deleted nor superseded). alias is the table alias
+// used by the query ("" for none).
+func liveRates(alias string) string {
+	if alias != "" {
+		alias += "."
+	}
+	return fmt.Sprintf("%[1]sdeleted_at IS NULL AND %[1]ssuperseded_at IS NULL", alias)
+}
+
+// publishedRates is the predicate for rates that are shown publicly: live rates
+// that passed moderation.
+func publishedRates(alias string) string {
+	prefix := alias
+	if prefix != "" {
+		prefix += "."
+	}
+	return liveRates(alias) + fmt.Sprintf(" AND %sstatus = 'published'", prefix)
+}
 
-func (r *PosHRRepository) LikeRate(ctx context.Context, like *models.RateLike) (int, error) {
-	var newCount int
+// countedRates is the predicate for rates that count in profile averages:
+// published rates not flagged by fraud scoring.
+func countedRates(alias string) string {
+	prefix := alias
+	if prefix != "" {
+		prefix += "."
+	}
+	return publishedRates(alias) + fmt.Sprintf(" AND NOT %srisk_flagged", prefix)
+}
+
+// recalcProfileRate recomputes rate, weighted_rate and total_rates_count of an
+// HR profile from its rates instead of patching the previous average, so edits,
+// deletes and a NULL starting rate are all handled the same way. Must run inside
+// the caller's transaction.
+func (r *PosHRRepository) recalcProfileRate(ctx context.Context, tx *sqlx.Tx, hrProfileID int) (float32, error) {
+	var newAverageRate sql.NullFloat64
+	query := `
+        UPDATE hr_profiles p
+        SET
+            rate = s.avg_rate,
+            total_rates_count = s.cnt,
+            updated_at = NOW()
+        FROM (
+            SELECT AVG(rate_value) AS avg_rate, COUNT(*) AS cnt
+            FROM rates
+            WHERE hr_profile_id = $1 AND ` + countedRates("") + `
+        ) s
+        WHERE p.id = $1
+        RETURNING p.rate
+    `
+	if err := tx.GetContext(ctx, &newAverageRate, query, hrProfileID); err != nil {
+		return 0, fmt.Errorf("failed to recalculate hr_profile %d average: %w", hrProfileID, err)
+	}
+
+	if err := r.recalcWeightedRate(ctx, tx, hrProfileID); err != nil {
+		return 0, err
+	}
+	return float32(newAverageRate.Float64), nil
+}
+
+// recalcWeightedRate stores the Bayesian average of a profile: its rates,
+// weighted by recency, reviewer verification and contributor level, are
+// blended with PriorWeight virtual rates at the prior mean, so a single 5-star
+// rate cannot outrank hundreds of 4.8 ones.
+func (r *PosHRRepository) recalcWeightedRate(ctx context.Context, tx sqlx.ExtContext, hrProfileID int) error {
+	query := `
+        WITH prior AS (
+            SELECT COALESCE(
+                CASE WHEN $2 = 'job_position' THEN (
+                    SELECT AVG(pr.rate_value)
+                    FROM rates pr
+                    JOIN hr_profiles pp ON pp.id = pr.hr_profile_id
+                    WHERE ` + countedRates("pr") + `
+                      AND pp.job_position = (SELECT job_position FROM hr_profiles WHERE id = $1)
+                ) END,
+                (SELECT AVG(pr.rate_value) FROM rates pr WHERE ` + countedRates("pr") + `),
+                0
+            ) AS mean
+        ),
+        weighted AS (
+            SELECT r.rate_value,
+                   (CASE WHEN $4::float8 > 0
+                         THEN power(0.5, EXTRACT(EPOCH FROM (NOW() - r.created_at)) / 86400.0 / $4::float8)
+                         ELSE 1 END)
+                 * (CASE WHEN e.is_verified THEN $5::float8 ELSE 1 END)
+                 * (CASE e.contributor_level WHEN 'expert' THEN $7::float8 WHEN 'trusted' THEN $6::float8 ELSE 1 END) AS weight
+            FROM rates r
+            LEFT JOIN employees e ON e.id = r.employee_id
+            WHERE r.hr_profile_id = $1 AND ` + countedRates("r") + `
+        )
+        UPDATE hr_profiles
+        SET weighted_rate = (
+            SELECT CASE WHEN COUNT(*) = 0 THEN NULL
+                        ELSE ((SELECT mean FROM prior) * $3::float8 + SUM(rate_value * weight))
+                             / ($3::float8 + SUM(weight))
+                   END
+            FROM weighted
+        )
+        WHERE id = $1
+    `
+	_, err := tx.ExecContext(ctx, query, hrProfileID, r.Scoring.PriorScope, r.Scoring.PriorWeight,
+		r.Scoring.RecencyHalfLifeDays, r.Scoring.VerifiedReviewerWeight, r.Scoring.TrustedReviewerWeight, r.Scoring.ExpertReviewerWeight)
+	if err != nil {
+		return fmt.Errorf("failed to recalculate weighted rate of hr_profile %d: %w", hrProfileID, err)
+	}
+	return nil
+}
+
+// RecalculateAllScores recomputes weighted_rate for every HR profile. The prior
+// and recency decay move over time, so this also serves as a periodic refresh.
+func (r *PosHRRepository) RecalculateAllScores(ctx context.Context) (int, error) {
+	var ids []int
+	if err := r.DB.SelectContext(ctx, &ids, `SELECT id FROM hr_profiles ORDER BY id`); err != nil {
+		return 0, fmt.Errorf("failed to list hr profiles: %w", err)
+	}
+
+	for i, id := range ids {
+		if err := r.recalcWeightedRate(ctx, r.DB, id); err != nil {
+			return i, err
+		}
+	}
+	return len(ids), nil
+}
+
+// lockOwnedRate loads a live rate FOR UPDATE and checks that employeeID wrote it.
+func lockOwnedRate(ctx context.Context, tx *sqlx.Tx, rateID int, employeeID int) (*models.Rate, error) {
+	var current models.Rate
+	query := `
+        SELECT id, hr_profile_id, employee_id, review_text, rate_value, rating_context, is_anonymous
+        FROM rates
+        WHERE id = $1 AND ` + liveRates("") + `
+        FOR UPDATE
+    `
+	err := tx.GetContext(ctx, &current, query, rateID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrRateNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock rate %d: %w", rateID, err)
+	}
+	if current.EmployeeID != employeeID {
+		return nil, ErrRateForbidden
+	}
+	return &current, nil
+}
+
+func insertRateRevision(ctx context.Context, tx *sqlx.Tx, current *models.Rate, action string) error {
+	query := `
+        INSERT INTO rate_revisions (rate_id, action, review_text, rate_value, rating_context, is_anonymous, scores, created_at)
+        VALUES ($1, $2, $3, $4, $5, $6, (
+            SELECT jsonb_agg(jsonb_build_object('code', c.code, 'score', rs.score) ORDER BY c.sort_order)
+            FROM rate_scores rs JOIN rating_criteria c ON c.id = rs.criterion_id
+            WHERE rs.rate_id = $1
+        ), NOW())
+    `
+	_, err := tx.ExecContext(ctx, query, current.ID, action, current.ReviewText, current.RateValue, current.RatingContext, current.IsAnonymous)
+	if err != nil {
+		return fmt.Errorf("failed to store revision of rate %d: %w", current.ID, err)
+	}
+	return nil
+}
+
+// UpdateRate edits a rate written by rate.EmployeeID, keeping the previous
+// version in rate_revisions, and returns the profile's new average.
+func (r *PosHRRepository) UpdateRate(ctx context.Context, rate *models.Rate) (float32, error) {
 	tx, err := r.DB.BeginTxx(ctx, nil)
 	if err != nil {
 		return 0, fmt.Errorf("failed to begin transaction: %w", err)
 	}
 	defer tx.Rollback()
 
+	// 1. قفل التقييم والتحقق من الملكية
+	current, err := lockOwnedRate(ctx, tx, rate.ID, rate.EmployeeID)
+	if err != nil {
+		return 0, err
+	}
+
+	// 2. حفظ النسخة السابقة
+	if err := insertRateRevision(ctx, tx, current, "edit"); err != nil {
+		return 0, err
+	}
+
+	// 3. تحديث التقييم
+	queryUpdate := `
+        UPDATE rates
+        SET review_text = $1, rate_value = $2, rating_context = $3, is_anonymous = $4, rating_context_note = $8,
+            status = COALESCE(NULLIF($6, ''), 'published'), moderation_reasons = $7,
+            moderated_by = NULL, moderated_at = NULL,
+            edited_at = NOW(), updated_at = NOW()
+        WHERE id = $5
+        RETURNING hr_profile_id, status, created_at, edited_at, updated_at
+    `
+	err = tx.QueryRowxContext(ctx, queryUpdate, rate.ReviewText, rate.RateValue, rate.RatingContext, rate.IsAnonymous, rate.ID,
+		rate.Status, rate.ModerationReasons, rate.RatingContextNote).
+		Scan(&rate.HRProfileID, &rate.Status, &rate.CreatedAt, &rate.EditedAt, &rate.UpdatedAt)
+	if err != nil {
+		return 0, fmt.Errorf("failed to update rate %d: %w", rate.ID, err)
+	}
+
+	if err := replaceRateScores(ctx, tx, rate); err != nil {
+		return 0, err
+	}
+
+	// 4. إعادة حساب متوسط البروفايل
+	newAverageRate, err := r.recalcProfileRate(ctx, tx, rate.HRProfileID)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return newAverageRate, nil
+}
+
+// DeleteRate soft-deletes a rate written by employeeID and returns the id of the
+// HR profile it belonged to.
+func (r *PosHRRepository) DeleteRate(ctx context.Context, rateID int, employeeID int) (int, error) {
+	tx, err := r.DB.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	current, err := lockOwnedRate(ctx, tx, rateID, employeeID)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := insertRateRevision(ctx, tx, current, "delete"); err != nil {
+		return 0, err
+	}
+
+	_, err = tx.ExecContext(ctx, `UPDATE rates SET deleted_at = NOW(), updated_at = NOW() WHERE id = $1`, rateID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete rate %d: %w", rateID, err)
+	}
+
+	if _, err := r.recalcProfileRate(ctx, tx, current.HRProfileID); err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return current.HRProfileID, nil
+}
+
+// LikeRate records a like or dislike and settles the points it moves in the
+// same transaction.
+func (r *PosHRRepository) LikeRate(ctx context.Context, like *models.RateLike, points models.RateVotePoints) (*models.RateVoteResult, error) {
+	tx, err := r.DB.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// 0. قفل التقييم وقراءة التصويت السابق
+	result, err := lockRateVotes(ctx, tx, like.RateID, like.EmployeeID, true)
+	if err != nil {
+		return nil, err
+	}
+	if result.AuthorID == like.EmployeeID {
+		return nil, ErrSelfVote
+	}
+
 	// 1. UPSERT the like
 	queryUpsertLike := `
         INSERT INTO rate_likes (rate_id, employee_id, is_like, created_at)
@@ -364,33 +851,124 @@ func (r *PosHRRepository) LikeRate(ctx context.Context, like *models.RateLike) (
     `
 	_, err = tx.NamedExecContext(ctx, queryUpsertLike, like)
 	if err != nil {
-		return 0, fmt.Errorf("failed to upsert rate_like: %w", err)
+		return nil, fmt.Errorf("failed to upsert rate_like: %w", err)
 	}
 
-	// 2. Recalculate the 'likes_count' and return the new value
-	queryRecount := `
-        UPDATE rates
-        SET likes_count = (
-            SELECT COUNT(*) FROM rate_likes
-            WHERE rate_id = $1 AND is_like = true
-        )
-        WHERE id = $1
-        RETURNING likes_count
-    `
-	err = tx.GetContext(ctx, &newCount, queryRecount, like.RateID)
-	if err != nil {
-		tx.Rollback()
-		return 0, fmt.Errorf("failed to recount likes for rate: %w", err)
+	// 2. Recalculate the counters and the helpfulness score
+	if err := recountRateVotes(ctx, tx, result); err != nil {
+		return nil, err
+	}
+
+	// 3. النقاط تُسجّل مع التصويت نفسه، فلا يبقى تصويت بلا نقاطه
+	if err := settleVotePoints(ctx, tx, like.EmployeeID, like.IsLike, points, result); err != nil {
+		return nil, err
 	}
 
 	if err := tx.Commit(); err != nil {
-		return 0, err
+		return nil, err
 	}
 	
-	return newCount, nil 
+	return result, nil 
 }
 
-func (r *PosHRRepository) LikeBadge(ctx context.Context, like *models.BadgeLike) error {
+// RemoveRateVote deletes the employee's like or dislike on a rate and takes
+// back the points it earned in the same transaction.
+func (r *PosHRRepository) RemoveRateVote(ctx context.Context, rateID int, employeeID int, points models.RateVotePoints) (*models.RateVoteResult, error) {
+	tx, err := r.DB.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// سحب التصويت مسموح حتى لو أُخفي التقييم أو عاد للمراجعة
+	result, err := lockRateVotes(ctx, tx, rateID, employeeID, false)
+	if err != nil {
+		return nil, err
+	}
+	if result.PreviousVote == nil {
+		return nil, ErrVoteNotFound
+	}
+
+	_, err = tx.ExecContext(ctx, `DELETE FROM rate_likes WHERE rate_id = $1 AND employee_id = $2`, rateID, employeeID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to delete vote on rate %d: %w", rateID, err)
+	}
+
+	if err := recountRateVotes(ctx, tx, result); err != nil {
+		return nil, err
+	}
+	if err := settleVotePoints(ctx, tx, employeeID, false, points, result); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return result, nil
+}
+
+// lockRateVotes locks a rate for a vote change and reads its author, the
+// current likes count and the employee's previous vote. New votes need a
+// published rate; withdrawing one only needs a live rate.
+func lockRateVotes(ctx context.Context, tx *sqlx.Tx, rateID int, employeeID int, publishedOnly bool) (*models.RateVoteResult, error) {
+	result := &models.RateVoteResult{RateID: rateID}
+	visible := liveRates("r")
+	if publishedOnly {
+		visible = publishedRates("r")
+	}
+	query := `
+        SELECT r.hr_profile_id, r.employee_id, r.likes_count,
+               (SELECT is_like FROM rate_likes WHERE rate_id = r.id AND employee_id = $2)
+        FROM rates r
+        WHERE r.id = $1 AND ` + visible + `
+        FOR UPDATE
+    `
+	err := tx.QueryRowxContext(ctx, query, rateID, employeeID).
+		Scan(&result.HRProfileID, &result.AuthorID, &result.PreviousLikes, &result.PreviousVote)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrRateNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock rate %d for voting: %w", rateID, err)
+	}
+	return result, nil
+}
+
+// recountRateVotes recounts likes and dislikes from rate_likes and stores the
+// Wilson lower bound as the helpfulness score.
+func recountRateVotes(ctx context.Context, tx *sqlx.Tx, result *models.RateVoteResult) error {
+	query := `
+        UPDATE rates r
+        SET likes_count = v.likes,
+            dislikes_count = v.dislikes,
+            helpfulness = wilson_lower_bound(v.likes, v.dislikes)
+        FROM (
+            SELECT COUNT(*) FILTER (WHERE is_like) AS likes,
+                   COUNT(*) FILTER (WHERE NOT is_like) AS dislikes
+            FROM rate_likes
+            WHERE rate_id = $1
+        ) v
+        WHERE r.id = $1
+        RETURNING r.likes_count, r.dislikes_count, r.helpfulness
+    `
+	err := tx.QueryRowxContext(ctx, query, result.RateID).Scan(&result.LikesCount, &result.DislikesCount, &result.Helpfulness)
+	if err != nil {
+		return fmt.Errorf("failed to recount votes for rate %d: %w", result.RateID, err)
+	}
+	return nil
+}
+
+func (r *PosHRRepository) LikeBadge(ctx context.Context, like *models.BadgeLike) (*models.BadgeVoteResult, error) {
+	tx, err := r.DB.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := lockBadgeVotes(ctx, tx, like.BadgeID, like.EmployeeID, true)
+	if err != nil {
+		return nil, err
+	}
 
 	query := `
         INSERT INTO badge_likes (badge_id, employee_id, is_like, created_at)
@@ -400,14 +978,97 @@ func (r *PosHRRepository) LikeBadge(ctx context.Context, like *models.BadgeLike)
             is_like = EXCLUDED.is_like,
             created_at = NOW()
     `
-	_, err := r.DB.NamedExecContext(ctx, query, like)
+	if _, err := tx.NamedExecContext(ctx, query, like); err != nil {
+		return nil, fmt.Errorf("failed to upsert badge_like: %w", err)
+	}
+
+	if err := recountBadgeVotes(ctx, tx, result); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	result.MyVote = &like.IsLike
+	return result, nil
+}
+
+// RemoveBadgeVote deletes the employee's like or dislike on a badge.
+func (r *PosHRRepository) RemoveBadgeVote(ctx context.Context, badgeID int, employeeID int) (*models.BadgeVoteResult, error) {
+	tx, err := r.DB.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// سحب التصويت مسموح حتى بعد سحب الشارة أو انتهائها
+	result, err := lockBadgeVotes(ctx, tx, badgeID, employeeID, false)
+	if err != nil {
+		return nil, err
+	}
+	if result.PreviousVote == nil {
+		return nil, ErrVoteNotFound
+	}
+
+	_, err = tx.ExecContext(ctx, `DELETE FROM badge_likes WHERE badge_id = $1 AND employee_id = $2`, badgeID, employeeID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to delete vote on badge %d: %w", badgeID, err)
+	}
+
+	if err := recountBadgeVotes(ctx, tx, result); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return result, nil
+}
+
+// lockBadgeVotes locks a badge not hidden by moderation for a vote change and
+// reads the employee's previous vote. New votes also need the badge to be
+// active (not revoked or expired); withdrawing one does not.
+func lockBadgeVotes(ctx context.Context, tx *sqlx.Tx, badgeID int, employeeID int, activeOnly bool) (*models.BadgeVoteResult, error) {
+	result := &models.BadgeVoteResult{BadgeID: badgeID}
+	visible := "b.hidden_at IS NULL"
+	if activeOnly {
+		visible += " AND " + activeBadges("b")
+	}
+	query := `
+        SELECT b.hr_profile_id,
+               (SELECT is_like FROM badge_likes WHERE badge_id = b.id AND employee_id = $2)
+        FROM badges b
+        WHERE b.id = $1 AND ` + visible + `
+        FOR UPDATE
+    `
+	err := tx.QueryRowxContext(ctx, query, badgeID, employeeID).Scan(&result.HRProfileID, &result.PreviousVote)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrBadgeNotFound
+	}
 	if err != nil {
-		return fmt.Errorf("failed to upsert badge_like: %w", err)
+		return nil, fmt.Errorf("failed to lock badge %d for voting: %w", badgeID, err)
+	}
+	return result, nil
+}
+
+// recountBadgeVotes recalculates the like and dislike counters of a badge
+// inside the voting transaction.
+func recountBadgeVotes(ctx context.Context, tx *sqlx.Tx, result *models.BadgeVoteResult) error {
+	query := `
+        UPDATE badges b
+        SET likes_count = v.likes,
+            dislikes_count = v.dislikes
+        FROM (
+            SELECT COUNT(*) FILTER (WHERE is_like) AS likes,
+                   COUNT(*) FILTER (WHERE NOT is_like) AS dislikes
+            FROM badge_likes
+            WHERE badge_id = $1
+        ) v
+        WHERE b.id = $1
+        RETURNING b.likes_count, b.dislikes_count
+    `
+	err := tx.QueryRowxContext(ctx, query, result.BadgeID).Scan(&result.LikesCount, &result.DislikesCount)
+	if err != nil {
+		return fmt.Errorf("failed to recount votes for badge %d: %w", result.BadgeID, err)
 	}
-	
-	// (Note: You might want to update the badge's likes_count atomically here 
-	// similar to LikeRate, but for simplicity, we keep it as a simple upsert.)
-	
 	return nil
 }
 
@@ -417,7 +1078,7 @@ func (r *PosHRRepository) LikeBadge(ctx context.Context, like *models.BadgeLike)
 
 func (r *PosHRRepository) GetHRProfileByID(ctx context.Context, hrID int) (*models.HRProfile, error) {
 	var profile models.HRProfile
-	query := `SELECT id, name, email, company_name, job_position, rate, total_rates_count, verified_profile, updated_at FROM hr_profiles WHERE id = $1`
+	query := `SELECT id, name, email, image, company_name, job_position, rate, weighted_rate, total_rates_count, verified_profile, created_at, updated_at FROM hr_profiles WHERE id = $1`
 	err := r.DB.GetContext(ctx, &profile, query, hrID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch HR profile %d: %w", hrID, err)
@@ -427,12 +1088,25 @@ func (r *PosHRRepository) GetHRProfileByID(ctx context.Context, hrID int) (*mode
 
 
 
-func (r *PosHRRepository) CheckIfProfileHasBadge(ctx context.Context, profileID int, badgeName string) (bool, error) {
+// SetProfileVerified sets the verified flag of an HR profile; sql.ErrNoRows
+// when the profile does not exist.
+func (r *PosHRRepository) SetProfileVerified(ctx context.Context, profileID int, verified bool) error {
+	res, err := r.DB.ExecContext(ctx, `UPDATE hr_profiles SET verified_profile = $2, updated_at = NOW() WHERE id = $1`, profileID, verified)
+	if err != nil {
+		return fmt.Errorf("failed to set verified flag of HR profile %d: %w", profileID, err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func (r *PosHRRepository) CheckIfProfileHasBadge(ctx context.Context, profileID int, definitionID int) (bool, error) {
 	var count int
-	query := `SELECT COUNT(id) FROM badges WHERE hr_profile_id = $1 AND job_position = $2`
-	err := r.DB.GetContext(ctx, &count, query, profileID, badgeName)
+	query := `SELECT COUNT(id) FROM badges WHERE hr_profile_id = $1 AND definition_id = $2 AND revoked_at IS NULL`
+	err := r.DB.GetContext(ctx, &count, query, profileID, definitionID)
 	if err != nil {
-		return false, fmt.Errorf("failed to check for badge %s: %w", badgeName, err)
+		return false, fmt.Errorf("failed to check for badge definition %d: %w", definitionID, err)
 	}
 	return count > 0, nil
 }
@@ -446,20 +1120,110 @@ func (r *PosHRRepository) GetRateOwner(ctx context.Context, rateID int) (int, er
 	return employeeID, nil
 }
 
-func (r *PosHRRepository) UpdateEmployeePoints(ctx context.Context, employeeID int, pointsToAdd int) error {
-	// Assumes an 'employees' table with an 'active_points' column
-	query := `UPDATE employees SET active_points = active_points + $1 WHERE id = $2`
-	_, err := r.DB.ExecContext(ctx, query, pointsToAdd, employeeID)
+func (r *PosHRRepository) GetRate(ctx context.Context, rateID int) (*models.Rate, error) {
+	var rate models.Rate
+	query := `
+        SELECT id, hr_profile_id, employee_id, review_text, rate_value, rating_context, rating_context_note, likes_count,
+               dislikes_count, helpfulness, sentiment_score, sentiment_label, sentiment_contradiction,
+               ` + rateAspectsJSON("rates") + ` AS aspects, is_verified, verification_method, ` + publicHRResponse("") + `, is_anonymous, created_at, updated_at, edited_at,
+               status, moderation_reasons, moderated_by, moderated_at
+        FROM rates
+        WHERE id = $1 AND deleted_at IS NULL
+    `
+	err := r.DB.GetContext(ctx, &rate, query, rateID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrRateNotFound
+	}
 	if err != nil {
-		return fmt.Errorf("failed to update points for employee %d: %w", employeeID, err)
+		return nil, fmt.Errorf("failed to fetch rate %d: %w", rateID, err)
 	}
-	return nil
+
+	scores, err := r.fetchRateScores(ctx, []int{rate.ID})
+	if err != nil {
+		return nil, err
+	}
+	rate.Scores = scores[rate.ID]
+	return &rate, nil
+}
+
+// GetActiveRate returns the employee's current rate for an HR profile, or
+// ErrRateNotFound when there is none.
+func (r *PosHRRepository) GetActiveRate(ctx context.Context, hrProfileID int, employeeID int) (*models.Rate, error) {
+	var rate models.Rate
+	query := `
+        SELECT id, hr_profile_id, employee_id, review_text, rate_value, rating_context, rating_context_note, likes_count,
+               dislikes_count, helpfulness, sentiment_score, sentiment_label, sentiment_contradiction,
+               ` + rateAspectsJSON("rates") + ` AS aspects, is_verified, verification_method, ` + publicHRResponse("") + `, is_anonymous, created_at, updated_at, edited_at,
+               status, moderation_reasons, moderated_by, moderated_at
+        FROM rates
+        WHERE hr_profile_id = $1 AND employee_id = $2 AND ` + liveRates("") + `
+    `
+	err := r.DB.GetContext(ctx, &rate, query, hrProfileID, employeeID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrRateNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch active rate: %w", err)
+	}
+	return &rate, nil
+}
+
+// GetLastRateTime returns when the employee last rated an HR profile, counting
+// deleted and superseded rates, or nil when they never did.
+func (r *PosHRRepository) GetLastRateTime(ctx context.Context, hrProfileID int, employeeID int) (*time.Time, error) {
+	var createdAt *time.Time
+	query := `SELECT MAX(created_at) FROM rates WHERE hr_profile_id = $1 AND employee_id = $2`
+	if err := r.DB.GetContext(ctx, &createdAt, query, hrProfileID, employeeID); err != nil {
+		return nil, fmt.Errorf("failed to fetch last rate time: %w", err)
+	}
+	return createdAt, nil
 }
 
+// SetHRResponse stores (or clears, when response is nil) the HR's public
+// response to a rate together with its moderation status. It reports whether
+// the rate had a response before, held or not.
+func (r *PosHRRepository) SetHRResponse(ctx context.Context, rateID int, response *string, status *string, reasons models.JSONB) (bool, error) {
+	query := `
+        UPDATE rates r
+        SET hr_response = $1,
+            hr_response_status = $2,
+            hr_response_moderation_reasons = $4,
+            hr_response_at = CASE WHEN $1::text IS NULL THEN NULL ELSE NOW() END
+        FROM (SELECT id, hr_response IS NOT NULL AS had_response FROM rates WHERE id = $3 FOR UPDATE) old
+        WHERE r.id = old.id AND ` + liveRates("r") + `
+        RETURNING old.had_response
+    `
+	var hadResponse bool
+	err := r.DB.GetContext(ctx, &hadResponse, query, response, status, rateID, reasons)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, ErrRateNotFound
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to set hr response of rate %d: %w", rateID, err)
+	}
+	return hadResponse, nil
+}
+
+func (r *PosHRRepository) GetRateRevisions(ctx context.Context, rateID int) ([]models.RateRevision, error) {
+	revisions := []models.RateRevision{}
+	query := `
+        SELECT id, rate_id, action, review_text, rate_value, rating_context, is_anonymous, scores, created_at
+        FROM rate_revisions
+        WHERE rate_id = $1
+        ORDER BY created_at DESC
+    `
+	if err := r.DB.SelectContext(ctx, &revisions, query, rateID); err != nil {
+		return nil, fmt.Errorf("failed to fetch revisions of rate %d: %w", rateID, err)
+	}
+	return revisions, nil
+}
+
+
 func (r *PosHRRepository) AwardBadge(ctx context.Context, badge *models.Badge) (int, error) {
 	query := `
-        INSERT INTO badges (hr_profile_id, created_date, total_rates_number, rate, job_position, current_job_roles, created_at, updated_at)
-        VALUES (:hr_profile_id, :created_date, :total_rates_number, :rate, :job_position, :current_job_roles, NOW(), NOW())
+        INSERT INTO badges (hr_profile_id, definition_id, created_date, total_rates_number, rate, award_note, expires_at, created_at, updated_at)
+        VALUES (:hr_profile_id, :definition_id, :created_date, :total_rates_number, :rate, :award_note, :expires_at, NOW(), NOW())
+        ON CONFLICT (hr_profile_id, definition_id) WHERE revoked_at IS NULL DO NOTHING
         RETURNING id
     `
 	rows, err := r.DB.NamedQueryContext(ctx, query, badge)
@@ -468,10 +1232,15 @@ func (r *PosHRRepository) AwardBadge(ctx context.Context, badge *models.Badge) (
 	}
 	defer rows.Close()
 
-	if rows.Next() {
-		if err := rows.Scan(&badge.ID); err != nil {
+	// لا صف = الشارة ممنوحة مسبقاً (المنح متكرر الأمان)
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
 			return 0, err
 		}
+		return 0, ErrBadgeAlreadyAwarded
+	}
+	if err := rows.Scan(&badge.ID); err != nil {
+		return 0, err
 	}
 	return badge.ID, nil
 }
@@ -479,7 +1248,7 @@ func (r *PosHRRepository) AwardBadge(ctx context.Context, badge *models.Badge) (
 
 // internal/repos/employee_repo.go
 
-func (r *PosHRRepository) GetEmployeeStats(ctx context.Context, employeeID int) (models.EmployeeStats, error) {
+func (r *PosHRRepository) GetEmployeeStats(ctx context.Context, employeeID int, includeAnonymous bool) (models.EmployeeStats, error) {
     stats := models.EmployeeStats{}
 
     // 1. حساب إجمالي التقييمات والإعجابات
@@ -488,28 +1257,17 @@ func (r *PosHRRepository) GetEmployeeStats(ctx context.Context, employeeID int)
             COUNT(r.id) AS total_ratings_count,
             COALESCE(SUM(r.likes_count), 0) AS total_likes_count
         FROM rates r
-        WHERE r.employee_id = $1
+        WHERE r.employee_id = $1 AND ` + publishedRates("r") + `
+          AND ($2 OR r.is_anonymous = false)
     `
     // ⚠️ يجب استخدام QueryRow أو DB.GetContext إذا كنت تستخدم sqlx
-    if err := r.DB.QueryRowContext(ctx, query1, employeeID).Scan(
+    if err := r.DB.QueryRowContext(ctx, query1, employeeID, includeAnonymous).Scan(
         &stats.TotalRatingsCount, 
         &stats.TotalLikesCount,
     ); err != nil {
         return models.EmployeeStats{}, fmt.Errorf("failed to fetch rate counts: %w", err)
     }
 
-    // 2. حساب التصنيف المئوي (ContributorRank)
-    // هذا مثال بسيط، المنطق الحقيقي أكثر تعقيداً ويتطلب نافذة وظيفية (Window Function)
-    
-    // لتبسيط الأمر الآن، نفترض أن أي شخص لديه أكثر من 5 تقييمات هو Top 10%
-    if stats.TotalRatingsCount >= 5 {
-        stats.ContributorRank = "Top 10%"
-    } else if stats.TotalRatingsCount > 0 {
-        stats.ContributorRank = "Contributor"
-    } else {
-        stats.ContributorRank = "Newbie"
-    }
-
-
+    // 2. التصنيف المئوي (ContributorRank) يُقرأ في الخدمة من ترتيب مخزن مؤقتاً لكل الموظفين
     return stats, nil
 }
\ No newline at end of file
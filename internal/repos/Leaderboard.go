@@ -0,0 +1,299 @@
+package repos
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"githup.ahmedramadan.4cashier/internal/models"
+)
+
+var (
+	ErrInvalidLeaderboard = errors.New("unknown leaderboard or scope")
+	ErrSnapshotNotFound   = errors.New("no leaderboard snapshot for this date")
+)
+
+// Weight of a public review in the contributor score:
+// points + contributorReviewWeight*reviews + likes received.
+const contributorReviewWeight = 5
+
+// Fewest counted rates an HR needs to be ranked, all-time and in a month.
+const (
+	minRankedHRRates        = 1
+	minMonthlyRankedHRRates = 3
+)
+
+// leaderboardScopes maps the scopes of each board to the column they partition by.
+var leaderboardScopes = map[string]map[string]string{
+	models.BoardContributors: {
+		models.ScopeOverall:  "''",
+		models.ScopeCity:     "LOWER(TRIM(e.city))",
+		models.ScopeJobField: "LOWER(TRIM(e.job_field))",
+	},
+	models.BoardHRs: {
+		models.ScopeOverall:     "''",
+		models.ScopeCompany:     "LOWER(TRIM(p.company_name))",
+		models.ScopeJobPosition: "LOWER(TRIM(p.job_position))",
+	},
+}
+
+// =================================================================
+// 🏆 Leaderboards (الترتيب المئوي ولوحات الصدارة)
+// =================================================================
+
+// inPeriod restricts a timestamp column to the month starting at $1, or lets
+// everything through when $1 is NULL (all-time boards).
+func inPeriod(column string) string {
+	return fmt.Sprintf("($1::date IS NULL OR (%[1]s >= $1::date AND %[1]s < $1::date + INTERVAL '1 month'))", column)
+}
+
+// contributorScoresSQL scores every employee. All-time boards sum the whole
+// points ledger, monthly boards the points, public reviews and likes received
+// in the month. Anonymous rates are left out so rankings reveal nothing about
+// them, including the points their authors earned on them.
+func contributorScoresSQL(scope string) string {
+	public := publishedRates("r") + " AND r.is_anonymous IS NOT TRUE"
+	stats := `
+            SELECT e.id AS subject_id, e.name, e.image, COALESCE(` + scope + `, '') AS scope_value,
+                   COALESCE(pl.points, 0) AS points,
+                   COALESCE(rv.reviews, 0) AS reviews,
+                   CASE WHEN $1::date IS NULL THEN COALESCE(rv.likes, 0) ELSE COALESCE(lk.likes, 0) END AS likes
+            FROM employees e
+            LEFT JOIN (
+                SELECT l.employee_id, SUM(l.delta) AS points
+                FROM points_ledger l
+                WHERE ` + inPeriod("l.created_at") + `
+                  AND NOT EXISTS (
+                      SELECT 1 FROM rates ar
+                      WHERE l.source_type = 'rate' AND ar.id = l.source_id
+                        AND ar.employee_id = l.employee_id AND ar.is_anonymous
+                  )
+                GROUP BY l.employee_id
+            ) pl ON pl.employee_id = e.id
+            LEFT JOIN (
+                SELECT r.employee_id, COUNT(*) AS reviews, SUM(r.likes_count) AS likes
+                FROM rates r
+                WHERE ` + public + ` AND ` + inPeriod("r.created_at") + `
+                GROUP BY r.employee_id
+            ) rv ON rv.employee_id = e.id
+            LEFT JOIN (
+                SELECT r.employee_id, COUNT(*) AS likes
+                FROM rate_likes l
+                JOIN rates r ON r.id = l.rate_id
+                WHERE $1::date IS NOT NULL AND l.is_like AND ` + public + ` AND ` + inPeriod("l.created_at") + `
+                GROUP BY r.employee_id
+            ) lk ON lk.employee_id = e.id`
+	return fmt.Sprintf(`
+        SELECT c.*, (c.points + %d * c.reviews + c.likes)::float8 AS score
+        FROM (%s
+        ) c`, contributorReviewWeight, stats)
+}
+
+// hrScoresSQL scores every HR profile: the weighted rate all-time, the average
+// of the counted rates of the month for monthly boards.
+func hrScoresSQL(scope string) string {
+	return `
+        SELECT p.id AS subject_id, p.name, p.image, COALESCE(` + scope + `, '') AS scope_value,
+               CASE WHEN $1::date IS NULL THEN COALESCE(p.total_rates_count, 0) ELSE COALESCE(m.rates_count, 0) END AS rates_count,
+               (CASE WHEN $1::date IS NULL THEN COALESCE(p.weighted_rate, p.rate, 0) ELSE COALESCE(m.avg_rate, 0) END)::float8 AS score
+        FROM hr_profiles p
+        LEFT JOIN (
+            SELECT r.hr_profile_id, COUNT(*) AS rates_count, AVG(r.rate_value) AS avg_rate
+            FROM rates r
+            WHERE $1::date IS NOT NULL AND ` + countedRates("r") + ` AND ` + inPeriod("r.created_at") + `
+            GROUP BY r.hr_profile_id
+        ) m ON m.hr_profile_id = p.id`
+}
+
+// rankedLeaderboardSQL ranks a board within each scope value with window
+// functions. The month start is $1 (NULL for all-time).
+func rankedLeaderboardSQL(board string, scope string) (string, error) {
+	column, ok := leaderboardScopes[board][scope]
+	if !ok {
+		return "", ErrInvalidLeaderboard
+	}
+
+	var scores, eligible string
+	switch board {
+	case models.BoardContributors:
+		scores, eligible = contributorScoresSQL(column), "x.score > 0"
+	default:
+		scores = hrScoresSQL(column)
+		eligible = fmt.Sprintf("x.rates_count >= CASE WHEN $1::date IS NULL THEN %d ELSE %d END", minRankedHRRates, minMonthlyRankedHRRates)
+	}
+	if scope != models.ScopeOverall {
+		eligible += " AND x.scope_value <> ''"
+	}
+
+	return `
+        SELECT x.*,
+               RANK() OVER (PARTITION BY x.scope_value ORDER BY x.score DESC)::int AS rank,
+               100 * PERCENT_RANK() OVER (PARTITION BY x.scope_value ORDER BY x.score) AS percentile,
+               100 * CUME_DIST() OVER (PARTITION BY x.scope_value ORDER BY x.score DESC) AS top_percent
+        FROM (` + scores + `
+        ) x
+        WHERE ` + eligible, nil
+}
+
+// GetLeaderboard returns the top entries of every scope value, or of one when
+// query.Value is set.
+func (r *PosHRRepository) GetLeaderboard(ctx context.Context, query models.LeaderboardQuery) ([]models.LeaderboardEntry, error) {
+	ranked, err := rankedLeaderboardSQL(query.Board, query.Scope)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := []models.LeaderboardEntry{}
+	sqlQuery := `
+        SELECT * FROM (` + ranked + `
+        ) ranked
+        WHERE ($2 = '' OR ranked.scope_value = LOWER(TRIM($2))) AND ranked.rank <= $3
+        ORDER BY ranked.scope_value, ranked.rank, ranked.subject_id
+    `
+	if err := r.DB.SelectContext(ctx, &entries, sqlQuery, query.Month, query.Value, query.Limit); err != nil {
+		return nil, fmt.Errorf("failed to rank %s by %s: %w", query.Board, query.Scope, err)
+	}
+	return entries, nil
+}
+
+// GetContributorStandings places every employee among all employees,
+// all-time, with their points and overall score. It ranks everyone in one
+// query, so callers keep the result for a while instead of asking per employee.
+func (r *PosHRRepository) GetContributorStandings(ctx context.Context) ([]models.ContributorStanding, error) {
+	query := `
+        SELECT c.subject_id, c.points, c.score,
+               100 * PERCENT_RANK() OVER (ORDER BY c.points),
+               100 * PERCENT_RANK() OVER (ORDER BY c.reviews),
+               100 * PERCENT_RANK() OVER (ORDER BY c.likes),
+               100 * PERCENT_RANK() OVER (ORDER BY c.score),
+               100 * CUME_DIST() OVER (ORDER BY c.score DESC)
+        FROM (` + contributorScoresSQL("''") + `
+        ) c
+    `
+	rows, err := r.DB.QueryxContext(ctx, query, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to rank contributors: %w", err)
+	}
+	defer rows.Close()
+
+	standings := []models.ContributorStanding{}
+	for rows.Next() {
+		var s models.ContributorStanding
+		p := &s.Percentiles
+		if err := rows.Scan(&s.EmployeeID, &s.Points, &s.Score, &p.Points, &p.Reviews, &p.Likes, &p.Overall, &p.TopPercent); err != nil {
+			return nil, fmt.Errorf("failed to read contributor standing: %w", err)
+		}
+		standings = append(standings, s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to rank contributors: %w", err)
+	}
+	return standings, nil
+}
+
+// SaveLeaderboardSnapshot stores the top entries of every scope value of a
+// board. A second snapshot of the same board on the same day replaces the first.
+func (r *PosHRRepository) SaveLeaderboardSnapshot(ctx context.Context, query models.LeaderboardQuery, takenOn time.Time) (*models.LeaderboardSnapshot, error) {
+	ranked, err := rankedLeaderboardSQL(query.Board, query.Scope)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := r.DB.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var snapshot models.LeaderboardSnapshot
+	insert := `
+        INSERT INTO leaderboard_snapshots (board, scope, period, period_start, taken_on, taken_at)
+        VALUES ($1, $2, $3, $4::date, $5::date, NOW())
+        ON CONFLICT ON CONSTRAINT ux_leaderboard_snapshots DO UPDATE SET taken_at = NOW()
+        RETURNING id, board, scope, period, period_start, taken_on, taken_at
+    `
+	if err := tx.GetContext(ctx, &snapshot, insert, query.Board, query.Scope, query.Period, query.Month, takenOn); err != nil {
+		return nil, fmt.Errorf("failed to create %s snapshot: %w", query.Board, err)
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM leaderboard_snapshot_entries WHERE snapshot_id = $1`, snapshot.ID); err != nil {
+		return nil, fmt.Errorf("failed to clear snapshot %d: %w", snapshot.ID, err)
+	}
+
+	var points, reviews, likes, ratesCount = "NULL::int", "NULL::int", "NULL::int", "NULL::int"
+	if query.Board == models.BoardContributors {
+		points, reviews, likes = "ranked.points", "ranked.reviews", "ranked.likes"
+	} else {
+		ratesCount = "ranked.rates_count"
+	}
+	entries := `
+        INSERT INTO leaderboard_snapshot_entries
+            (snapshot_id, scope_value, rank, subject_id, name, image, score, percentile, top_percent, points, reviews, likes, rates_count)
+        SELECT $2::bigint, ranked.scope_value, ranked.rank, ranked.subject_id, ranked.name, ranked.image, ranked.score,
+               ranked.percentile, ranked.top_percent, ` + points + `, ` + reviews + `, ` + likes + `, ` + ratesCount + `
+        FROM (` + ranked + `
+        ) ranked
+        WHERE ranked.rank <= $3
+    `
+	if _, err := tx.ExecContext(ctx, entries, query.Month, snapshot.ID, query.Limit); err != nil {
+		return nil, fmt.Errorf("failed to fill snapshot %d: %w", snapshot.ID, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit snapshot %d: %w", snapshot.ID, err)
+	}
+	return &snapshot, nil
+}
+
+// GetLeaderboardSnapshots lists the snapshots of a board, newest first.
+func (r *PosHRRepository) GetLeaderboardSnapshots(ctx context.Context, query models.LeaderboardQuery) ([]models.LeaderboardSnapshot, error) {
+	snapshots := []models.LeaderboardSnapshot{}
+	sqlQuery := `
+        SELECT id, board, scope, period, period_start, taken_on, taken_at
+        FROM leaderboard_snapshots
+        WHERE board = $1 AND scope = $2 AND period = $3 AND period_start IS NOT DISTINCT FROM $4::date
+        ORDER BY taken_on DESC
+        LIMIT $5
+    `
+	if err := r.DB.SelectContext(ctx, &snapshots, sqlQuery, query.Board, query.Scope, query.Period, query.Month, query.Limit); err != nil {
+		return nil, fmt.Errorf("failed to fetch %s snapshots: %w", query.Board, err)
+	}
+	return snapshots, nil
+}
+
+// GetLeaderboardSnapshotAt returns the latest snapshot taken on or before at.
+func (r *PosHRRepository) GetLeaderboardSnapshotAt(ctx context.Context, query models.LeaderboardQuery, at time.Time) (*models.LeaderboardSnapshot, error) {
+	var snapshot models.LeaderboardSnapshot
+	sqlQuery := `
+        SELECT id, board, scope, period, period_start, taken_on, taken_at
+        FROM leaderboard_snapshots
+        WHERE board = $1 AND scope = $2 AND period = $3 AND period_start IS NOT DISTINCT FROM $4::date AND taken_on <= $5::date
+        ORDER BY taken_on DESC
+        LIMIT 1
+    `
+	err := r.DB.GetContext(ctx, &snapshot, sqlQuery, query.Board, query.Scope, query.Period, query.Month, at)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrSnapshotNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s snapshot: %w", query.Board, err)
+	}
+	return &snapshot, nil
+}
+
+// GetLeaderboardSnapshotEntries returns the stored entries of a snapshot,
+// filtered like GetLeaderboard.
+func (r *PosHRRepository) GetLeaderboardSnapshotEntries(ctx context.Context, snapshotID int64, value string, limit int) ([]models.LeaderboardEntry, error) {
+	entries := []models.LeaderboardEntry{}
+	query := `
+        SELECT rank, subject_id, name, image, scope_value, score, percentile, top_percent, points, reviews, likes, rates_count
+        FROM leaderboard_snapshot_entries
+        WHERE snapshot_id = $1 AND ($2 = '' OR scope_value = LOWER(TRIM($2))) AND rank <= $3
+        ORDER BY scope_value, rank, subject_id
+    `
+	if err := r.DB.SelectContext(ctx, &entries, query, snapshotID, value, limit); err != nil {
+		return nil, fmt.Errorf("failed to fetch entries of snapshot %d: %w", snapshotID, err)
+	}
+	return entries, nil
+}
@@ -0,0 +1,211 @@
+package repos
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+	"githup.ahmedramadan.4cashier/internal/bootstrap"
+	"githup.ahmedramadan.4cashier/internal/models"
+)
+
+var (
+	ErrReportNotFound      = errors.New("report not found")
+	ErrAlreadyReported     = errors.New("you already reported this item")
+	ErrReportTargetMissing = errors.New("reported item not found")
+	ErrNoOpenReports       = errors.New("no open reports on this item")
+)
+
+// ModerationRepository stores user reports and the moderation audit log.
+type ModerationRepository interface {
+	CreateReport(ctx context.Context, report *models.Report, levelWeight float64) (int, error)
+	GetReport(ctx context.Context, reportID int) (*models.Report, error)
+	GetReports(ctx context.Context, pagination bootstrap.Pagination, filters map[string]interface{}) ([]models.Report, error)
+	GetOpenReportWeight(ctx context.Context, targetType string, targetID int) (float64, error)
+
+	AddAuditEntry(ctx context.Context, entry *models.AuditEntry) error
+	GetAuditLog(ctx context.Context, pagination bootstrap.Pagination, filters map[string]interface{}) ([]models.AuditEntry, error)
+}
+
+// PosModerationRepository implements ModerationRepository for PostgreSQL.
+type PosModerationRepository struct {
+	DB *sqlx.DB
+}
+
+// NewPosModerationRepository creates a new instance of PosModerationRepository.
+func NewPosModerationRepository(db *sqlx.DB) ModerationRepository {
+	return &PosModerationRepository{DB: db}
+}
+
+const reportColumns = `id, target_type, target_id, reporter_id, reason, details, weight, status,
+        resolved_by, resolution_note, resolved_at, created_at`
+
+// CreateReport files a report weighted by the reporter's current credibility
+// times levelWeight, the weight of their contributor level. The target must
+// exist: a published rate or a visible badge.
+func (r *PosModerationRepository) CreateReport(ctx context.Context, report *models.Report, levelWeight float64) (int, error) {
+	var targetQuery string
+	switch report.TargetType {
+	case "rate":
+		targetQuery = `SELECT EXISTS (SELECT 1 FROM rates WHERE id = $1 AND ` + publishedRates("") + `)`
+	case "badge":
+		targetQuery = `SELECT EXISTS (SELECT 1 FROM badges WHERE id = $1 AND hidden_at IS NULL)`
+	default:
+		return 0, ErrReportTargetMissing
+	}
+	var exists bool
+	if err := r.DB.GetContext(ctx, &exists, targetQuery, report.TargetID); err != nil {
+		return 0, fmt.Errorf("failed to check reported %s %d: %w", report.TargetType, report.TargetID, err)
+	}
+	if !exists {
+		return 0, ErrReportTargetMissing
+	}
+
+	query := `
+        INSERT INTO reports (target_type, target_id, reporter_id, reason, details, weight, status, created_at)
+        VALUES ($1, $2, $3, $4, $5,
+            COALESCE((SELECT report_credibility FROM employees WHERE id = $3), 1.0) * $6::real, 'open', NOW())
+        RETURNING id, weight, status, created_at
+    `
+	err := r.DB.QueryRowxContext(ctx, query, report.TargetType, report.TargetID, report.ReporterID, report.Reason, report.Details, levelWeight).
+		Scan(&report.ID, &report.Weight, &report.Status, &report.CreatedAt)
+	if isUniqueViolation(err, "ux_reports_target_reporter") {
+		return 0, ErrAlreadyReported
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to insert report: %w", err)
+	}
+	return report.ID, nil
+}
+
+func (r *PosModerationRepository) GetReport(ctx context.Context, reportID int) (*models.Report, error) {
+	var report models.Report
+	err := r.DB.GetContext(ctx, &report, `SELECT `+reportColumns+` FROM reports WHERE id = $1`, reportID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrReportNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch report %d: %w", reportID, err)
+	}
+	return &report, nil
+}
+
+// GetReports lists reports oldest first; filters: status, target_type, target_id.
+func (r *PosModerationRepository) GetReports(ctx context.Context, pagination bootstrap.Pagination, filters map[string]interface{}) ([]models.Report, error) {
+	reports := []models.Report{}
+	args := []interface{}{}
+	conditions := []string{}
+	argPos := 1
+
+	if status, ok := filters["status"].(string); ok && status != "" {
+		conditions = append(conditions, fmt.Sprintf("status = $%d", argPos))
+		args = append(args, status)
+		argPos++
+	}
+
+	if targetType, ok := filters["target_type"].(string); ok && targetType != "" {
+		conditions = append(conditions, fmt.Sprintf("target_type = $%d", argPos))
+		args = append(args, targetType)
+		argPos++
+	}
+
+	if targetID, ok := filters["target_id"].(int); ok && targetID > 0 {
+		conditions = append(conditions, fmt.Sprintf("target_id = $%d", argPos))
+		args = append(args, targetID)
+		argPos++
+	}
+
+	whereClause := ""
+	if len(conditions) > 0 {
+		whereClause = "WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	offset := (pagination.Page - 1) * pagination.Limit
+	args = append(args, pagination.Limit, offset)
+
+	query := fmt.Sprintf(`SELECT `+reportColumns+` FROM reports %s ORDER BY created_at, id LIMIT $%d OFFSET $%d`,
+		whereClause, argPos, argPos+1)
+	if err := r.DB.SelectContext(ctx, &reports, query, args...); err != nil {
+		return nil, fmt.Errorf("failed to fetch reports: %w", err)
+	}
+	return reports, nil
+}
+
+// GetOpenReportWeight sums the credibility weights of the open reports on a target.
+func (r *PosModerationRepository) GetOpenReportWeight(ctx context.Context, targetType string, targetID int) (float64, error) {
+	var weight float64
+	query := `SELECT COALESCE(SUM(weight), 0) FROM reports WHERE target_type = $1 AND target_id = $2 AND status = 'open'`
+	if err := r.DB.GetContext(ctx, &weight, query, targetType, targetID); err != nil {
+		return 0, fmt.Errorf("failed to sum reports on %s %d: %w", targetType, targetID, err)
+	}
+	return weight, nil
+}
+
+func (r *PosModerationRepository) AddAuditEntry(ctx context.Context, entry *models.AuditEntry) error {
+	query := `
+        INSERT INTO moderation_audit_log (actor_id, action, target_type, target_id, details, created_at)
+        VALUES ($1, $2, $3, $4, $5, NOW())
+        RETURNING id, created_at
+    `
+	err := r.DB.QueryRowxContext(ctx, query, entry.ActorID, entry.Action, entry.TargetType, entry.TargetID, entry.Details).
+		Scan(&entry.ID, &entry.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to insert audit entry: %w", err)
+	}
+	return nil
+}
+
+// GetAuditLog lists audit entries newest first; filters: target_type, target_id, actor_id, action.
+func (r *PosModerationRepository) GetAuditLog(ctx context.Context, pagination bootstrap.Pagination, filters map[string]interface{}) ([]models.AuditEntry, error) {
+	entries := []models.AuditEntry{}
+	args := []interface{}{}
+	conditions := []string{}
+	argPos := 1
+
+	if targetType, ok := filters["target_type"].(string); ok && targetType != "" {
+		conditions = append(conditions, fmt.Sprintf("target_type = $%d", argPos))
+		args = append(args, targetType)
+		argPos++
+	}
+
+	if targetID, ok := filters["target_id"].(int); ok && targetID > 0 {
+		conditions = append(conditions, fmt.Sprintf("target_id = $%d", argPos))
+		args = append(args, targetID)
+		argPos++
+	}
+
+	if actorID, ok := filters["actor_id"].(int); ok && actorID > 0 {
+		conditions = append(conditions, fmt.Sprintf("actor_id = $%d", argPos))
+		args = append(args, actorID)
+		argPos++
+	}
+
+	if action, ok := filters["action"].(string); ok && action != "" {
+		conditions = append(conditions, fmt.Sprintf("action = $%d", argPos))
+		args = append(args, action)
+		argPos++
+	}
+
+	whereClause := ""
+	if len(conditions) > 0 {
+		whereClause = "WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	offset := (pagination.Page - 1) * pagination.Limit
+	args = append(args, pagination.Limit, offset)
+
+	query := fmt.Sprintf(`
+        SELECT id, actor_id, action, target_type, target_id, details, created_at
+        FROM moderation_audit_log
+        %s
+        ORDER BY created_at DESC, id DESC
+        LIMIT $%d OFFSET $%d
+    `, whereClause, argPos, argPos+1)
+	if err := r.DB.SelectContext(ctx, &entries, query, args...); err != nil {
+		return nil, fmt.Errorf("failed to fetch audit log: %w", err)
+	}
+	return entries, nil
+}
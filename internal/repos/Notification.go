@@ -0,0 +1,77 @@
+package repos
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+	"githup.ahmedramadan.4cashier/internal/bootstrap"
+	"githup.ahmedramadan.4cashier/internal/models"
+)
+
+// NotificationRepository stores in-app notifications.
+type NotificationRepository interface {
+	AddNotification(ctx context.Context, notification *models.Notification) (int, error)
+	GetNotifications(ctx context.Context, recipientType string, recipientID int, pagination bootstrap.Pagination) ([]models.Notification, error)
+	MarkNotificationRead(ctx context.Context, recipientType string, recipientID int, notificationID int) (int, error)
+}
+
+// PosNotificationRepository implements NotificationRepository for PostgreSQL.
+type PosNotificationRepository struct {
+	DB *sqlx.DB
+}
+
+// NewPosNotificationRepository creates a new instance of PosNotificationRepository.
+func NewPosNotificationRepository(db *sqlx.DB) NotificationRepository {
+	return &PosNotificationRepository{DB: db}
+}
+
+func (r *PosNotificationRepository) AddNotification(ctx context.Context, notification *models.Notification) (int, error) {
+	query := `
+        INSERT INTO notifications (recipient_type, recipient_id, kind, title, payload, created_at)
+        VALUES (:recipient_type, :recipient_id, :kind, :title, :payload, NOW())
+        RETURNING id
+    `
+	stmt, err := r.DB.PrepareNamedContext(ctx, query)
+	if err != nil {
+		return 0, fmt.Errorf("failed to prepare notification insert: %w", err)
+	}
+	defer stmt.Close()
+
+	if err := stmt.GetContext(ctx, &notification.ID, notification); err != nil {
+		return 0, fmt.Errorf("failed to insert notification: %w", err)
+	}
+	return notification.ID, nil
+}
+
+func (r *PosNotificationRepository) GetNotifications(ctx context.Context, recipientType string, recipientID int, pagination bootstrap.Pagination) ([]models.Notification, error) {
+	notifications := []models.Notification{}
+	query := `
+        SELECT id, recipient_type, recipient_id, kind, title, payload, read_at, created_at
+        FROM notifications
+        WHERE recipient_type = $1 AND recipient_id = $2
+        ORDER BY created_at DESC, id DESC
+        LIMIT $3 OFFSET $4
+    `
+	offset := (pagination.Page - 1) * pagination.Limit
+	if err := r.DB.SelectContext(ctx, &notifications, query, recipientType, recipientID, pagination.Limit, offset); err != nil {
+		return nil, fmt.Errorf("failed to fetch notifications: %w", err)
+	}
+	return notifications, nil
+}
+
+func (r *PosNotificationRepository) MarkNotificationRead(ctx context.Context, recipientType string, recipientID int, notificationID int) (int, error) {
+	query := `
+        UPDATE notifications SET read_at = COALESCE(read_at, NOW())
+        WHERE id = $1 AND recipient_type = $2 AND recipient_id = $3
+    `
+	result, err := r.DB.ExecContext(ctx, query, notificationID, recipientType, recipientID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to mark notification %d as read: %w", notificationID, err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	return int(rowsAffected), nil
+}
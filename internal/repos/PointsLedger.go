@@ -0,0 +1,192 @@
+package repos
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+	"githup.ahmedramadan.4cashier/internal/bootstrap"
+	"githup.ahmedramadan.4cashier/internal/models"
+)
+
+var (
+	ErrDuplicatePoints  = errors.New("points entry already recorded")
+	ErrEmployeeNotFound = errors.New("employee not found")
+)
+
+const pointsEntryColumns = `id, employee_id, delta, reason, source_type, source_id, idempotency_key, created_at`
+
+// =================================================================
+// 🪙 Points ledger (سجل النقاط)
+// =================================================================
+
+// AddPoints records an entry once per idempotency key and moves the cached
+// balance with it; a repeated key returns ErrDuplicatePoints.
+func (r *PosHRRepository) AddPoints(ctx context.Context, entry *models.PointsEntry) error {
+	tx, err := r.DB.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	recorded, err := insertPoints(ctx, tx, entry)
+	if err != nil {
+		return err
+	}
+	if !recorded {
+		return ErrDuplicatePoints
+	}
+	return tx.Commit()
+}
+
+// settleVotePoints brings the points tied to a rate in line with the vote
+// just written inside the caller's transaction: the voter holds points while
+// they like the rate, its author while it has at least points.HelpfulLikes
+// likes. The rate is locked by the vote, so the state read here is the
+// latest one. Balances are moved in employee order so that two votes
+// crediting the same pair of employees cannot deadlock.
+func settleVotePoints(ctx context.Context, tx *sqlx.Tx, voterID int, isLike bool, points models.RateVotePoints, result *models.RateVoteResult) error {
+	type award struct {
+		employeeID int
+		reason     string
+		target     int
+	}
+	awards := []award{
+		{voterID, models.PointsReasonRateVote, 0},
+		{result.AuthorID, models.PointsReasonHelpfulRate, 0},
+	}
+	if isLike {
+		awards[0].target = points.Voter
+	}
+	if result.LikesCount >= points.HelpfulLikes {
+		awards[1].target = points.HelpfulAuthor
+	}
+	if awards[1].employeeID < awards[0].employeeID {
+		awards[0], awards[1] = awards[1], awards[0]
+	}
+
+	for _, a := range awards {
+		moved, err := settleRatePoints(ctx, tx, a.employeeID, a.reason, result.RateID, a.target)
+		if err != nil {
+			return err
+		}
+		if moved && a.reason == models.PointsReasonHelpfulRate {
+			result.AuthorPoints = true
+		}
+	}
+	return nil
+}
+
+// settleRatePoints records the difference between what an employee holds for
+// one reason on a rate and target. Each change gets the next idempotency key
+// of that rate, so the same state is never settled twice.
+func settleRatePoints(ctx context.Context, tx *sqlx.Tx, employeeID int, reason string, rateID int, target int) (bool, error) {
+	sourceType := "rate"
+	var held, entries int
+	query := `
+        SELECT COALESCE(SUM(delta), 0), COUNT(*)
+        FROM points_ledger
+        WHERE employee_id = $1 AND reason = $2 AND source_type = $3 AND source_id = $4
+    `
+	if err := tx.QueryRowxContext(ctx, query, employeeID, reason, sourceType, rateID).Scan(&held, &entries); err != nil {
+		return false, fmt.Errorf("failed to read %s points of employee %d: %w", reason, employeeID, err)
+	}
+	if held == target {
+		return false, nil
+	}
+
+	entry := &models.PointsEntry{
+		EmployeeID:     employeeID,
+		Delta:          target - held,
+		Reason:         reason,
+		SourceType:     &sourceType,
+		SourceID:       &rateID,
+		IdempotencyKey: fmt.Sprintf("%s:%s:%d:%d:%d", reason, sourceType, rateID, employeeID, entries+1),
+	}
+	recorded, err := insertPoints(ctx, tx, entry)
+	if err != nil {
+		return false, err
+	}
+	if !recorded {
+		return false, ErrDuplicatePoints
+	}
+	return true, nil
+}
+
+// insertPoints inserts an entry and updates the balance inside the caller's
+// transaction. It reports false when the idempotency key is already used.
+func insertPoints(ctx context.Context, tx *sqlx.Tx, entry *models.PointsEntry) (bool, error) {
+	query := `
+        INSERT INTO points_ledger (employee_id, delta, reason, source_type, source_id, idempotency_key, created_at)
+        VALUES ($1, $2, $3, $4, $5, $6, NOW())
+        ON CONFLICT (idempotency_key) DO NOTHING
+        RETURNING id, created_at
+    `
+	err := tx.QueryRowxContext(ctx, query, entry.EmployeeID, entry.Delta, entry.Reason, entry.SourceType, entry.SourceID, entry.IdempotencyKey).
+		Scan(&entry.ID, &entry.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to record points of employee %d: %w", entry.EmployeeID, err)
+	}
+
+	res, err := tx.ExecContext(ctx, `UPDATE employees SET points_balance = points_balance + $2, updated_at = NOW() WHERE id = $1`,
+		entry.EmployeeID, entry.Delta)
+	if err != nil {
+		return false, fmt.Errorf("failed to update points balance of employee %d: %w", entry.EmployeeID, err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return false, ErrEmployeeNotFound
+	}
+	return true, nil
+}
+
+func (r *PosHRRepository) GetPointsBalance(ctx context.Context, employeeID int) (int, error) {
+	var balance int
+	err := r.DB.GetContext(ctx, &balance, `SELECT points_balance FROM employees WHERE id = $1`, employeeID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, ErrEmployeeNotFound
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to fetch points balance of employee %d: %w", employeeID, err)
+	}
+	return balance, nil
+}
+
+// GetPointsHistory returns the ledger of an employee, newest first.
+func (r *PosHRRepository) GetPointsHistory(ctx context.Context, employeeID int, pagination bootstrap.Pagination) ([]models.PointsEntry, error) {
+	entries := []models.PointsEntry{}
+	offset := (pagination.Page - 1) * pagination.Limit
+	query := `
+        SELECT ` + pointsEntryColumns + `
+        FROM points_ledger
+        WHERE employee_id = $1
+        ORDER BY created_at DESC, id DESC
+        LIMIT $2 OFFSET $3
+    `
+	if err := r.DB.SelectContext(ctx, &entries, query, employeeID, pagination.Limit, offset); err != nil {
+		return nil, fmt.Errorf("failed to fetch points history of employee %d: %w", employeeID, err)
+	}
+	return entries, nil
+}
+
+// RebuildPointsBalances recomputes every cached balance from the ledger and
+// returns how many were wrong.
+func (r *PosHRRepository) RebuildPointsBalances(ctx context.Context) (int, error) {
+	query := `
+        UPDATE employees e
+        SET points_balance = COALESCE(s.balance, 0), updated_at = NOW()
+        FROM employees e2
+        LEFT JOIN (SELECT employee_id, SUM(delta) AS balance FROM points_ledger GROUP BY employee_id) s ON s.employee_id = e2.id
+        WHERE e.id = e2.id AND e.points_balance <> COALESCE(s.balance, 0)
+    `
+	res, err := r.DB.ExecContext(ctx, query)
+	if err != nil {
+		return 0, fmt.Errorf("failed to rebuild points balances: %w", err)
+	}
+	n, _ := res.RowsAffected()
+	return int(n), nil
+}
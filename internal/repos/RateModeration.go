@@ -0,0 +1,220 @@
+package repos
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"githup.ahmedramadan.4cashier/internal/bootstrap"
+	"githup.ahmedramadan.4cashier/internal/models"
+)
+
+const moderationRateColumns = `id, hr_profile_id, employee_id, review_text, rate_value, rating_context, likes_count,
+        is_verified, verification_method, is_anonymous, created_at, updated_at, edited_at,
+        status, moderation_reasons, moderated_by, moderated_at,
+        host(client_ip) AS client_ip, device_fingerprint, risk_score, risk_signals, risk_flagged, risk_reviewed_at`
+
+// GetModerationRates lists live rates with the given moderation status, oldest
+// first so the queue is worked in order.
+func (r *PosHRRepository) GetModerationRates(ctx context.Context, status string, pagination bootstrap.Pagination) ([]models.Rate, error) {
+	rates := []models.Rate{}
+	query := `
+        SELECT ` + moderationRateColumns + `
+        FROM rates
+        WHERE status = $1 AND ` + liveRates("") + `
+        ORDER BY created_at, id
+        LIMIT $2 OFFSET $3
+    `
+	offset := (pagination.Page - 1) * pagination.Limit
+	if err := r.DB.SelectContext(ctx, &rates, query, status, pagination.Limit, offset); err != nil {
+		return nil, fmt.Errorf("failed to fetch %s rates: %w", status, err)
+	}
+	return rates, nil
+}
+
+// DecideRate changes the moderation status of a rate, appends the note to its
+// moderation reasons and recomputes the profile scores. moderatorID is nil for
+// automatic decisions (e.g. hidden by reports).
+func (r *PosHRRepository) DecideRate(ctx context.Context, rateID int, status string, moderatorID *int, note *string) (*models.Rate, error) {
+	tx, err := r.DB.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	rate, err := r.decideRate(ctx, tx, rateID, status, moderatorID, note)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return rate, nil
+}
+
+// decideRate is DecideRate inside the caller's transaction.
+func (r *PosHRRepository) decideRate(ctx context.Context, tx *sqlx.Tx, rateID int, status string, moderatorID *int, note *string) (*models.Rate, error) {
+	var rate models.Rate
+	query := `
+        UPDATE rates
+        SET status = $2,
+            moderated_by = $3,
+            moderated_at = NOW(),
+            moderation_reasons = CASE WHEN $4::text IS NULL THEN moderation_reasons
+                ELSE COALESCE(moderation_reasons, '[]'::jsonb)
+                     || jsonb_build_array(jsonb_build_object('check', CASE WHEN $3::int IS NULL THEN 'system' ELSE 'moderator' END, 'reason', $4::text, 'level', $2::text))
+                END,
+            updated_at = NOW()
+        WHERE id = $1 AND ` + liveRates("") + `
+        RETURNING ` + moderationRateColumns + `
+    `
+	err := tx.GetContext(ctx, &rate, query, rateID, status, moderatorID, note)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrRateNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to moderate rate %d: %w", rateID, err)
+	}
+
+	if _, err := r.recalcProfileRate(ctx, tx, rate.HRProfileID); err != nil {
+		return nil, err
+	}
+	return &rate, nil
+}
+
+// ResolveReportedItem applies a moderator's decision on a reported item in one
+// transaction: every open report on it is closed with the outcome, the
+// reporters' credibility moves by credibilityDelta, and the item follows. An
+// upheld report rejects a rate or hides a badge; a dismissed one puts back a
+// rate that reports had hidden. A missing item still closes its reports, and
+// ErrNoOpenReports means another decision got there first.
+func (r *PosHRRepository) ResolveReportedItem(ctx context.Context, targetType string, targetID int, uphold bool,
+	moderatorID int, note *string, credibilityDelta float32) (*models.ReportResolution, error) {
+	tx, err := r.DB.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// 1. إغلاق كل البلاغات المفتوحة على نفس العنصر (يقفلها أيضاً ضد قرار متزامن)
+	status := "dismissed"
+	if uphold {
+		status = "upheld"
+	}
+	resolution := &models.ReportResolution{Status: status, ReporterIDs: []int{}}
+	query := `
+        UPDATE reports
+        SET status = $3, resolved_by = $4, resolution_note = $5, resolved_at = NOW()
+        WHERE target_type = $1 AND target_id = $2 AND status = 'open'
+        RETURNING reporter_id
+    `
+	if err := tx.SelectContext(ctx, &resolution.ReporterIDs, query, targetType, targetID, status, moderatorID, note); err != nil {
+		return nil, fmt.Errorf("failed to resolve reports on %s %d: %w", targetType, targetID, err)
+	}
+	if len(resolution.ReporterIDs) == 0 {
+		return nil, ErrNoOpenReports
+	}
+
+	// 2. تنفيذ القرار على العنصر المُبلّغ عنه
+	switch targetType {
+	case "rate":
+		if resolution.Rate, err = r.decideReportedRate(ctx, tx, targetID, uphold, moderatorID, note); err != nil {
+			return nil, err
+		}
+	case "badge":
+		if uphold {
+			err := tx.GetContext(ctx, &resolution.BadgeHRProfileID, `
+                UPDATE badges SET hidden_at = NOW(), updated_at = NOW()
+                WHERE id = $1 AND hidden_at IS NULL
+                RETURNING hr_profile_id
+            `, targetID)
+			if err != nil && !errors.Is(err, sql.ErrNoRows) {
+				return nil, fmt.Errorf("failed to hide badge %d: %w", targetID, err)
+			}
+		}
+	}
+
+	// 3. تحديث مصداقية المُبلّغين
+	query = `
+        UPDATE employees
+        SET report_credibility = LEAST(3.0, GREATEST(0.1, report_credibility + $2)), updated_at = NOW()
+        WHERE id = ANY($1)
+    `
+	if _, err := tx.ExecContext(ctx, query, pq.Array(resolution.ReporterIDs), credibilityDelta); err != nil {
+		return nil, fmt.Errorf("failed to adjust reporter credibility: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit decision on %s %d: %w", targetType, targetID, err)
+	}
+	return resolution, nil
+}
+
+// decideReportedRate rejects a reported rate, or publishes again one that
+// reports had hidden (held by the system, not by a moderator). It returns the
+// rate when its status changed.
+func (r *PosHRRepository) decideReportedRate(ctx context.Context, tx *sqlx.Tx, rateID int, uphold bool, moderatorID int, note *string) (*models.Rate, error) {
+	var hiddenByReports bool
+	query := `
+        SELECT status = 'held' AND moderated_by IS NULL AND moderated_at IS NOT NULL
+        FROM rates
+        WHERE id = $1 AND ` + liveRates("") + `
+        FOR UPDATE
+    `
+	err := tx.GetContext(ctx, &hiddenByReports, query, rateID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock reported rate %d: %w", rateID, err)
+	}
+
+	status := "rejected"
+	if !uphold {
+		if !hiddenByReports {
+			return nil, nil
+		}
+		status = "published"
+	}
+	return r.decideRate(ctx, tx, rateID, status, &moderatorID, note)
+}
+
+// GetHeldResponses lists HR responses held by moderation, oldest first.
+func (r *PosHRRepository) GetHeldResponses(ctx context.Context, pagination bootstrap.Pagination) ([]models.HeldResponse, error) {
+	responses := []models.HeldResponse{}
+	query := `
+        SELECT id AS rate_id, hr_profile_id, hr_response, hr_response_moderation_reasons, hr_response_at
+        FROM rates
+        WHERE hr_response_status = 'held' AND ` + liveRates("") + `
+        ORDER BY hr_response_at, id
+        LIMIT $1 OFFSET $2
+    `
+	offset := (pagination.Page - 1) * pagination.Limit
+	if err := r.DB.SelectContext(ctx, &responses, query, pagination.Limit, offset); err != nil {
+		return nil, fmt.Errorf("failed to fetch held hr responses: %w", err)
+	}
+	return responses, nil
+}
+
+// DecideHRResponse publishes or rejects a held HR response and returns the rate.
+func (r *PosHRRepository) DecideHRResponse(ctx context.Context, rateID int, status string) (*models.Rate, error) {
+	var rate models.Rate
+	query := `
+        UPDATE rates
+        SET hr_response_status = $2
+        WHERE id = $1 AND hr_response_status = 'held' AND ` + liveRates("") + `
+        RETURNING ` + moderationRateColumns + `
+    `
+	err := r.DB.GetContext(ctx, &rate, query, rateID, status)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrRateNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to moderate hr response of rate %d: %w", rateID, err)
+	}
+	return &rate, nil
+}
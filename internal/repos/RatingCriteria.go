@@ -0,0 +1,313 @@
+package repos
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"githup.ahmedramadan.4cashier/internal/models"
+)
+
+var (
+	ErrUnknownCriterion   = errors.New("unknown or inactive rating criterion, or not used for this rating context")
+	ErrDuplicateCriterion = errors.New("a rating criterion is scored more than once")
+	ErrCriterionNotFound  = errors.New("rating criterion not found")
+	ErrContextNotFound    = errors.New("rating context not found")
+	ErrUnknownContextCode = errors.New("unknown rating context code")
+)
+
+// =================================================================
+// ⭐️ Rating Criteria (معايير التقييم)
+// =================================================================
+
+// GetRatingCriteria lists criteria; when contextCode is set, only the ones that
+// apply to that rating context (or to every context).
+func (r *PosHRRepository) GetRatingCriteria(ctx context.Context, activeOnly bool, contextCode string) ([]models.RatingCriterion, error) {
+	criteria := []models.RatingCriterion{}
+	query := `
+        SELECT id, code, name_en, name_ar, is_active, sort_order, context_codes, created_at, updated_at
+        FROM rating_criteria
+        WHERE ($1 = false OR is_active = true)
+          AND ($2 = '' OR cardinality(context_codes) = 0 OR $2 = ANY(context_codes))
+        ORDER BY sort_order, id
+    `
+	if err := r.DB.SelectContext(ctx, &criteria, query, activeOnly, contextCode); err != nil {
+		return nil, fmt.Errorf("failed to fetch rating criteria: %w", err)
+	}
+	return criteria, nil
+}
+
+func (r *PosHRRepository) CreateRatingCriterion(ctx context.Context, criterion *models.RatingCriterion) (int, error) {
+	tx, err := r.DB.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := checkContextCodes(ctx, tx, criterion.Contexts); err != nil {
+		return 0, err
+	}
+
+	query := `
+        INSERT INTO rating_criteria (code, name_en, name_ar, is_active, sort_order, context_codes, created_at, updated_at)
+        VALUES (:code, :name_en, :name_ar, :is_active, :sort_order, COALESCE(CAST(:context_codes AS TEXT[]), '{}'::text[]), NOW(), NOW())
+        RETURNING id
+    `
+	stmt, err := tx.PrepareNamedContext(ctx, query)
+	if err != nil {
+		return 0, fmt.Errorf("failed to prepare rating criterion insert: %w", err)
+	}
+	defer stmt.Close()
+
+	if err := stmt.GetContext(ctx, &criterion.ID, criterion); err != nil {
+		return 0, fmt.Errorf("failed to insert rating criterion: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit rating criterion: %w", err)
+	}
+	return criterion.ID, nil
+}
+
+func (r *PosHRRepository) UpdateRatingCriterion(ctx context.Context, criterion *models.RatingCriterion) error {
+	tx, err := r.DB.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := checkContextCodes(ctx, tx, criterion.Contexts); err != nil {
+		return err
+	}
+
+	query := `
+        UPDATE rating_criteria
+        SET code = :code, name_en = :name_en, name_ar = :name_ar,
+            is_active = :is_active, sort_order = :sort_order,
+            context_codes = COALESCE(CAST(:context_codes AS TEXT[]), '{}'::text[]), updated_at = NOW()
+        WHERE id = :id
+    `
+	res, err := tx.NamedExecContext(ctx, query, criterion)
+	if err != nil {
+		return fmt.Errorf("failed to update rating criterion %d: %w", criterion.ID, err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrCriterionNotFound
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit rating criterion %d: %w", criterion.ID, err)
+	}
+	return nil
+}
+
+// checkContextCodes makes sure every code names an existing rating context,
+// since context_codes has no foreign key. The contexts are locked until the
+// transaction ends so a concurrent rename cannot slip in between.
+func checkContextCodes(ctx context.Context, tx *sqlx.Tx, codes []string) error {
+	if len(codes) == 0 {
+		return nil
+	}
+	var known []string
+	query := `SELECT code FROM rating_contexts WHERE code = ANY($1) FOR SHARE`
+	if err := tx.SelectContext(ctx, &known, query, pq.Array(codes)); err != nil {
+		return fmt.Errorf("failed to check rating context codes: %w", err)
+	}
+	exists := map[string]bool{}
+	for _, code := range known {
+		exists[code] = true
+	}
+	for _, code := range codes {
+		if !exists[code] {
+			return fmt.Errorf("%w: %s", ErrUnknownContextCode, code)
+		}
+	}
+	return nil
+}
+
+// GetCriteriaAverages returns the per-criterion averages of an HR profile over
+// its live rates. Rates without a breakdown are simply not part of any criterion.
+func (r *PosHRRepository) GetCriteriaAverages(ctx context.Context, hrProfileID int) ([]models.CriterionAverage, error) {
+	averages := []models.CriterionAverage{}
+	query := `
+        SELECT c.id AS criterion_id, c.code, c.name_en, c.name_ar,
+               AVG(rs.score) AS average, COUNT(*) AS rates_count
+        FROM rate_scores rs
+        JOIN rates r ON r.id = rs.rate_id
+        JOIN rating_criteria c ON c.id = rs.criterion_id
+        WHERE r.hr_profile_id = $1 AND ` + countedRates("r") + `
+        GROUP BY c.id, c.code, c.name_en, c.name_ar, c.sort_order
+        ORDER BY c.sort_order, c.id
+    `
+	if err := r.DB.SelectContext(ctx, &averages, query, hrProfileID); err != nil {
+		return nil, fmt.Errorf("failed to fetch criteria averages for profile %d: %w", hrProfileID, err)
+	}
+	return averages, nil
+}
+
+// replaceRateScores stores the breakdown of a rate, replacing any previous one.
+// Scores are matched by criterion code (or id when no code is given) and must
+// belong to a criterion used for the rate's context. Nil scores keep the stored
+// breakdown, so an edit that does not send one does not wipe it.
+func replaceRateScores(ctx context.Context, tx *sqlx.Tx, rate *models.Rate) error {
+	if rate.Scores == nil {
+		return nil
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM rate_scores WHERE rate_id = $1`, rate.ID); err != nil {
+		return fmt.Errorf("failed to clear scores of rate %d: %w", rate.ID, err)
+	}
+
+	resolve := `
+        SELECT id FROM rating_criteria
+        WHERE is_active = true AND (code = $1 OR ($1 = '' AND id = $2))
+          AND (cardinality(context_codes) = 0 OR $3::text = ANY(context_codes))
+    `
+	// نفس المعيار قد يُرسل مرة بالكود ومرة بالرقم، فالتكرار يُفحص بعد معرفة الرقم
+	seen := map[int]bool{}
+	for i := range rate.Scores {
+		score := &rate.Scores[i]
+		err := tx.GetContext(ctx, &score.CriterionID, resolve, score.Code, score.CriterionID, rate.RatingContext)
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrUnknownCriterion
+		}
+		if err != nil {
+			return fmt.Errorf("failed to resolve criterion of rate %d: %w", rate.ID, err)
+		}
+		if seen[score.CriterionID] {
+			return ErrDuplicateCriterion
+		}
+		seen[score.CriterionID] = true
+
+		_, err = tx.ExecContext(ctx, `INSERT INTO rate_scores (rate_id, criterion_id, score) VALUES ($1, $2, $3)`,
+			rate.ID, score.CriterionID, score.Score)
+		if err != nil {
+			return fmt.Errorf("failed to store score of rate %d: %w", rate.ID, err)
+		}
+		score.RateID = rate.ID
+	}
+	return nil
+}
+
+// fetchRateScores loads the breakdown of the given rates keyed by rate id.
+func (r *PosHRRepository) fetchRateScores(ctx context.Context, rateIDs []int) (map[int][]models.RateScore, error) {
+	byRate := map[int][]models.RateScore{}
+	if len(rateIDs) == 0 {
+		return byRate, nil
+	}
+
+	var scores []models.RateScore
+	query := `
+        SELECT rs.rate_id, rs.criterion_id, c.code, rs.score
+        FROM rate_scores rs
+        JOIN rating_criteria c ON c.id = rs.criterion_id
+        WHERE rs.rate_id = ANY($1)
+        ORDER BY c.sort_order, c.id
+    `
+	if err := r.DB.SelectContext(ctx, &scores, query, pq.Array(rateIDs)); err != nil {
+		return nil, fmt.Errorf("failed to fetch rate scores: %w", err)
+	}
+	for _, score := range scores {
+		byRate[score.RateID] = append(byRate[score.RateID], score)
+	}
+	return byRate, nil
+}
+
+// =================================================================
+// ⭐️ Rating Contexts (سياقات التقييم)
+// =================================================================
+
+func (r *PosHRRepository) GetRatingContexts(ctx context.Context, activeOnly bool) ([]models.RatingContext, error) {
+	contexts := []models.RatingContext{}
+	query := `
+        SELECT id, code, name_en, name_ar, description, is_active, sort_order, created_at, updated_at
+        FROM rating_contexts
+        WHERE ($1 = false OR is_active = true)
+        ORDER BY sort_order, id
+    `
+	if err := r.DB.SelectContext(ctx, &contexts, query, activeOnly); err != nil {
+		return nil, fmt.Errorf("failed to fetch rating contexts: %w", err)
+	}
+	return contexts, nil
+}
+
+func (r *PosHRRepository) CreateRatingContext(ctx context.Context, ratingContext *models.RatingContext) (int, error) {
+	query := `
+        INSERT INTO rating_contexts (code, name_en, name_ar, description, is_active, sort_order, created_at, updated_at)
+        VALUES (:code, :name_en, :name_ar, :description, :is_active, :sort_order, NOW(), NOW())
+        RETURNING id
+    `
+	stmt, err := r.DB.PrepareNamedContext(ctx, query)
+	if err != nil {
+		return 0, fmt.Errorf("failed to prepare rating context insert: %w", err)
+	}
+	defer stmt.Close()
+
+	if err := stmt.GetContext(ctx, &ratingContext.ID, ratingContext); err != nil {
+		return 0, fmt.Errorf("failed to insert rating context: %w", err)
+	}
+	return ratingContext.ID, nil
+}
+
+// UpdateRatingContext edits a context; a new code is propagated to the rates
+// by the foreign key (ON UPDATE CASCADE) and to the criteria's context codes here.
+func (r *PosHRRepository) UpdateRatingContext(ctx context.Context, ratingContext *models.RatingContext) error {
+	tx, err := r.DB.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var oldCode string
+	err = tx.GetContext(ctx, &oldCode, `SELECT code FROM rating_contexts WHERE id = $1 FOR UPDATE`, ratingContext.ID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrContextNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to lock rating context %d: %w", ratingContext.ID, err)
+	}
+
+	query := `
+        UPDATE rating_contexts
+        SET code = :code, name_en = :name_en, name_ar = :name_ar, description = :description,
+            is_active = :is_active, sort_order = :sort_order, updated_at = NOW()
+        WHERE id = :id
+    `
+	if _, err := tx.NamedExecContext(ctx, query, ratingContext); err != nil {
+		return fmt.Errorf("failed to update rating context %d: %w", ratingContext.ID, err)
+	}
+
+	// context_codes ليس مفتاحاً أجنبياً، فتغيير الكود يُنقل للمعايير يدوياً
+	if ratingContext.Code != oldCode {
+		_, err = tx.ExecContext(ctx, `
+            UPDATE rating_criteria
+            SET context_codes = array_replace(context_codes, $1, $2), updated_at = NOW()
+            WHERE $1 = ANY(context_codes)
+        `, oldCode, ratingContext.Code)
+		if err != nil {
+			return fmt.Errorf("failed to rename context %s in rating criteria: %w", oldCode, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit rating context %d: %w", ratingContext.ID, err)
+	}
+	return nil
+}
+
+// GetContextAverages returns the average of an HR profile per rating context.
+func (r *PosHRRepository) GetContextAverages(ctx context.Context, hrProfileID int) ([]models.ContextAverage, error) {
+	averages := []models.ContextAverage{}
+	query := `
+        SELECT c.code, c.name_en, c.name_ar, AVG(r.rate_value) AS average, COUNT(*) AS rates_count
+        FROM rates r
+        JOIN rating_contexts c ON c.code = r.rating_context
+        WHERE r.hr_profile_id = $1 AND ` + countedRates("r") + `
+        GROUP BY c.code, c.name_en, c.name_ar, c.sort_order
+        ORDER BY c.sort_order, c.code
+    `
+	if err := r.DB.SelectContext(ctx, &averages, query, hrProfileID); err != nil {
+		return nil, fmt.Errorf("failed to fetch context averages for profile %d: %w", hrProfileID, err)
+	}
+	return averages, nil
+}
@@ -0,0 +1,109 @@
+package repos
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"githup.ahmedramadan.4cashier/internal/models"
+)
+
+// =================================================================
+// 📊 Rating Summary (توزيع النجوم واتجاه التقييم)
+// =================================================================
+
+// GetRatingSummary builds the histogram, monthly trend, recent window and
+// position percentile of a profile in one read-only snapshot. Every part reads
+// countedRates, the same set recalcProfileRate averages into hr_profiles.rate.
+// Returns sql.ErrNoRows when the profile does not exist.
+func (r *PosHRRepository) GetRatingSummary(ctx context.Context, hrProfileID int, months int, windowDays int) (*models.RatingSummary, error) {
+	tx, err := r.DB.BeginTxx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	summary := &models.RatingSummary{HRProfileID: hrProfileID}
+
+	// 1. Lifetime (straight from hr_profiles) vs. the recent window
+	periodQuery := `
+        SELECT p.rate AS lifetime_average, p.total_rates_count AS lifetime_count,
+               s.recent_average, s.recent_count
+        FROM hr_profiles p
+        CROSS JOIN LATERAL (
+            SELECT AVG(rate_value) AS recent_average, COUNT(*) AS recent_count
+            FROM rates
+            WHERE hr_profile_id = p.id AND ` + countedRates("") + `
+              AND created_at >= NOW() - make_interval(days => $2)
+        ) s
+        WHERE p.id = $1
+    `
+	if err := tx.GetContext(ctx, &summary.Recent, periodQuery, hrProfileID, windowDays); err != nil {
+		return nil, fmt.Errorf("failed to fetch rating periods for profile %d: %w", hrProfileID, err)
+	}
+	summary.Recent.WindowDays = windowDays
+	summary.Rate = summary.Recent.LifetimeAverage
+	summary.TotalRatesCount = summary.Recent.LifetimeCount
+
+	// 2. 1-5 star histogram, every bucket present even when empty
+	summary.Distribution = []models.StarBucket{}
+	distributionQuery := `
+        WITH buckets AS (
+            SELECT LEAST(GREATEST(ROUND(rate_value::numeric)::int, 1), 5) AS stars, COUNT(*) AS rates_count
+            FROM rates
+            WHERE hr_profile_id = $1 AND ` + countedRates("") + `
+            GROUP BY 1
+        )
+        SELECT s.stars,
+               COALESCE(b.rates_count, 0) AS rates_count,
+               COALESCE(b.rates_count::real / NULLIF(SUM(b.rates_count) OVER (), 0), 0) AS share
+        FROM generate_series(1, 5) AS s(stars)
+        LEFT JOIN buckets b ON b.stars = s.stars
+        ORDER BY s.stars DESC
+    `
+	if err := tx.SelectContext(ctx, &summary.Distribution, distributionQuery, hrProfileID); err != nil {
+		return nil, fmt.Errorf("failed to fetch rating distribution for profile %d: %w", hrProfileID, err)
+	}
+
+	// 3. Monthly averages for the last N months; months without rates are left out
+	summary.Monthly = []models.MonthlyAverage{}
+	monthlyQuery := `
+        SELECT to_char(date_trunc('month', created_at), 'YYYY-MM') AS month,
+               AVG(rate_value) AS average, COUNT(*) AS rates_count
+        FROM rates
+        WHERE hr_profile_id = $1 AND ` + countedRates("") + `
+          AND created_at >= date_trunc('month', NOW()) - make_interval(months => $2 - 1)
+        GROUP BY date_trunc('month', created_at)
+        ORDER BY date_trunc('month', created_at)
+    `
+	if err := tx.SelectContext(ctx, &summary.Monthly, monthlyQuery, hrProfileID, months); err != nil {
+		return nil, fmt.Errorf("failed to fetch monthly averages for profile %d: %w", hrProfileID, err)
+	}
+
+	// 4. Percentile among rated profiles with the same job position
+	var position models.PositionPercentile
+	positionQuery := `
+        WITH me AS (
+            SELECT job_position, rate
+            FROM hr_profiles
+            WHERE id = $1 AND job_position IS NOT NULL AND rate IS NOT NULL AND total_rates_count > 0
+        )
+        SELECT me.job_position,
+               COUNT(*) AS profiles_count,
+               100.0 * COUNT(*) FILTER (WHERE p.rate < me.rate) / (COUNT(*) - 1) AS percentile
+        FROM me
+        JOIN hr_profiles p ON p.job_position = me.job_position AND p.rate IS NOT NULL AND p.total_rates_count > 0
+        GROUP BY me.job_position
+        HAVING COUNT(*) > 1
+    `
+	err = tx.GetContext(ctx, &position, positionQuery, hrProfileID)
+	switch {
+	case err == nil:
+		summary.Position = &position
+	case !errors.Is(err, sql.ErrNoRows):
+		return nil, fmt.Errorf("failed to fetch position percentile for profile %d: %w", hrProfileID, err)
+	}
+
+	return summary, nil
+}
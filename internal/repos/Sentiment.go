@@ -0,0 +1,135 @@
+package repos
+
+import (
+	"context"
+	"fmt"
+
+	"githup.ahmedramadan.4cashier/internal/bootstrap"
+	"githup.ahmedramadan.4cashier/internal/models"
+)
+
+// aspectSentimentBand is the |score| from which an aspect counts as praised or
+// criticized; it matches the neutral band of the sentiment analyzer.
+const aspectSentimentBand = 0.1
+
+// rateAspectsJSON selects the aspects of a rate as a JSON array.
+func rateAspectsJSON(alias string) string {
+	return `COALESCE((
+                SELECT jsonb_agg(jsonb_build_object('aspect', ra.aspect, 'score', ra.score, 'mentions', ra.mentions) ORDER BY ra.aspect)
+                FROM rate_aspects ra WHERE ra.rate_id = ` + alias + `.id
+            ), '[]'::jsonb)`
+}
+
+// =================================================================
+// 💬 Sentiment & Aspects (تحليل المشاعر والجوانب)
+// =================================================================
+
+// SetRateSentiment stores the analysis of a rate and replaces its aspects.
+func (r *PosHRRepository) SetRateSentiment(ctx context.Context, rateID int, score float32, label string, contradiction bool, version int, aspects []models.RateAspect) error {
+	tx, err := r.DB.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `
+        UPDATE rates
+        SET sentiment_score = $2, sentiment_label = $3, sentiment_contradiction = $4,
+            sentiment_version = $5, sentiment_analyzed_at = NOW()
+        WHERE id = $1
+    `
+	res, err := tx.ExecContext(ctx, query, rateID, score, label, contradiction, version)
+	if err != nil {
+		return fmt.Errorf("failed to store sentiment of rate %d: %w", rateID, err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrRateNotFound
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM rate_aspects WHERE rate_id = $1`, rateID); err != nil {
+		return fmt.Errorf("failed to clear aspects of rate %d: %w", rateID, err)
+	}
+	for _, aspect := range aspects {
+		_, err := tx.ExecContext(ctx,
+			`INSERT INTO rate_aspects (rate_id, aspect, score, mentions) VALUES ($1, $2, $3, $4)`,
+			rateID, aspect.Aspect, aspect.Score, aspect.Mentions)
+		if err != nil {
+			return fmt.Errorf("failed to store aspect %s of rate %d: %w", aspect.Aspect, rateID, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// GetRatesForSentiment returns the next batch of live rates after afterID that
+// were not analyzed with the given version (every rate when all is set).
+func (r *PosHRRepository) GetRatesForSentiment(ctx context.Context, version int, all bool, afterID int, limit int) ([]models.Rate, error) {
+	rates := []models.Rate{}
+	query := `
+        SELECT id, review_text, rate_value
+        FROM rates
+        WHERE id > $1 AND ` + liveRates("") + `
+          AND ($3 OR sentiment_version IS DISTINCT FROM $2)
+        ORDER BY id
+        LIMIT $4
+    `
+	if err := r.DB.SelectContext(ctx, &rates, query, afterID, version, all, limit); err != nil {
+		return nil, fmt.Errorf("failed to fetch rates for sentiment analysis: %w", err)
+	}
+	return rates, nil
+}
+
+// GetAspectAverages returns how the counted rates of a profile talk about each aspect.
+func (r *PosHRRepository) GetAspectAverages(ctx context.Context, hrProfileID int) ([]models.AspectAverage, error) {
+	averages := []models.AspectAverage{}
+	query := `
+        SELECT ra.aspect, AVG(ra.score) AS average, COUNT(*) AS rates_count,
+               COUNT(*) FILTER (WHERE ra.score >= $2) AS positive_count,
+               COUNT(*) FILTER (WHERE ra.score <= -$2) AS negative_count
+        FROM rate_aspects ra
+        JOIN rates r ON r.id = ra.rate_id
+        WHERE r.hr_profile_id = $1 AND ` + countedRates("r") + `
+        GROUP BY ra.aspect
+        ORDER BY ra.aspect
+    `
+	if err := r.DB.SelectContext(ctx, &averages, query, hrProfileID, aspectSentimentBand); err != nil {
+		return nil, fmt.Errorf("failed to fetch aspect averages for profile %d: %w", hrProfileID, err)
+	}
+	return averages, nil
+}
+
+// GetAspectLeaders ranks profiles by the average sentiment of an aspect:
+// most praised first, or most criticized first when criticized is set. Only
+// profiles with at least minRates reviews mentioning the aspect are ranked.
+func (r *PosHRRepository) GetAspectLeaders(ctx context.Context, aspect string, criticized bool, minRates int, pagination bootstrap.Pagination) ([]models.AspectLeader, error) {
+	leaders := []models.AspectLeader{}
+	order := "DESC"
+	if criticized {
+		order = "ASC"
+	}
+	query := `
+        SELECT p.id AS hr_profile_id, p.name, p.image, p.company_name, p.job_position, p.rate,
+               a.aspect, a.average, a.rates_count, a.positive_count, a.negative_count
+        FROM (
+            SELECT r.hr_profile_id, ra.aspect, AVG(ra.score) AS average, COUNT(*) AS rates_count,
+                   COUNT(*) FILTER (WHERE ra.score >= $2) AS positive_count,
+                   COUNT(*) FILTER (WHERE ra.score <= -$2) AS negative_count
+            FROM rate_aspects ra
+            JOIN rates r ON r.id = ra.rate_id
+            WHERE ra.aspect = $1 AND ` + countedRates("r") + `
+            GROUP BY r.hr_profile_id, ra.aspect
+            HAVING COUNT(*) >= $3
+        ) a
+        JOIN hr_profiles p ON p.id = a.hr_profile_id
+        ORDER BY a.average ` + order + `, a.rates_count DESC, p.id
+        LIMIT $4 OFFSET $5
+    `
+	offset := (pagination.Page - 1) * pagination.Limit
+	if err := r.DB.SelectContext(ctx, &leaders, query, aspect, aspectSentimentBand, minRates, pagination.Limit, offset); err != nil {
+		return nil, fmt.Errorf("failed to rank profiles by aspect %s: %w", aspect, err)
+	}
+	return leaders, nil
+}
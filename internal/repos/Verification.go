@@ -0,0 +1,322 @@
+package repos
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"githup.ahmedramadan.4cashier/internal/bootstrap"
+	"githup.ahmedramadan.4cashier/internal/models"
+)
+
+var (
+	ErrVerificationNotFound = errors.New("verification request not found")
+	ErrInviteTokenInvalid   = errors.New("invite token is invalid, expired or used up")
+	ErrWorkEmailTaken       = errors.New("work email is already verified by another account")
+)
+
+// VerificationRepository stores proof-of-employment requests for rates.
+type VerificationRepository interface {
+	CreateVerification(ctx context.Context, verification *models.RateVerification) (int, error)
+	GetVerification(ctx context.Context, verificationID int) (*models.RateVerification, error)
+	GetPendingEmailVerification(ctx context.Context, rateID int) (*models.RateVerification, error)
+	IncrementVerificationAttempts(ctx context.Context, verificationID int) error
+	GetEmailCodeUsage(ctx context.Context, rateID int, since time.Time) (issued int, attempts int, err error)
+	GetWorkEmailOwner(ctx context.Context, email string) (int, error)
+	GetVerifications(ctx context.Context, status string, pagination bootstrap.Pagination) ([]models.RateVerification, error)
+	CompleteVerification(ctx context.Context, verificationID int, approved bool, reviewerID *int, note *string) error
+	RedeemInviteToken(ctx context.Context, tokenHash string, hrProfileID int, verification *models.RateVerification) error
+
+	CreateInviteToken(ctx context.Context, token *models.HRInviteToken) (int, error)
+	GetInviteTokens(ctx context.Context, hrProfileID int) ([]models.HRInviteToken, error)
+	RevokeInviteToken(ctx context.Context, hrProfileID int, tokenID int) (int, error)
+}
+
+// PosVerificationRepository implements VerificationRepository for PostgreSQL.
+type PosVerificationRepository struct {
+	DB *sqlx.DB
+}
+
+// NewPosVerificationRepository creates a new instance of PosVerificationRepository.
+func NewPosVerificationRepository(db *sqlx.DB) VerificationRepository {
+	return &PosVerificationRepository{DB: db}
+}
+
+const verificationColumns = `id, rate_id, employee_id, method, status, email, code_hash, attempts, document_path,
+        invite_token_id, expires_at, reviewed_by, review_note, reviewed_at, verified_at, created_at`
+
+func insertVerification(ctx context.Context, q sqlx.ExtContext, verification *models.RateVerification) error {
+	query := `
+        INSERT INTO rate_verifications (rate_id, employee_id, method, status, email, code_hash, attempts, document_path,
+            invite_token_id, expires_at, verified_at, created_at)
+        VALUES (:rate_id, :employee_id, :method, :status, :email, :code_hash, :attempts, :document_path,
+            :invite_token_id, :expires_at, :verified_at, NOW())
+        RETURNING id
+    `
+	rows, err := sqlx.NamedQueryContext(ctx, q, query, verification)
+	if err != nil {
+		return fmt.Errorf("failed to insert rate verification: %w", err)
+	}
+	defer rows.Close()
+
+	if rows.Next() {
+		if err := rows.Scan(&verification.ID); err != nil {
+			return fmt.Errorf("failed to scan rate verification ID: %w", err)
+		}
+	}
+	return rows.Err()
+}
+
+func (r *PosVerificationRepository) CreateVerification(ctx context.Context, verification *models.RateVerification) (int, error) {
+	if err := insertVerification(ctx, r.DB, verification); err != nil {
+		return 0, err
+	}
+	return verification.ID, nil
+}
+
+func (r *PosVerificationRepository) GetVerification(ctx context.Context, verificationID int) (*models.RateVerification, error) {
+	var verification models.RateVerification
+	err := r.DB.GetContext(ctx, &verification, `SELECT `+verificationColumns+` FROM rate_verifications WHERE id = $1`, verificationID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrVerificationNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch verification %d: %w", verificationID, err)
+	}
+	return &verification, nil
+}
+
+// GetPendingEmailVerification returns the latest unexpired work-email request of a rate.
+func (r *PosVerificationRepository) GetPendingEmailVerification(ctx context.Context, rateID int) (*models.RateVerification, error) {
+	var verification models.RateVerification
+	query := `
+        SELECT ` + verificationColumns + `
+        FROM rate_verifications
+        WHERE rate_id = $1 AND method = 'work_email' AND status = 'pending' AND expires_at > NOW()
+        ORDER BY created_at DESC
+        LIMIT 1
+    `
+	err := r.DB.GetContext(ctx, &verification, query, rateID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrVerificationNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch pending email verification of rate %d: %w", rateID, err)
+	}
+	return &verification, nil
+}
+
+func (r *PosVerificationRepository) IncrementVerificationAttempts(ctx context.Context, verificationID int) error {
+	_, err := r.DB.ExecContext(ctx, `UPDATE rate_verifications SET attempts = attempts + 1 WHERE id = $1`, verificationID)
+	if err != nil {
+		return fmt.Errorf("failed to count attempt of verification %d: %w", verificationID, err)
+	}
+	return nil
+}
+
+// GetEmailCodeUsage counts the work-email codes issued for a rate since a time
+// and the failed attempts made on them. Each code starts with the attempts of
+// the previous one, so the highest count is the total.
+func (r *PosVerificationRepository) GetEmailCodeUsage(ctx context.Context, rateID int, since time.Time) (int, int, error) {
+	var usage struct {
+		Issued   int `db:"issued"`
+		Attempts int `db:"attempts"`
+	}
+	query := `
+        SELECT COUNT(*) AS issued, COALESCE(MAX(attempts), 0) AS attempts
+        FROM rate_verifications
+        WHERE rate_id = $1 AND method = 'work_email' AND created_at > $2
+    `
+	if err := r.DB.GetContext(ctx, &usage, query, rateID, since); err != nil {
+		return 0, 0, fmt.Errorf("failed to count email codes of rate %d: %w", rateID, err)
+	}
+	return usage.Issued, usage.Attempts, nil
+}
+
+// GetWorkEmailOwner returns the employee a work email is verified for, or 0
+// when it is not verified yet.
+func (r *PosVerificationRepository) GetWorkEmailOwner(ctx context.Context, email string) (int, error) {
+	var employeeID int
+	err := r.DB.GetContext(ctx, &employeeID, `SELECT employee_id FROM verified_work_emails WHERE email = lower($1)`, email)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to fetch owner of work email: %w", err)
+	}
+	return employeeID, nil
+}
+
+// claimWorkEmail binds a verified work email to the employee, failing when
+// another employee verified it first.
+func claimWorkEmail(ctx context.Context, tx *sqlx.Tx, email string, employeeID int) error {
+	var owner int
+	query := `
+        INSERT INTO verified_work_emails (email, employee_id) VALUES (lower($1), $2)
+        ON CONFLICT (email) DO UPDATE SET email = EXCLUDED.email
+        RETURNING employee_id
+    `
+	if err := tx.GetContext(ctx, &owner, query, email, employeeID); err != nil {
+		return fmt.Errorf("failed to claim work email: %w", err)
+	}
+	if owner != employeeID {
+		return ErrWorkEmailTaken
+	}
+	return nil
+}
+
+func (r *PosVerificationRepository) GetVerifications(ctx context.Context, status string, pagination bootstrap.Pagination) ([]models.RateVerification, error) {
+	verifications := []models.RateVerification{}
+	query := `
+        SELECT ` + verificationColumns + `
+        FROM rate_verifications
+        WHERE method = 'document' AND ($1 = '' OR status = $1)
+        ORDER BY created_at ASC
+        LIMIT $2 OFFSET $3
+    `
+	offset := (pagination.Page - 1) * pagination.Limit
+	if err := r.DB.SelectContext(ctx, &verifications, query, status, pagination.Limit, offset); err != nil {
+		return nil, fmt.Errorf("failed to fetch verifications: %w", err)
+	}
+	return verifications, nil
+}
+
+// markRateVerified flags the rate as verified with the given method.
+func markRateVerified(ctx context.Context, tx *sqlx.Tx, rateID int, method string) error {
+	_, err := tx.ExecContext(ctx, `
+        UPDATE rates SET is_verified = true, verification_method = $1, updated_at = NOW()
+        WHERE id = $2
+    `, method, rateID)
+	if err != nil {
+		return fmt.Errorf("failed to mark rate %d as verified: %w", rateID, err)
+	}
+	return nil
+}
+
+// CompleteVerification approves or rejects a pending request; an approval also
+// marks the rate as verified, atomically.
+func (r *PosVerificationRepository) CompleteVerification(ctx context.Context, verificationID int, approved bool, reviewerID *int, note *string) error {
+	tx, err := r.DB.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	status := "rejected"
+	if approved {
+		status = "verified"
+	}
+
+	var verification models.RateVerification
+	query := `
+        UPDATE rate_verifications
+        SET status = $1, reviewed_by = $2, review_note = $3,
+            reviewed_at = CASE WHEN $2::int IS NULL THEN NULL ELSE NOW() END,
+            verified_at = CASE WHEN $1 = 'verified' THEN NOW() END
+        WHERE id = $4 AND status = 'pending'
+        RETURNING rate_id, employee_id, method, email
+    `
+	err = tx.QueryRowxContext(ctx, query, status, reviewerID, note, verificationID).
+		Scan(&verification.RateID, &verification.EmployeeID, &verification.Method, &verification.Email)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrVerificationNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to complete verification %d: %w", verificationID, err)
+	}
+
+	if approved {
+		if verification.Method == models.VerificationWorkEmail && verification.Email != nil {
+			if err := claimWorkEmail(ctx, tx, *verification.Email, verification.EmployeeID); err != nil {
+				return err
+			}
+		}
+		if err := markRateVerified(ctx, tx, verification.RateID, verification.Method); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// RedeemInviteToken consumes one use of an HR invite token and records the
+// rate as verified by it, atomically.
+func (r *PosVerificationRepository) RedeemInviteToken(ctx context.Context, tokenHash string, hrProfileID int, verification *models.RateVerification) error {
+	tx, err := r.DB.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var tokenID int
+	query := `
+        UPDATE hr_invite_tokens
+        SET used_count = used_count + 1
+        WHERE token_hash = $1 AND hr_profile_id = $2 AND revoked_at IS NULL
+          AND used_count < max_uses AND (expires_at IS NULL OR expires_at > NOW())
+        RETURNING id
+    `
+	err = tx.GetContext(ctx, &tokenID, query, tokenHash, hrProfileID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrInviteTokenInvalid
+	}
+	if err != nil {
+		return fmt.Errorf("failed to redeem invite token: %w", err)
+	}
+
+	verification.InviteTokenID = &tokenID
+	if err := insertVerification(ctx, tx, verification); err != nil {
+		return err
+	}
+	if err := markRateVerified(ctx, tx, verification.RateID, verification.Method); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *PosVerificationRepository) CreateInviteToken(ctx context.Context, token *models.HRInviteToken) (int, error) {
+	query := `
+        INSERT INTO hr_invite_tokens (hr_profile_id, token_hash, max_uses, expires_at, created_at)
+        VALUES ($1, $2, $3, $4, NOW())
+        RETURNING id, created_at
+    `
+	err := r.DB.QueryRowxContext(ctx, query, token.HRProfileID, token.TokenHash, token.MaxUses, token.ExpiresAt).
+		Scan(&token.ID, &token.CreatedAt)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create invite token: %w", err)
+	}
+	return token.ID, nil
+}
+
+func (r *PosVerificationRepository) GetInviteTokens(ctx context.Context, hrProfileID int) ([]models.HRInviteToken, error) {
+	tokens := []models.HRInviteToken{}
+	query := `
+        SELECT id, hr_profile_id, token_hash, max_uses, used_count, expires_at, revoked_at, created_at
+        FROM hr_invite_tokens
+        WHERE hr_profile_id = $1
+        ORDER BY created_at DESC
+    `
+	if err := r.DB.SelectContext(ctx, &tokens, query, hrProfileID); err != nil {
+		return nil, fmt.Errorf("failed to fetch invite tokens: %w", err)
+	}
+	return tokens, nil
+}
+
+func (r *PosVerificationRepository) RevokeInviteToken(ctx context.Context, hrProfileID int, tokenID int) (int, error) {
+	result, err := r.DB.ExecContext(ctx, `
+        UPDATE hr_invite_tokens SET revoked_at = NOW()
+        WHERE id = $1 AND hr_profile_id = $2 AND revoked_at IS NULL
+    `, tokenID, hrProfileID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to revoke invite token %d: %w", tokenID, err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	return int(rowsAffected), nil
+}
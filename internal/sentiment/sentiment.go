@@ -0,0 +1,349 @@
+// Package sentiment scores review text and detects the aspects it talks about
+// (salary, communication, respect, delays) with Arabic and English lexicons
+// shipped inside the binary; no external service is called.
+//
+// The text is split into clauses at punctuation and contrast words ("but",
+// "لكن"). Each clause sums the polarity of its terms, after negators and
+// intensifiers, and carries that sum to every aspect it mentions.
+package sentiment
+
+import (
+	"bufio"
+	"embed"
+	"fmt"
+	"math"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"githup.ahmedramadan.4cashier/internal/moderation"
+)
+
+// Version identifies the lexicons and rules. Bump it whenever they change so
+// the backfill job re-analyzes the stored rates.
+const Version = 1
+
+// Labels of an analysis.
+const (
+	LabelPositive = "positive"
+	LabelNegative = "negative"
+	LabelNeutral  = "neutral"
+)
+
+const (
+	// neutralBand is the |score| below which a text is neutral.
+	neutralBand = 0.1
+	// contradictionScore is the |score| a text needs before it can contradict
+	// its star rating.
+	contradictionScore = 0.4
+	// normalizeAlpha maps raw sums to -1..1 (x / sqrt(x² + alpha)).
+	normalizeAlpha = 15
+	// negationFactor flips and dampens a negated term: "not bad" is mildly good.
+	negationFactor = -0.75
+	// negationWindow is how many words before a term a negator still applies.
+	negationWindow = 2
+)
+
+//go:embed lexicon/*.txt
+var lexiconFS embed.FS
+
+// AspectScore is the sentiment of the clauses mentioning one aspect.
+type AspectScore struct {
+	Aspect   string  `json:"aspect"`
+	Score    float32 `json:"score"` // -1..1
+	Mentions int     `json:"mentions"`
+}
+
+// Analysis is the result of analyzing one text.
+type Analysis struct {
+	Score   float32       `json:"score"` // -1..1
+	Label   string        `json:"label"`
+	Aspects []AspectScore `json:"aspects"`
+}
+
+// Contradicts reports whether the text clearly disagrees with the star
+// rating: a 4-5 star rate with a clearly negative text or a 0-2 star rate
+// with a clearly positive one.
+func (a Analysis) Contradicts(rateValue float32) bool {
+	switch {
+	case rateValue >= 4:
+		return a.Score <= -contradictionScore
+	case rateValue <= 2:
+		return a.Score >= contradictionScore
+	}
+	return false
+}
+
+// Analyzer holds the loaded lexicons; it is safe for concurrent use.
+type Analyzer struct {
+	polarity     map[string]float64
+	aspects      map[string][]string // term -> aspects
+	aspectOrder  []string
+	negators     map[string]bool
+	intensifiers map[string]float64
+	contrasts    map[string]bool
+	maxPhrase    int // longest term, in words
+}
+
+var clauseSeparator = regexp.MustCompile(`[.!?؟،,;:؛\n]+`)
+
+// Arabic attaches the article and conjunctions to the word: "والراتب" -> "راتب".
+var arabicPrefixes = []string{"وال", "بال", "فال", "لل", "ال", "و", "ب", "ف", "ل"}
+
+func NewAnalyzer() *Analyzer {
+	a := &Analyzer{
+		polarity:     map[string]float64{},
+		aspects:      map[string][]string{},
+		negators:     map[string]bool{},
+		intensifiers: map[string]float64{},
+		contrasts:    map[string]bool{},
+		maxPhrase:    1,
+	}
+
+	for _, file := range []string{"polarity_en.txt", "polarity_ar.txt"} {
+		for _, fields := range loadLexicon(file) {
+			term, score := splitScore(file, fields)
+			a.polarity[a.addTerm(term)] = score
+		}
+	}
+
+	for _, fields := range loadLexicon("aspects.txt") {
+		aspect, term := fields[0], a.addTerm(strings.Join(fields[1:], " "))
+		if !slices.Contains(a.aspectOrder, aspect) {
+			a.aspectOrder = append(a.aspectOrder, aspect)
+		}
+		a.aspects[term] = append(a.aspects[term], aspect)
+	}
+
+	for _, fields := range loadLexicon("modifiers.txt") {
+		switch fields[0] {
+		case "negator":
+			a.negators[fields[1]] = true
+		case "contrast":
+			a.contrasts[fields[1]] = true
+		case "intensifier":
+			_, factor := splitScore("modifiers.txt", fields[1:])
+			a.intensifiers[fields[1]] = factor
+		default:
+			panic(fmt.Sprintf("sentiment: unknown modifier %q", fields[0]))
+		}
+	}
+	return a
+}
+
+// addTerm normalizes a lexicon term and tracks the longest phrase.
+func (a *Analyzer) addTerm(term string) string {
+	words := moderation.Tokens(term)
+	if len(words) > a.maxPhrase {
+		a.maxPhrase = len(words)
+	}
+	return strings.Join(words, " ")
+}
+
+// Analyze scores a text and its aspects.
+func (a *Analyzer) Analyze(text string) Analysis {
+	var total float64
+	aspectSum := map[string]float64{}
+	aspectMentions := map[string]int{}
+
+	for _, clause := range a.clauses(text) {
+		score, aspects := a.scoreClause(clause)
+		total += score
+		for _, aspect := range aspects {
+			aspectSum[aspect] += score
+			aspectMentions[aspect]++
+		}
+	}
+
+	analysis := Analysis{Score: normalize(total), Aspects: []AspectScore{}}
+	analysis.Label = label(analysis.Score)
+	for _, aspect := range a.aspectOrder {
+		if aspectMentions[aspect] == 0 {
+			continue
+		}
+		analysis.Aspects = append(analysis.Aspects, AspectScore{
+			Aspect:   aspect,
+			Score:    normalize(aspectSum[aspect]),
+			Mentions: aspectMentions[aspect],
+		})
+	}
+	return analysis
+}
+
+// Aspects lists the aspect names known to the lexicon.
+func (a *Analyzer) Aspects() []string {
+	return append([]string(nil), a.aspectOrder...)
+}
+
+// clauses splits text at punctuation and contrast words into token lists.
+func (a *Analyzer) clauses(text string) [][]string {
+	var clauses [][]string
+	for _, part := range clauseSeparator.Split(text, -1) {
+		var clause []string
+		for _, token := range moderation.Tokens(part) {
+			if a.contrasts[token] {
+				if len(clause) > 0 {
+					clauses = append(clauses, clause)
+				}
+				clause = nil
+				continue
+			}
+			clause = append(clause, token)
+		}
+		if len(clause) > 0 {
+			clauses = append(clauses, clause)
+		}
+	}
+	return clauses
+}
+
+// scoreClause sums the polarity of a clause and lists the aspects it mentions.
+func (a *Analyzer) scoreClause(tokens []string) (float64, []string) {
+	var score float64
+	var aspects []string
+
+	// polarity: longest phrase first, each word used once
+	for i := 0; i < len(tokens); {
+		value, n := a.match(tokens, i, a.polarity)
+		if n == 0 {
+			i++
+			continue
+		}
+		if a.negated(tokens, i) {
+			value *= negationFactor
+		}
+		value *= a.intensity(tokens, i, n)
+		score += value
+		i += n
+	}
+
+	// aspects are matched independently: "rude" is both negative and about respect
+	for i := 0; i < len(tokens); i++ {
+		term, n := a.matchTerm(tokens, i, a.aspects)
+		if n == 0 {
+			continue
+		}
+		for _, aspect := range a.aspects[term] {
+			if !slices.Contains(aspects, aspect) {
+				aspects = append(aspects, aspect)
+			}
+		}
+		i += n - 1
+	}
+	return score, aspects
+}
+
+func (a *Analyzer) match(tokens []string, i int, lexicon map[string]float64) (float64, int) {
+	for n := min(a.maxPhrase, len(tokens)-i); n >= 1; n-- {
+		for _, key := range a.variants(tokens[i : i+n]) {
+			if value, ok := lexicon[key]; ok {
+				return value, n
+			}
+		}
+	}
+	return 0, 0
+}
+
+func (a *Analyzer) matchTerm(tokens []string, i int, lexicon map[string][]string) (string, int) {
+	for n := min(a.maxPhrase, len(tokens)-i); n >= 1; n-- {
+		for _, key := range a.variants(tokens[i : i+n]) {
+			if _, ok := lexicon[key]; ok {
+				return key, n
+			}
+		}
+	}
+	return "", 0
+}
+
+// variants are the lookup keys of a word sequence: as written, then with an
+// Arabic prefix removed from its first word.
+func (a *Analyzer) variants(words []string) []string {
+	keys := []string{strings.Join(words, " ")}
+	for _, prefix := range arabicPrefixes {
+		stem := strings.TrimPrefix(words[0], prefix)
+		if stem == words[0] || utf8.RuneCountInString(stem) < 2 {
+			continue
+		}
+		keys = append(keys, strings.Join(append([]string{stem}, words[1:]...), " "))
+	}
+	return keys
+}
+
+func (a *Analyzer) negated(tokens []string, i int) bool {
+	for j := max(0, i-negationWindow); j < i; j++ {
+		if a.negators[tokens[j]] || a.negators[strings.TrimPrefix(tokens[j], "و")] {
+			return true
+		}
+	}
+	return false
+}
+
+// intensity checks the word before (English) and after (Arabic "جدا") a term.
+func (a *Analyzer) intensity(tokens []string, i int, n int) float64 {
+	factor := 1.0
+	if i > 0 {
+		if f, ok := a.intensifiers[tokens[i-1]]; ok {
+			factor *= f
+		}
+	}
+	if i+n < len(tokens) {
+		if f, ok := a.intensifiers[tokens[i+n]]; ok {
+			factor *= f
+		}
+	}
+	return factor
+}
+
+func normalize(sum float64) float32 {
+	if sum == 0 {
+		return 0
+	}
+	return float32(sum / math.Sqrt(sum*sum+normalizeAlpha))
+}
+
+func label(score float32) string {
+	switch {
+	case score >= neutralBand:
+		return LabelPositive
+	case score <= -neutralBand:
+		return LabelNegative
+	}
+	return LabelNeutral
+}
+
+// loadLexicon reads a lexicon file into normalized fields, skipping blank
+// lines and # comments.
+func loadLexicon(name string) [][]string {
+	file, err := lexiconFS.Open("lexicon/" + name)
+	if err != nil {
+		panic(fmt.Sprintf("sentiment: missing lexicon %s: %v", name, err))
+	}
+	defer file.Close()
+
+	var lines [][]string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(moderation.Normalize(line))
+		if len(fields) < 2 {
+			panic(fmt.Sprintf("sentiment: malformed line in %s: %q", name, line))
+		}
+		lines = append(lines, fields)
+	}
+	return lines
+}
+
+// splitScore separates "<term...> <number>".
+func splitScore(name string, fields []string) (string, float64) {
+	last := len(fields) - 1
+	score, err := strconv.ParseFloat(fields[last], 64)
+	if err != nil || last == 0 {
+		panic(fmt.Sprintf("sentiment: bad score in %s: %q", name, strings.Join(fields, " ")))
+	}
+	return strings.Join(fields[:last], " "), score
+}
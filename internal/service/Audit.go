@@ -0,0 +1,52 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/rs/zerolog"
+	"githup.ahmedramadan.4cashier/internal/bootstrap"
+	"githup.ahmedramadan.4cashier/internal/models"
+	"githup.ahmedramadan.4cashier/internal/repos"
+)
+
+// AuditTrail records moderation actions (by moderators or automatic) in the
+// moderation audit log.
+type AuditTrail struct {
+	log  zerolog.Logger
+	repo repos.ModerationRepository
+}
+
+// NewAuditTrail creates a new instance of AuditTrail.
+func NewAuditTrail(log zerolog.Logger, repo repos.ModerationRepository) *AuditTrail {
+	return &AuditTrail{
+		log:  log.With().Str("layer", "service").Str("component", "AuditTrail").Logger(),
+		repo: repo,
+	}
+}
+
+// Record stores an audit entry; actorID is nil for automatic actions. Like
+// notifications, failures are logged and never undo the audited action.
+func (a *AuditTrail) Record(ctx context.Context, actorID *int, action string, targetType string, targetID int, details map[string]interface{}) {
+	if a == nil {
+		return
+	}
+
+	entry := &models.AuditEntry{ActorID: actorID, Action: action, TargetType: targetType, TargetID: targetID}
+	if details != nil {
+		raw, err := json.Marshal(details)
+		if err != nil {
+			a.log.Error().Err(err).Str("action", action).Msg("Failed to encode audit details")
+			return
+		}
+		entry.Details = raw
+	}
+
+	if err := a.repo.AddAuditEntry(ctx, entry); err != nil {
+		a.log.Error().Err(err).Str("action", action).Int("targetID", targetID).Msg("Audit record failed")
+	}
+}
+
+func (a *AuditTrail) GetAuditLog(ctx context.Context, pagination bootstrap.Pagination, filters map[string]interface{}) ([]models.AuditEntry, error) {
+	return a.repo.GetAuditLog(ctx, pagination, filters)
+}
@@ -2,27 +2,95 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
 
 	"github.com/rs/zerolog"
 	"githup.ahmedramadan.4cashier/internal/bootstrap"
 	"githup.ahmedramadan.4cashier/internal/models"
+	"githup.ahmedramadan.4cashier/internal/moderation"
+	"githup.ahmedramadan.4cashier/internal/sentiment"
 	"githup.ahmedramadan.4cashier/internal/repos"
 )
 
 type HRService struct {
 	log  zerolog.Logger
 	repo repos.HRRepository
-	badgeEvaluators []BadgeEvaluator
+	ratePolicy bootstrap.RatePolicy
+	fraud      bootstrap.FraudConfig
+	notifications *NotificationService
+	audit         *AuditTrail
+	reviewModeration   *moderation.Pipeline
+	responseModeration *moderation.Pipeline
+	sentiment          *sentiment.Analyzer
+	embeds             *EmbedService // cached widgets are dropped when a profile changes; may be nil
+	levels             *ContributorLevels
+	achievements       *AchievementService // may be nil
+	standings          *contributorStandings
 }
 
-func NewHRService(log zerolog.Logger, repo repos.HRRepository) *HRService {
+func NewHRService(log zerolog.Logger, repo repos.HRRepository, ratePolicy bootstrap.RatePolicy, fraud bootstrap.FraudConfig,
+	notifications *NotificationService, audit *AuditTrail, embeds *EmbedService, levels *ContributorLevels,
+	achievements *AchievementService) *HRService {
 	return &HRService{
 		log:  log.With().Str("layer", "service").Str("component", "HRService").Logger(),
 		repo: repo,
+		ratePolicy: ratePolicy,
+		fraud:      fraud,
+		notifications: notifications,
+		audit:         audit,
+		reviewModeration:   moderation.NewReviewPipeline(),
+		responseModeration: moderation.NewResponsePipeline(),
+		sentiment:          sentiment.NewAnalyzer(),
+		embeds:             embeds,
+		levels:             levels,
+		achievements:       achievements,
+		standings:          newContributorStandings(repo),
 	}
 }
 
+// RateConflictError is returned when an employee already has an active rate for
+// the HR profile and the rate policy does not allow another one yet, or when
+// their deleted rate is still within the cooldown.
+type RateConflictError struct {
+	ExistingRateID int        // 0 when the employee has no active rate
+	AvailableAt    *time.Time // when a new rate will be accepted (cooldown policy)
+}
+
+func (e *RateConflictError) Error() string {
+	return fmt.Sprintf("employee already rated this HR profile (rate %d)", e.ExistingRateID)
+}
+
+var (
+	ErrInvalidRateScores      = errors.New("criterion scores must be between 0 and 5 and not repeated")
+	ErrUnknownRatingContext   = errors.New("unknown or inactive rating context")
+	ErrUnknownBadgeDefinition = errors.New("unknown or inactive badge definition")
+)
+
+// normalizeRateScores validates the per-criterion breakdown and, when present,
+// makes the overall rate value the mean of the criterion scores.
+func normalizeRateScores(rate *models.Rate) error {
+	if len(rate.Scores) == 0 {
+		return nil
+	}
+
+	seen := map[string]bool{}
+	var total float32
+	for _, score := range rate.Scores {
+		key := fmt.Sprintf("%s#%d", score.Code, score.CriterionID)
+		if score.Score < 0 || score.Score > 5 || seen[key] {
+			return ErrInvalidRateScores
+		}
+		seen[key] = true
+		total += score.Score
+	}
+	rate.RateValue = total / float32(len(rate.Scores))
+	return nil
+}
+
 
 
 func (s *HRService) AddExperience(ctx context.Context, hrID int, exp []models.Experience) error {
@@ -41,25 +109,115 @@ func (s *HRService) AddJobRoles(ctx context.Context, hrID int, roles []models.Jo
 	return err
 }
 
-func (s *HRService) GetEmployeeStats(ctx context.Context, employeeId int) (models.EmployeeStats, error) {
-	return s.repo.GetEmployeeStats(ctx,employeeId)
-	
-}
+// GetEmployeeStats counts anonymous rates only for the employee themself and
+// moderators, otherwise the public stats would reveal anonymous activity.
+func (s *HRService) GetEmployeeStats(ctx context.Context, viewer models.Viewer, employeeId int) (models.EmployeeStats, error) {
+	stats, err := s.repo.GetEmployeeStats(ctx, employeeId, canSeeReviewer(viewer, employeeId))
+	if err != nil {
+		return stats, err
+	}
 
+	standing, err := s.standings.get(ctx, employeeId)
+	if err != nil {
+		return models.EmployeeStats{}, err
+	}
+	stats.Points = standing.Points
+	stats.Percentiles = &standing.Percentiles
+	stats.ContributorRank = contributorRank(standing.Score, standing.Percentiles.TopPercent)
 
-func (s *HRService) LikeBadge(ctx context.Context, like *models.BadgeLike) error {
-	err := s.repo.LikeBadge(ctx, like)
+	level, privileges, err := s.levels.PrivilegesOf(ctx, employeeId)
 	if err != nil {
+		return models.EmployeeStats{}, err
+	}
+	stats.Level, stats.Privileges = level, &privileges
+
+	if s.achievements != nil {
+		section, err := s.achievements.GetEmployeeAchievements(ctx, employeeId)
+		if err != nil {
+			return models.EmployeeStats{}, err
+		}
+		stats.Achievements, stats.Streak = section.Earned, &section.Streak
+	}
+	return stats, nil
+}
+
+
+func (s *HRService) LikeBadge(ctx context.Context, like *models.BadgeLike) (*models.BadgeVoteResult, error) {
+	result, err := s.repo.LikeBadge(ctx, like)
+	if err != nil && !errors.Is(err, repos.ErrBadgeNotFound) {
 		s.log.Error().Err(err).Msg("LikeBadge failed")
 	}
-	return err
+	return result, err
+}
+
+// RemoveBadgeVote withdraws the employee's like or dislike on a badge.
+func (s *HRService) RemoveBadgeVote(ctx context.Context, badgeID int, employeeID int) (*models.BadgeVoteResult, error) {
+	result, err := s.repo.RemoveBadgeVote(ctx, badgeID, employeeID)
+	if err != nil && !errors.Is(err, repos.ErrBadgeNotFound) && !errors.Is(err, repos.ErrVoteNotFound) {
+		s.log.Error().Err(err).Int("badgeID", badgeID).Msg("RemoveBadgeVote failed")
+	}
+	return result, err
+}
+
+// voterID is the employee whose votes are shown to the viewer (0 for others).
+func voterID(viewer models.Viewer) int {
+	if viewer.Role == "employee" {
+		return viewer.UserID
+	}
+	return 0
+}
+
+// GetBadge returns a badge with its definition, vote counters and the viewer's vote.
+func (s *HRService) GetBadge(ctx context.Context, badgeID int, viewer models.Viewer) (*models.Badge, error) {
+	badge, err := s.repo.GetVisibleBadge(ctx, badgeID, voterID(viewer))
+	if err != nil {
+		return nil, err
+	}
+	badge.Definition, err = s.repo.GetBadgeDefinition(ctx, badge.DefinitionID)
+	if err != nil {
+		return nil, err
+	}
+	return badge, nil
 }
 
+// GetRecentBadges is the public feed of the latest active badges.
+func (s *HRService) GetRecentBadges(ctx context.Context, pagination bootstrap.Pagination, viewer models.Viewer) ([]models.Badge, error) {
+	badges, err := s.repo.GetRecentBadges(ctx, pagination, voterID(viewer))
+	if err != nil {
+		s.log.Error().Err(err).Msg("GetRecentBadges failed")
+		return nil, err
+	}
+	definitions, err := s.badgeDefinitionsByID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for i := range badges {
+		badges[i].Definition = definitions[badges[i].DefinitionID]
+	}
+	return badges, nil
+}
+
+// AwardBadge grants a badge by hand; the definition must be active in the catalog.
 func (s *HRService) AwardBadge(ctx context.Context, badge *models.Badge) (int, error) {
-	id, err := s.repo.AwardBadge(ctx, badge)
+	definition, err := s.repo.GetBadgeDefinition(ctx, badge.DefinitionID)
+	if errors.Is(err, repos.ErrBadgeDefinitionNotFound) || (err == nil && !definition.IsActive) {
+		return 0, ErrUnknownBadgeDefinition
+	}
 	if err != nil {
+		return 0, err
+	}
+	badge.Definition = definition
+	if badge.ExpiresAt == nil {
+		badge.ExpiresAt = badgeExpiry(definition, time.Now())
+	}
+
+	id, err := s.repo.AwardBadge(ctx, badge)
+	if err != nil && !errors.Is(err, repos.ErrBadgeAlreadyAwarded) {
 		s.log.Error().Err(err).Msg("AwardBadge failed")
 	}
+	if err == nil {
+		s.embeds.Invalidate(badge.HRProfileID)
+	}
 	return id, err
 }
 
@@ -67,84 +225,582 @@ func (s *HRService) AwardBadge(ctx context.Context, badge *models.Badge) (int, e
 
 func (s * HRService) RateHR(ctx context.Context, rate *models.Rate) (*models.HRProfile, error) {
 	
-	// 1. تسجيل التقييم وتحديث المتوسط (Atomic)
-	_, _, err := s.repo.RateHR(ctx, rate)
+	// 1. تسجيل التقييم وتحديث المتوسط (Atomic) حسب سياسة التقييم المتكرر
+	if err := s.submitRate(ctx, rate); err != nil {
+		return nil, err
+	}
+	s.notifyModerationOutcome(ctx, rate)
+	s.analyzeRateQuietly(ctx, rate)
+	s.embeds.Invalidate(rate.HRProfileID)
+	s.contributionChanged(ctx, rate.EmployeeID)
+
+	// 2. تقييم الشارات في الخلفية (BadgeWorker)
+	s.queueBadgeEvaluation(ctx, &rate.HRProfileID, models.BadgeTriggerRateCreated)
+
+	// 3. جلب البروفايل المحدث
+	profile, err := s.repo.GetHRProfileByID(ctx, rate.HRProfileID)
 	if err != nil {
-		return nil, fmt.Errorf("service failed to execute rate transaction: %w", err)
+		return nil, fmt.Errorf("service failed to fetch updated profile: %w", err)
 	}
 
-	// 2. جلب البروفايل المحدث (لتقييم الشارات)
+	return profile, nil
+}
+
+// submitRate applies the repeat-rate policy: a first rate is inserted, a repeated
+// one is either applied as an edit (update policy) or accepted as a replacement
+// once the cooldown has passed (cooldown policy).
+func (s *HRService) submitRate(ctx context.Context, rate *models.Rate) error {
+	if err := normalizeRateScores(rate); err != nil {
+		return err
+	}
+	if err := s.checkRatingContext(ctx, rate); err != nil {
+		return err
+	}
+	s.moderateRate(ctx, rate)
+
+	existing, err := s.repo.GetActiveRate(ctx, rate.HRProfileID, rate.EmployeeID)
+	if errors.Is(err, repos.ErrRateNotFound) {
+		// deleting a rate does not skip the cooldown of the next one
+		if err := s.checkCooldown(ctx, rate, 0); err != nil {
+			return err
+		}
+		s.scoreNewRate(ctx, rate)
+		_, _, err = s.repo.RateHR(ctx, rate)
+		if errors.Is(err, repos.ErrRateExists) {
+			// سباق بين طلبين متزامنين: القيد في قاعدة البيانات منع التكرار
+			return s.conflictFor(ctx, rate)
+		}
+		if err != nil {
+			return fmt.Errorf("service failed to execute rate transaction: %w", err)
+		}
+		s.newRateSaved(ctx, rate)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("service failed to check existing rate: %w", err)
+	}
+
+	// a rejected rate is never public, so it is fixed by editing it whatever the policy
+	mode := s.ratePolicy.Mode
+	if existing.Status == moderation.StatusRejected {
+		mode = bootstrap.RatePolicyUpdate
+	}
+
+	switch mode {
+	case bootstrap.RatePolicyCooldown:
+		if err := s.checkCooldown(ctx, rate, existing.ID); err != nil {
+			return err
+		}
+		s.scoreNewRate(ctx, rate)
+		if _, _, err := s.repo.ReplaceRate(ctx, existing.ID, rate); err != nil {
+			return fmt.Errorf("service failed to replace rate %d: %w", existing.ID, err)
+		}
+		s.newRateSaved(ctx, rate)
+	default:
+		rate.ID = existing.ID
+		if _, err := s.repo.UpdateRate(ctx, rate); err != nil {
+			return fmt.Errorf("service failed to update rate %d: %w", existing.ID, err)
+		}
+		s.scoreRateQuietly(ctx, rate)
+	}
+	return nil
+}
+
+// checkCooldown rejects a rate given within the cooldown of the employee's
+// latest rate on the profile, deleted and superseded ones included.
+func (s *HRService) checkCooldown(ctx context.Context, rate *models.Rate, existingRateID int) error {
+	if s.ratePolicy.Mode != bootstrap.RatePolicyCooldown {
+		return nil
+	}
+	last, err := s.repo.GetLastRateTime(ctx, rate.HRProfileID, rate.EmployeeID)
+	if err != nil {
+		return fmt.Errorf("service failed to check rate cooldown: %w", err)
+	}
+	if last == nil {
+		return nil
+	}
+	availableAt := last.Add(s.ratePolicy.Cooldown)
+	if time.Now().Before(availableAt) {
+		return &RateConflictError{ExistingRateID: existingRateID, AvailableAt: &availableAt}
+	}
+	return nil
+}
+
+func (s *HRService) conflictFor(ctx context.Context, rate *models.Rate) error {
+	existing, err := s.repo.GetActiveRate(ctx, rate.HRProfileID, rate.EmployeeID)
+	if err != nil {
+		return fmt.Errorf("service failed to load conflicting rate: %w", err)
+	}
+	return &RateConflictError{ExistingRateID: existing.ID}
+}
+
+// UpdateRate lets an employee edit their own rate; the previous version is kept
+// as a revision and the profile average is recomputed.
+func (s *HRService) UpdateRate(ctx context.Context, rate *models.Rate) (*models.HRProfile, error) {
+	if err := normalizeRateScores(rate); err != nil {
+		return nil, err
+	}
+	if err := s.checkRatingContext(ctx, rate); err != nil {
+		return nil, err
+	}
+	s.moderateRate(ctx, rate)
+
+	if _, err := s.repo.UpdateRate(ctx, rate); err != nil {
+		return nil, fmt.Errorf("service failed to update rate %d: %w", rate.ID, err)
+	}
+	s.notifyModerationOutcome(ctx, rate)
+	s.scoreRateQuietly(ctx, rate)
+	s.analyzeRateQuietly(ctx, rate)
+	s.embeds.Invalidate(rate.HRProfileID)
+	s.contributionChanged(ctx, rate.EmployeeID)
+
 	profile, err := s.repo.GetHRProfileByID(ctx, rate.HRProfileID)
 	if err != nil {
 		return nil, fmt.Errorf("service failed to fetch updated profile: %w", err)
 	}
-    
-	// 3. تشغيل محرك الشارات (The Engine)
-	awardedBadges, err := s.runBadgeEngine(ctx, profile)
+	return profile, nil
+}
+
+// DeleteRate removes an employee's own rate and returns the recalculated profile.
+func (s *HRService) DeleteRate(ctx context.Context, rateID int, employeeID int) (*models.HRProfile, error) {
+	hrProfileID, err := s.repo.DeleteRate(ctx, rateID, employeeID)
 	if err != nil {
-		fmt.Printf("Warning: Failed to run badge engine for profile %d: %v\n", profile.ID, err)
+		return nil, fmt.Errorf("service failed to delete rate %d: %w", rateID, err)
 	}
-	
-	// (اختياري) إضافة الشارات الممنوحة حديثاً لنموذج الرد
-	profile.Badges = awardedBadges 
+	s.embeds.Invalidate(hrProfileID)
+	s.levels.refresh(ctx, employeeID)
 
+	profile, err := s.repo.GetHRProfileByID(ctx, hrProfileID)
+	if err != nil {
+		return nil, fmt.Errorf("service failed to fetch updated profile: %w", err)
+	}
 	return profile, nil
 }
 
-func (s * HRService) runBadgeEngine(ctx context.Context, profile *models.HRProfile) ([]models.Badge, error) {
-	var awardedBadges []models.Badge
+func (s *HRService) GetRate(ctx context.Context, viewer models.Viewer, rateID int) (*models.Rate, error) {
+	rate, err := s.repo.GetRate(ctx, rateID)
+	if err != nil {
+		return nil, err
+	}
+	// held and rejected rates exist only for their author and moderators
+	if rate.Status != moderation.StatusPublished && !canSeeReviewer(viewer, rate.EmployeeID) {
+		return nil, repos.ErrRateNotFound
+	}
+	shapeRate(viewer, rate)
+	return rate, nil
+}
+
+var ErrInvalidHRResponse = errors.New("response must be between 2 and 2000 characters")
+
+// RespondToRate creates or edits the single public response of the rated HR.
+func (s *HRService) RespondToRate(ctx context.Context, hrProfileID int, rateID int, response string) (*models.Rate, error) {
+	response = strings.TrimSpace(response)
+	if n := utf8.RuneCountInString(response); n < 2 || n > 2000 {
+		return nil, ErrInvalidHRResponse
+	}
+
+	rate, err := s.respondableRate(ctx, hrProfileID, rateID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := s.responseModeration.Run(response)
+	// rate.HRResponse is masked while a response is held, so whether this edits
+	// an earlier response comes from the stored row
+	hadResponse, err := s.repo.SetHRResponse(ctx, rateID, &result.Text, &result.Status, findingsJSON(result.Findings))
+	if err != nil {
+		return nil, fmt.Errorf("service failed to save hr response: %w", err)
+	}
+
+	if result.Status != moderation.StatusPublished {
+		s.audit.Record(ctx, nil, "hr_response.auto_"+result.Status, "rate", rate.ID,
+			map[string]interface{}{"reasons": result.Findings})
+		s.notifications.Notify(ctx, RecipientHR, hrProfileID, "rate_response_"+result.Status,
+			"Your response was "+result.Status+" by moderation",
+			map[string]interface{}{"rate_id": rate.ID, "reasons": result.Findings})
+	} else if !hadResponse {
+		s.notifications.Notify(ctx, RecipientEmployee, rate.EmployeeID, "rate_response",
+			"The HR responded to your review", map[string]interface{}{"rate_id": rate.ID, "hr_profile_id": hrProfileID})
+	} else {
+		s.notifications.Notify(ctx, RecipientEmployee, rate.EmployeeID, "rate_response_edited",
+			"The HR edited the response to your review", map[string]interface{}{"rate_id": rate.ID, "hr_profile_id": hrProfileID})
+	}
+
+	updated, err := s.repo.GetRate(ctx, rateID)
+	if err != nil {
+		return nil, err
+	}
+	shapeRate(models.Viewer{UserID: hrProfileID, Role: "hr"}, updated)
+	return updated, nil
+}
+
+// DeleteRateResponse removes the HR's response from a rate.
+func (s *HRService) DeleteRateResponse(ctx context.Context, hrProfileID int, rateID int) error {
+	if _, err := s.respondableRate(ctx, hrProfileID, rateID); err != nil {
+		return err
+	}
+	_, err := s.repo.SetHRResponse(ctx, rateID, nil, nil, nil)
+	return err
+}
+
+// respondableRate loads a rate the HR may respond to: a published rate on
+// their own profile. Held and rejected rates are not found, as for everyone
+// but their author.
+func (s *HRService) respondableRate(ctx context.Context, hrProfileID int, rateID int) (*models.Rate, error) {
+	rate, err := s.repo.GetRate(ctx, rateID)
+	if err != nil {
+		return nil, err
+	}
+	if rate.Status != moderation.StatusPublished {
+		return nil, repos.ErrRateNotFound
+	}
+	if rate.HRProfileID != hrProfileID {
+		return nil, repos.ErrNotRateOwner
+	}
+	return rate, nil
+}
+
+func (s *HRService) GetRateRevisions(ctx context.Context, rateID int) ([]models.RateRevision, error) {
+	return s.repo.GetRateRevisions(ctx, rateID)
+}
+
+// helpfulAuthorLikes is the likes count at which the author of a rate earns
+// the bonus for trusted content.
+const helpfulAuthorLikes = 50
+
+// Points tied to the state of a rate.
+const (
+	voterPoints         = 1
+	helpfulAuthorPoints = 10
+)
+
+// votePoints are settled by the repository in the vote's transaction: +1 for
+// the employee who helps filter the rates while they like one, and a bonus
+// for the author while the rate has 50 likes or more.
+var votePoints = models.RateVotePoints{
+	Voter:         voterPoints,
+	HelpfulAuthor: helpfulAuthorPoints,
+	HelpfulLikes:  helpfulAuthorLikes,
+}
+
+func (s * HRService) LikeRate(ctx context.Context, like *models.RateLike) (*models.RateVoteResult, error) {
 	
-	for _, evaluator := range s.badgeEvaluators {
-		badge, err := evaluator.Evaluate(ctx, profile)
-		
-		if err != nil {
-			return nil, err 
+	// 1. تسجيل اللايك وتحديث الـ count ونقاط المصداقية (Atomic)
+	result, err := s.repo.LikeRate(ctx, like, votePoints)
+	if err != nil {
+		return nil, fmt.Errorf("service failed to execute like transaction: %w", err)
+	}
+
+	s.votePointsMoved(ctx, result)
+	s.voteContributed(ctx, result)
+	s.queueBadgeEvaluation(ctx, &result.HRProfileID, models.BadgeTriggerRateLiked)
+	return result, nil
+}
+
+// RemoveRateVote withdraws the employee's vote and takes back any points it earned.
+func (s * HRService) RemoveRateVote(ctx context.Context, rateID int, employeeID int) (*models.RateVoteResult, error) {
+	result, err := s.repo.RemoveRateVote(ctx, rateID, employeeID, votePoints)
+	if err != nil {
+		return nil, fmt.Errorf("service failed to remove vote: %w", err)
+	}
+
+	s.votePointsMoved(ctx, result)
+	return result, nil
+}
+
+// votePointsMoved re-evaluates the author's level when the vote moved their
+// points; points for casting votes do not count towards a level.
+func (s *HRService) votePointsMoved(ctx context.Context, result *models.RateVoteResult) {
+	if result.AuthorPoints {
+		s.levels.refresh(ctx, result.AuthorID)
+	}
+}
+
+// contributionChanged re-evaluates what an employee's contributions earn: the
+// level now, and the achievements in the background, which refresh the level
+// again if their points raise it.
+func (s *HRService) contributionChanged(ctx context.Context, employeeID int) {
+	s.achievements.enqueue(ctx, employeeID)
+	s.levels.refresh(ctx, employeeID)
+}
+
+// voteContributed queues the achievements a vote may unlock: the author's
+// helpful reviews when the rate just became helpful. Votes do not extend
+// streaks, so the voter has nothing to re-evaluate.
+func (s *HRService) voteContributed(ctx context.Context, result *models.RateVoteResult) {
+	if result.PreviousLikes < helpfulReviewLikes && result.LikesCount >= helpfulReviewLikes {
+		s.achievements.enqueue(ctx, result.AuthorID)
+	}
+}
+
+// GetPointsHistory returns an employee's balance with a page of the ledger.
+func (s *HRService) GetPointsHistory(ctx context.Context, employeeID int, pagination bootstrap.Pagination) (*models.PointsHistory, error) {
+	balance, err := s.repo.GetPointsBalance(ctx, employeeID)
+	if err != nil {
+		return nil, err
+	}
+	items, err := s.repo.GetPointsHistory(ctx, employeeID, pagination)
+	if err != nil {
+		s.log.Error().Err(err).Int("employeeID", employeeID).Msg("GetPointsHistory failed")
+		return nil, err
+	}
+	return &models.PointsHistory{EmployeeID: employeeID, Balance: balance, Items: items}, nil
+}
+
+// RebuildPointsBalances repairs cached balances that drifted from the ledger.
+func (s *HRService) RebuildPointsBalances(ctx context.Context) (int, error) {
+	count, err := s.repo.RebuildPointsBalances(ctx)
+	if err != nil {
+		s.log.Error().Err(err).Msg("RebuildPointsBalances failed")
+	}
+	return count, err
+}
+
+
+func (s * HRService) GetHRProfiles(ctx context.Context, pagination bootstrap.Pagination, filters map[string]interface{}) ([]models.HRProfile, error) {
+	return s.repo.GetHRProfiles(ctx, pagination, filters)
+}
+
+func (s * HRService) GetRates(ctx context.Context, viewer models.Viewer, pagination bootstrap.Pagination, filters map[string]interface{}) ([]models.RateWithDetails, error) {
+	// listing someone else's rates must not reveal which anonymous rates are theirs
+	if employeeID, ok := filters["employee_id"].(int); ok && employeeID > 0 && !canSeeReviewer(viewer, employeeID) {
+		filters["is_anonymous"] = false
+	}
+	// the contradiction flag is a moderation hint
+	if !viewer.IsModerator() {
+		delete(filters, "sentiment_contradiction")
+	}
+	// authors see their own held and rejected rates in their list
+	if employeeID, ok := filters["employee_id"].(int); ok && employeeID > 0 && canSeeReviewer(viewer, employeeID) {
+		filters["include_unpublished"] = true
+	}
+
+	rates, err := s.repo.GetRates(ctx, pagination, filters)
+	if err != nil {
+		return nil, err
+	}
+	shapeRatesWithDetails(viewer, rates)
+	return rates, nil
+}
+
+// GetHRProfile returns a profile with its per-criterion, per-context and per-aspect breakdown.
+func (s *HRService) GetHRProfile(ctx context.Context, hrID int) (*models.HRProfile, error) {
+	profile, err := s.repo.GetHRProfileByID(ctx, hrID)
+	if err != nil {
+		return nil, err
+	}
+
+	profile.CriteriaScores, err = s.repo.GetCriteriaAverages(ctx, hrID)
+	if err != nil {
+		s.log.Error().Err(err).Int("hrID", hrID).Msg("GetCriteriaAverages failed")
+		return nil, err
+	}
+
+	profile.ContextScores, err = s.repo.GetContextAverages(ctx, hrID)
+	if err != nil {
+		s.log.Error().Err(err).Int("hrID", hrID).Msg("GetContextAverages failed")
+		return nil, err
+	}
+
+	profile.AspectScores, err = s.repo.GetAspectAverages(ctx, hrID)
+	if err != nil {
+		s.log.Error().Err(err).Int("hrID", hrID).Msg("GetAspectAverages failed")
+		return nil, err
+	}
+
+	badges, err := s.profileBadges(ctx, hrID, 0)
+	if err != nil {
+		return nil, err
+	}
+	profile.Badges = badges.Active
+	return profile, nil
+}
+
+// GetProfileBadges splits the badges of a profile into active ones and the
+// history of expired and revoked ones.
+func (s *HRService) GetProfileBadges(ctx context.Context, hrID int, viewer models.Viewer) (*models.ProfileBadges, error) {
+	if _, err := s.repo.GetHRProfileByID(ctx, hrID); err != nil {
+		return nil, err
+	}
+	return s.profileBadges(ctx, hrID, voterID(viewer))
+}
+
+func (s *HRService) profileBadges(ctx context.Context, hrID int, viewerEmployeeID int) (*models.ProfileBadges, error) {
+	badges, err := s.repo.GetProfileBadges(ctx, hrID, viewerEmployeeID)
+	if err != nil {
+		s.log.Error().Err(err).Int("hrID", hrID).Msg("GetProfileBadges failed")
+		return nil, err
+	}
+	definitions, err := s.badgeDefinitionsByID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &models.ProfileBadges{HRProfileID: hrID, Active: []models.Badge{}, History: []models.Badge{}}
+	now := time.Now()
+	for _, badge := range badges {
+		badge.Definition = definitions[badge.DefinitionID]
+		if badge.IsActive(now) {
+			result.Active = append(result.Active, badge)
+		} else {
+			result.History = append(result.History, badge)
 		}
-		
-		if badge != nil {
-			// منح الشارة
-			_, err := s.repo.AwardBadge(ctx, badge)
-			if err == nil {
-				awardedBadges = append(awardedBadges, *badge)
-			}
+	}
+	return result, nil
+}
+
+// RevokeBadge is an admin revocation; the badge stays in the profile history
+// with the reason.
+func (s *HRService) RevokeBadge(ctx context.Context, adminID int, badgeID int, reason string) (*models.Badge, error) {
+	badge, err := s.repo.RevokeBadge(ctx, badgeID, models.BadgeRevokedManual, &reason, &adminID)
+	if err != nil {
+		if !errors.Is(err, repos.ErrBadgeNotFound) {
+			s.log.Error().Err(err).Int("badgeID", badgeID).Msg("RevokeBadge failed")
 		}
+		return nil, err
 	}
-	
-	return awardedBadges, nil
+
+	s.audit.Record(ctx, &adminID, "badge.revoked", "badge", badgeID, map[string]interface{}{"reason": reason})
+	s.notifyBadgeChanges(ctx, []models.Badge{*badge})
+	return badge, nil
 }
 
-func (s * HRService) LikeRate(ctx context.Context, like *models.RateLike) (int, error) {
-	
-	// 1. تسجيل اللايك وتحديث الـ count (Atomic)
-	newLikesCount, err := s.repo.LikeRate(ctx, like)
+// ratingSummaryWindowDays is the "recent" window compared against the lifetime score.
+const ratingSummaryWindowDays = 90
+
+// GetRatingSummary returns the star distribution and score trend of a profile.
+func (s *HRService) GetRatingSummary(ctx context.Context, hrID int, months int) (*models.RatingSummary, error) {
+	summary, err := s.repo.GetRatingSummary(ctx, hrID, months, ratingSummaryWindowDays)
+	if err != nil {
+		return nil, err
+	}
+
+	if recent, lifetime := summary.Recent.RecentAverage, summary.Recent.LifetimeAverage; recent != nil && lifetime != nil {
+		delta := *recent - *lifetime
+		summary.Recent.Delta = &delta
+	}
+	return summary, nil
+}
+
+func (s *HRService) GetRatingCriteria(ctx context.Context, activeOnly bool, contextCode string) ([]models.RatingCriterion, error) {
+	return s.repo.GetRatingCriteria(ctx, activeOnly, contextCode)
+}
+
+func (s *HRService) CreateRatingCriterion(ctx context.Context, criterion *models.RatingCriterion) (int, error) {
+	id, err := s.repo.CreateRatingCriterion(ctx, criterion)
 	if err != nil {
-		return 0, fmt.Errorf("service failed to execute like transaction: %w", err)
+		s.log.Error().Err(err).Msg("CreateRatingCriterion failed")
 	}
+	return id, err
+}
 
-	// 2. المصداقية للمُصوّت (Voter Credibility)
-	if like.IsLike {
-		// +1 نقطة للموظف الذي يساهم في الفلترة
-		s.repo.UpdateEmployeePoints(ctx, like.EmployeeID, 1) 
+func (s *HRService) UpdateRatingCriterion(ctx context.Context, criterion *models.RatingCriterion) error {
+	err := s.repo.UpdateRatingCriterion(ctx, criterion)
+	if err != nil {
+		s.log.Error().Err(err).Int("criterionID", criterion.ID).Msg("UpdateRatingCriterion failed")
 	}
+	return err
+}
 
-	// 3. المصداقية للكاتب (Author Credibility)
-	// قاعدة العمل: عند الوصول لـ 50 لايك، الكاتب يأخذ نقاط إضافية
-	if newLikesCount == 50 { 
-		rateOwnerID, err := s.repo.GetRateOwner(ctx, like.RateID)
-		if err == nil && rateOwnerID > 0 {
-			// +10 نقاط إضافية لمساهمته بمحتوى موثوق
-			s.repo.UpdateEmployeePoints(ctx, rateOwnerID, 10) 
+// RecalculateAllScores refreshes the weighted score of every HR profile.
+func (s *HRService) RecalculateAllScores(ctx context.Context) (int, error) {
+	count, err := s.repo.RecalculateAllScores(ctx)
+	if err != nil {
+		s.log.Error().Err(err).Int("processed", count).Msg("RecalculateAllScores failed")
+	}
+	return count, err
+}
+
+// checkRatingContext accepts a rate without context or with an active context code.
+func (s *HRService) checkRatingContext(ctx context.Context, rate *models.Rate) error {
+	if rate.RatingContext == nil {
+		return nil
+	}
+
+	contexts, err := s.repo.GetRatingContexts(ctx, true)
+	if err != nil {
+		return fmt.Errorf("service failed to load rating contexts: %w", err)
+	}
+	for _, ratingContext := range contexts {
+		if ratingContext.Code == *rate.RatingContext {
+			return nil
 		}
 	}
-	
-	return newLikesCount, nil
+	return ErrUnknownRatingContext
 }
 
+func (s *HRService) GetBadgeDefinitions(ctx context.Context, activeOnly bool) ([]models.BadgeDefinition, error) {
+	return s.repo.GetBadgeDefinitions(ctx, activeOnly)
+}
 
-func (s * HRService) GetHRProfiles(ctx context.Context, pagination bootstrap.Pagination, filters map[string]interface{}) ([]models.HRProfile, error) {
-	return s.repo.GetHRProfiles(ctx, pagination, filters)
+func (s *HRService) CreateBadgeDefinition(ctx context.Context, definition *models.BadgeDefinition) (int, error) {
+	if err := validateBadgeDefinition(definition); err != nil {
+		return 0, err
+	}
+	id, err := s.repo.CreateBadgeDefinition(ctx, definition)
+	if err != nil && !errors.Is(err, repos.ErrBadgeDefinitionExists) {
+		s.log.Error().Err(err).Msg("CreateBadgeDefinition failed")
+	}
+	if err == nil && definition.Rule != nil {
+		s.queueBadgeEvaluation(ctx, nil, models.BadgeTriggerRuleChanged)
+	}
+	return id, err
 }
 
-func (s * HRService) GetRates(ctx context.Context, pagination bootstrap.Pagination, filters map[string]interface{}) ([]models.RateWithDetails, error) {
-	return s.repo.GetRates(ctx, pagination, filters)
-}
\ No newline at end of file
+func (s *HRService) UpdateBadgeDefinition(ctx context.Context, definition *models.BadgeDefinition) error {
+	if err := validateBadgeDefinition(definition); err != nil {
+		return err
+	}
+	err := s.repo.UpdateBadgeDefinition(ctx, definition)
+	if err != nil && !errors.Is(err, repos.ErrBadgeDefinitionNotFound) && !errors.Is(err, repos.ErrBadgeDefinitionExists) {
+		s.log.Error().Err(err).Int("definitionID", definition.ID).Msg("UpdateBadgeDefinition failed")
+	}
+	if err == nil && definition.Rule != nil && definition.IsActive {
+		s.queueBadgeEvaluation(ctx, nil, models.BadgeTriggerRuleChanged)
+	}
+	return err
+}
+
+// SetProfileVerified marks an HR profile verified (or not) and re-evaluates
+// its badges, since rules may require a verified profile.
+func (s *HRService) SetProfileVerified(ctx context.Context, adminID int, profileID int, verified bool) error {
+	if err := s.repo.SetProfileVerified(ctx, profileID, verified); err != nil {
+		return err
+	}
+
+	action := "hr_profile.unverified"
+	if verified {
+		action = "hr_profile.verified"
+	}
+	s.audit.Record(ctx, &adminID, action, "hr_profile", profileID, nil)
+	s.queueBadgeEvaluation(ctx, &profileID, models.BadgeTriggerProfileVerified)
+	return nil
+}
+
+func (s *HRService) DeleteBadgeDefinition(ctx context.Context, definitionID int) error {
+	err := s.repo.DeleteBadgeDefinition(ctx, definitionID)
+	if err != nil && !errors.Is(err, repos.ErrBadgeDefinitionNotFound) && !errors.Is(err, repos.ErrBadgeDefinitionInUse) {
+		s.log.Error().Err(err).Int("definitionID", definitionID).Msg("DeleteBadgeDefinition failed")
+	}
+	return err
+}
+
+func (s *HRService) GetRatingContexts(ctx context.Context, activeOnly bool) ([]models.RatingContext, error) {
+	return s.repo.GetRatingContexts(ctx, activeOnly)
+}
+
+func (s *HRService) CreateRatingContext(ctx context.Context, ratingContext *models.RatingContext) (int, error) {
+	id, err := s.repo.CreateRatingContext(ctx, ratingContext)
+	if err != nil {
+		s.log.Error().Err(err).Msg("CreateRatingContext failed")
+	}
+	return id, err
+}
+
+func (s *HRService) UpdateRatingContext(ctx context.Context, ratingContext *models.RatingContext) error {
+	err := s.repo.UpdateRatingContext(ctx, ratingContext)
+	if err != nil {
+		s.log.Error().Err(err).Int("contextID", ratingContext.ID).Msg("UpdateRatingContext failed")
+	}
+	return err
+}
@@ -0,0 +1,65 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/rs/zerolog"
+	"githup.ahmedramadan.4cashier/internal/bootstrap"
+	"githup.ahmedramadan.4cashier/internal/models"
+	"githup.ahmedramadan.4cashier/internal/repos"
+)
+
+const (
+	RecipientEmployee = "employee"
+	RecipientHR       = "hr"
+)
+
+// NotificationService creates and lists in-app notifications.
+type NotificationService struct {
+	log  zerolog.Logger
+	repo repos.NotificationRepository
+}
+
+// NewNotificationService creates a new instance of NotificationService.
+func NewNotificationService(log zerolog.Logger, repo repos.NotificationRepository) *NotificationService {
+	return &NotificationService{
+		log:  log.With().Str("layer", "service").Str("component", "NotificationService").Logger(),
+		repo: repo,
+	}
+}
+
+// Notify stores a notification. Failures are logged and never block the action
+// that triggered the notification.
+func (s *NotificationService) Notify(ctx context.Context, recipientType string, recipientID int, kind string, title string, payload map[string]interface{}) {
+	if s == nil || recipientID <= 0 {
+		return
+	}
+
+	notification := &models.Notification{
+		RecipientType: recipientType,
+		RecipientID:   recipientID,
+		Kind:          kind,
+		Title:         title,
+	}
+	if payload != nil {
+		raw, err := json.Marshal(payload)
+		if err != nil {
+			s.log.Error().Err(err).Str("kind", kind).Msg("Failed to encode notification payload")
+			return
+		}
+		notification.Payload = raw
+	}
+
+	if _, err := s.repo.AddNotification(ctx, notification); err != nil {
+		s.log.Error().Err(err).Str("kind", kind).Int("recipientID", recipientID).Msg("Notify failed")
+	}
+}
+
+func (s *NotificationService) GetNotifications(ctx context.Context, recipientType string, recipientID int, pagination bootstrap.Pagination) ([]models.Notification, error) {
+	return s.repo.GetNotifications(ctx, recipientType, recipientID, pagination)
+}
+
+func (s *NotificationService) MarkNotificationRead(ctx context.Context, recipientType string, recipientID int, notificationID int) (int, error) {
+	return s.repo.MarkNotificationRead(ctx, recipientType, recipientID, notificationID)
+}
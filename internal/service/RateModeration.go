@@ -0,0 +1,137 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"githup.ahmedramadan.4cashier/internal/bootstrap"
+	"githup.ahmedramadan.4cashier/internal/models"
+	"githup.ahmedramadan.4cashier/internal/moderation"
+)
+
+// =================================================================
+// ⭐️ فحص محتوى التقييمات قبل النشر وطابور المشرفين
+// =================================================================
+
+var ErrInvalidModerationStatus = errors.New("status must be held, rejected or published")
+
+// findingsJSON encodes moderation findings for the moderation_reasons column.
+func findingsJSON(findings []moderation.Finding) models.JSONB {
+	if len(findings) == 0 {
+		return nil
+	}
+	data, err := json.Marshal(findings)
+	if err != nil {
+		return nil
+	}
+	return models.JSONB(data)
+}
+
+// moderateRate runs the review pipeline: the stored text is the redacted one
+// and the outcome decides whether the rate is public. Contributors whose level
+// skips the moderation hold are published right away; the hold findings stay
+// on the rate for moderators.
+func (s *HRService) moderateRate(ctx context.Context, rate *models.Rate) {
+	result := s.reviewModeration.Run(rate.ReviewText)
+	if result.Status == moderation.StatusHeld {
+		level, privileges, err := s.levels.PrivilegesOf(ctx, rate.EmployeeID)
+		if err != nil {
+			s.log.Error().Err(err).Int("employeeID", rate.EmployeeID).Msg("PrivilegesOf failed, keeping the hold")
+		}
+		if err == nil && privileges.SkipModerationHold {
+			result.Status = moderation.StatusPublished
+			result.Findings = append(result.Findings, moderation.Finding{
+				Check: "contributor_level", Reason: "hold skipped for " + level + " contributor", Level: "info",
+			})
+		}
+	}
+	rate.ReviewText = result.Text
+	rate.Status = result.Status
+	rate.ModerationReasons = findingsJSON(result.Findings)
+}
+
+// notifyModerationOutcome tells the author when their rate was not published
+// right away, and records the automatic decision.
+func (s *HRService) notifyModerationOutcome(ctx context.Context, rate *models.Rate) {
+	if rate.Status != moderation.StatusPublished {
+		s.audit.Record(ctx, nil, "rate.auto_"+rate.Status, "rate", rate.ID,
+			map[string]interface{}{"reasons": rate.ModerationReasons})
+	}
+
+	switch rate.Status {
+	case moderation.StatusHeld:
+		s.notifications.Notify(ctx, RecipientEmployee, rate.EmployeeID, "rate_held",
+			"Your review is waiting for a moderator", map[string]interface{}{"rate_id": rate.ID, "reasons": rate.ModerationReasons})
+	case moderation.StatusRejected:
+		s.notifications.Notify(ctx, RecipientEmployee, rate.EmployeeID, "rate_rejected",
+			"Your review was not published", map[string]interface{}{"rate_id": rate.ID, "reasons": rate.ModerationReasons})
+	}
+}
+
+// GetModerationRates lists the rates in a moderation state (the queue is "held").
+func (s *HRService) GetModerationRates(ctx context.Context, status string, pagination bootstrap.Pagination) ([]models.Rate, error) {
+	switch status {
+	case moderation.StatusHeld, moderation.StatusRejected, moderation.StatusPublished:
+	default:
+		return nil, ErrInvalidModerationStatus
+	}
+	return s.repo.GetModerationRates(ctx, status, pagination)
+}
+
+// DecideRate publishes or rejects a rate on behalf of a moderator and tells the
+// author the outcome.
+func (s *HRService) DecideRate(ctx context.Context, moderatorID int, rateID int, approve bool, note *string) (*models.Rate, error) {
+	status := moderation.StatusRejected
+	if approve {
+		status = moderation.StatusPublished
+	}
+
+	rate, err := s.repo.DecideRate(ctx, rateID, status, &moderatorID, note)
+	if err != nil {
+		return nil, fmt.Errorf("service failed to moderate rate %d: %w", rateID, err)
+	}
+	s.audit.Record(ctx, &moderatorID, "rate."+status, "rate", rateID, map[string]interface{}{"note": note})
+	s.embeds.Invalidate(rate.HRProfileID)
+	s.contributionChanged(ctx, rate.EmployeeID)
+
+	payload := map[string]interface{}{"rate_id": rate.ID, "hr_profile_id": rate.HRProfileID}
+	if note != nil {
+		payload["note"] = *note
+	}
+	if approve {
+		s.notifications.Notify(ctx, RecipientEmployee, rate.EmployeeID, "rate_published", "Your review was published", payload)
+	} else {
+		s.notifications.Notify(ctx, RecipientEmployee, rate.EmployeeID, "rate_rejected", "Your review was not published", payload)
+	}
+	return rate, nil
+}
+
+func (s *HRService) GetHeldResponses(ctx context.Context, pagination bootstrap.Pagination) ([]models.HeldResponse, error) {
+	return s.repo.GetHeldResponses(ctx, pagination)
+}
+
+// DecideHRResponse publishes or rejects a held HR response. The HR is told the
+// outcome and, once published, the reviewer is told about the response.
+func (s *HRService) DecideHRResponse(ctx context.Context, moderatorID int, rateID int, approve bool) error {
+	status := moderation.StatusRejected
+	if approve {
+		status = moderation.StatusPublished
+	}
+
+	rate, err := s.repo.DecideHRResponse(ctx, rateID, status)
+	if err != nil {
+		return fmt.Errorf("service failed to moderate hr response of rate %d: %w", rateID, err)
+	}
+	s.audit.Record(ctx, &moderatorID, "hr_response."+status, "rate", rateID, nil)
+
+	payload := map[string]interface{}{"rate_id": rate.ID, "hr_profile_id": rate.HRProfileID}
+	s.notifications.Notify(ctx, RecipientHR, rate.HRProfileID, "rate_response_"+status,
+		"Your response was "+status+" by a moderator", payload)
+	if approve {
+		s.notifications.Notify(ctx, RecipientEmployee, rate.EmployeeID, "rate_response",
+			"The HR responded to your review", payload)
+	}
+	return nil
+}
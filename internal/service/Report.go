@@ -0,0 +1,184 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/rs/zerolog"
+	"githup.ahmedramadan.4cashier/internal/bootstrap"
+	"githup.ahmedramadan.4cashier/internal/models"
+	"githup.ahmedramadan.4cashier/internal/moderation"
+	"githup.ahmedramadan.4cashier/internal/repos"
+)
+
+const (
+	ReportTargetRate  = "rate"
+	ReportTargetBadge = "badge"
+)
+
+var (
+	ErrCannotReportOwn = errors.New("you cannot report your own review")
+	ErrReportResolved  = errors.New("report was already resolved")
+)
+
+// ReportService handles user reports on rates and badges and the moderator
+// decisions on them.
+type ReportService struct {
+	log           zerolog.Logger
+	repo          repos.ModerationRepository
+	hrRepo        repos.HRRepository
+	notifications *NotificationService
+	audit         *AuditTrail
+	policy        bootstrap.ReportPolicy
+	levels        *ContributorLevels
+	embeds        *EmbedService // hiding a rate or a badge changes the profile widget; may be nil
+}
+
+// NewReportService creates a new instance of ReportService.
+func NewReportService(log zerolog.Logger, repo repos.ModerationRepository, hrRepo repos.HRRepository,
+	notifications *NotificationService, audit *AuditTrail, policy bootstrap.ReportPolicy, levels *ContributorLevels,
+	embeds *EmbedService) *ReportService {
+	return &ReportService{
+		log:           log.With().Str("layer", "service").Str("component", "ReportService").Logger(),
+		repo:          repo,
+		hrRepo:        hrRepo,
+		notifications: notifications,
+		audit:         audit,
+		policy:        policy,
+		levels:        levels,
+		embeds:        embeds,
+	}
+}
+
+// ReportRate files a report on a published rate. Once the summed credibility
+// of its open reports reaches the threshold the rate is held until a moderator
+// decides.
+func (s *ReportService) ReportRate(ctx context.Context, report *models.Report) (*models.Report, error) {
+	rate, err := s.hrRepo.GetRate(ctx, report.TargetID)
+	if err != nil {
+		return nil, err
+	}
+	// held and rejected rates are not public, so they cannot be reported and
+	// the answer must not tell they exist
+	if rate.Status != moderation.StatusPublished {
+		return nil, repos.ErrRateNotFound
+	}
+	if rate.EmployeeID == report.ReporterID {
+		return nil, ErrCannotReportOwn
+	}
+
+	report.TargetType = ReportTargetRate
+	if _, err := s.repo.CreateReport(ctx, report, s.reporterWeight(ctx, report.ReporterID)); err != nil {
+		return nil, err
+	}
+
+	weight, err := s.repo.GetOpenReportWeight(ctx, ReportTargetRate, rate.ID)
+	if err != nil {
+		s.log.Error().Err(err).Int("rateID", rate.ID).Msg("GetOpenReportWeight failed")
+		return report, nil
+	}
+	if weight >= s.policy.HideThreshold && rate.Status == moderation.StatusPublished {
+		s.hideReportedRate(ctx, rate, weight)
+	}
+	return report, nil
+}
+
+// reporterWeight is what the reporter's level multiplies their credibility by.
+func (s *ReportService) reporterWeight(ctx context.Context, reporterID int) float64 {
+	_, privileges, err := s.levels.PrivilegesOf(ctx, reporterID)
+	if err != nil {
+		s.log.Error().Err(err).Int("reporterID", reporterID).Msg("PrivilegesOf failed, using newcomer weight")
+	}
+	return privileges.ReportWeight
+}
+
+// hideReportedRate holds a rate that passed the report threshold.
+func (s *ReportService) hideReportedRate(ctx context.Context, rate *models.Rate, weight float64) {
+	note := fmt.Sprintf("hidden after reports (weight %.1f)", weight)
+	if _, err := s.hrRepo.DecideRate(ctx, rate.ID, moderation.StatusHeld, nil, &note); err != nil {
+		s.log.Error().Err(err).Int("rateID", rate.ID).Msg("Failed to hide reported rate")
+		return
+	}
+	s.embeds.Invalidate(rate.HRProfileID)
+
+	s.audit.Record(ctx, nil, "rate.hidden_by_reports", ReportTargetRate, rate.ID, map[string]interface{}{"report_weight": weight})
+	s.notifications.Notify(ctx, RecipientEmployee, rate.EmployeeID, "rate_held",
+		"Your review is hidden while moderators check reports about it", map[string]interface{}{"rate_id": rate.ID})
+}
+
+// ReportBadge files a report on a visible badge; badges are only hidden by a moderator.
+func (s *ReportService) ReportBadge(ctx context.Context, report *models.Report) (*models.Report, error) {
+	report.TargetType = ReportTargetBadge
+	if _, err := s.repo.CreateReport(ctx, report, s.reporterWeight(ctx, report.ReporterID)); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+func (s *ReportService) GetReports(ctx context.Context, pagination bootstrap.Pagination, filters map[string]interface{}) ([]models.Report, error) {
+	return s.repo.GetReports(ctx, pagination, filters)
+}
+
+// DecideReport upholds or dismisses a report. The decision applies to every
+// open report on the same item, acts on the item and moves the credibility of
+// the reporters, all in one transaction.
+func (s *ReportService) DecideReport(ctx context.Context, moderatorID int, reportID int, uphold bool, note *string) error {
+	report, err := s.repo.GetReport(ctx, reportID)
+	if err != nil {
+		return err
+	}
+	if report.Status != "open" {
+		return ErrReportResolved
+	}
+
+	delta := -s.policy.DismissedPenalty
+	if uphold {
+		delta = s.policy.UpheldCredibility
+	}
+	resolution, err := s.hrRepo.ResolveReportedItem(ctx, report.TargetType, report.TargetID, uphold, moderatorID, note, delta)
+	if errors.Is(err, repos.ErrNoOpenReports) {
+		return ErrReportResolved
+	}
+	if err != nil {
+		s.log.Error().Err(err).Int("reportID", reportID).Msg("ResolveReportedItem failed")
+		return err
+	}
+
+	if resolution.Rate != nil {
+		s.reportedRateDecided(ctx, moderatorID, resolution.Rate, note)
+	}
+	if resolution.BadgeHRProfileID > 0 {
+		s.badgeHidden(ctx, moderatorID, report.TargetID, resolution.BadgeHRProfileID, note)
+	}
+
+	status := resolution.Status
+	s.audit.Record(ctx, &moderatorID, "report."+status, report.TargetType, report.TargetID,
+		map[string]interface{}{"report_id": report.ID, "reports_closed": len(resolution.ReporterIDs), "note": note})
+	for _, reporterID := range resolution.ReporterIDs {
+		s.notifications.Notify(ctx, RecipientEmployee, reporterID, "report_"+status, "Your report was reviewed",
+			map[string]interface{}{"target_type": report.TargetType, "target_id": report.TargetID, "outcome": status})
+	}
+	return nil
+}
+
+// reportedRateDecided follows up a reported rate that was rejected, or put back
+// online after reports had hidden it.
+func (s *ReportService) reportedRateDecided(ctx context.Context, moderatorID int, rate *models.Rate, note *string) {
+	s.embeds.Invalidate(rate.HRProfileID)
+	s.audit.Record(ctx, &moderatorID, "rate."+rate.Status, ReportTargetRate, rate.ID, map[string]interface{}{"via": "report", "note": note})
+	title := "Your review was removed after a report"
+	if rate.Status == moderation.StatusPublished {
+		title = "Your review is visible again"
+	}
+	s.notifications.Notify(ctx, RecipientEmployee, rate.EmployeeID, "rate_"+rate.Status, title, map[string]interface{}{"rate_id": rate.ID})
+	s.levels.refresh(ctx, rate.EmployeeID)
+}
+
+// badgeHidden follows up a badge hidden after an upheld report.
+func (s *ReportService) badgeHidden(ctx context.Context, moderatorID int, badgeID int, hrProfileID int, note *string) {
+	s.embeds.Invalidate(hrProfileID)
+	s.audit.Record(ctx, &moderatorID, "badge.hidden", ReportTargetBadge, badgeID, map[string]interface{}{"note": note})
+	s.notifications.Notify(ctx, RecipientHR, hrProfileID, "badge_hidden", "One of your badges was hidden after a report",
+		map[string]interface{}{"badge_id": badgeID})
+}
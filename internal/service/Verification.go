@@ -0,0 +1,359 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"githup.ahmedramadan.4cashier/internal/bootstrap"
+	"githup.ahmedramadan.4cashier/internal/models"
+	"githup.ahmedramadan.4cashier/internal/repos"
+)
+
+const (
+	emailCodeTTL         = 30 * time.Minute
+	maxEmailCodeAttempts = 5 // failed attempts per rate within emailCodeWindow, across codes
+	maxEmailCodes        = 5 // codes issued per rate within emailCodeWindow
+	emailCodeWindow      = 24 * time.Hour
+)
+
+var (
+	ErrAlreadyVerified       = errors.New("rate is already verified")
+	ErrNoCompanyDomain       = errors.New("the HR profile has no company email domain to verify against")
+	ErrEmailDomainMismatch   = errors.New("email is not at the HR's company domain")
+	ErrInvalidVerifyCode     = errors.New("verification code is invalid")
+	ErrTooManyVerifyAttempts = errors.New("too many attempts, try again later")
+	ErrTooManyVerifyCodes    = errors.New("too many verification codes requested, try again later")
+	ErrNotDocumentReview     = errors.New("only document verifications are reviewed by moderators")
+)
+
+// freeMailDomains cannot prove employment at a company.
+var freeMailDomains = map[string]bool{
+	"gmail.com": true, "googlemail.com": true, "yahoo.com": true, "hotmail.com": true,
+	"outlook.com": true, "live.com": true, "icloud.com": true, "aol.com": true,
+	"proton.me": true, "protonmail.com": true, "yandex.com": true, "mail.com": true,
+}
+
+// VerificationService proves that a reviewer really worked with the HR's company.
+type VerificationService struct {
+	log           zerolog.Logger
+	repo          repos.VerificationRepository
+	hrRepo        repos.HRRepository
+	notifications *NotificationService
+	audit         *AuditTrail
+	mailer        Mailer
+	uploadDir     string
+	levels        *ContributorLevels
+	achievements  *AchievementService // may be nil
+}
+
+// NewVerificationService creates a new instance of VerificationService.
+func NewVerificationService(log zerolog.Logger, repo repos.VerificationRepository, hrRepo repos.HRRepository,
+	notifications *NotificationService, audit *AuditTrail, mailer Mailer, uploadDir string, levels *ContributorLevels,
+	achievements *AchievementService) *VerificationService {
+	return &VerificationService{
+		log:           log.With().Str("layer", "service").Str("component", "VerificationService").Logger(),
+		repo:          repo,
+		hrRepo:        hrRepo,
+		notifications: notifications,
+		audit:         audit,
+		mailer:        mailer,
+		uploadDir:     uploadDir,
+		levels:        levels,
+		achievements:  achievements,
+	}
+}
+
+func hashSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+func emailDomain(email string) string {
+	at := strings.LastIndex(email, "@")
+	if at < 0 {
+		return ""
+	}
+	return strings.ToLower(strings.TrimSpace(email[at+1:]))
+}
+
+// companyDomain is the email domain of the HR, unless it is a free mail provider.
+func companyDomain(profile *models.HRProfile) string {
+	if profile.Email == nil {
+		return ""
+	}
+	domain := emailDomain(*profile.Email)
+	if freeMailDomains[domain] {
+		return ""
+	}
+	return domain
+}
+
+// rateVerified re-evaluates the badges of the rated HR, since verified rates
+// count in badge rules (verified_rates_count), and the achievements and level
+// of the author, which a verified rate can raise.
+func (s *VerificationService) rateVerified(ctx context.Context, rate *models.Rate) {
+	s.achievements.evaluate(ctx, rate.EmployeeID)
+	s.levels.refresh(ctx, rate.EmployeeID)
+	if _, err := s.hrRepo.EnqueueBadgeEvaluation(ctx, &rate.HRProfileID, models.BadgeTriggerRateVerified); err != nil {
+		s.log.Error().Err(err).Int("hrProfileID", rate.HRProfileID).Msg("EnqueueBadgeEvaluation failed")
+	}
+}
+
+// authorRate loads a rate and checks that employeeID wrote it and it still
+// needs verification.
+func (s *VerificationService) authorRate(ctx context.Context, employeeID int, rateID int) (*models.Rate, error) {
+	rate, err := s.hrRepo.GetRate(ctx, rateID)
+	if err != nil {
+		return nil, err
+	}
+	if rate.EmployeeID != employeeID {
+		return nil, repos.ErrRateForbidden
+	}
+	if rate.IsVerified {
+		return nil, ErrAlreadyVerified
+	}
+	return rate, nil
+}
+
+// StartEmailVerification sends a one-time code to a work email at the HR's company domain.
+func (s *VerificationService) StartEmailVerification(ctx context.Context, employeeID int, rateID int, email string) error {
+	rate, err := s.authorRate(ctx, employeeID, rateID)
+	if err != nil {
+		return err
+	}
+
+	profile, err := s.hrRepo.GetHRProfileByID(ctx, rate.HRProfileID)
+	if err != nil {
+		return err
+	}
+	domain := companyDomain(profile)
+	if domain == "" {
+		return ErrNoCompanyDomain
+	}
+	if got := emailDomain(email); got != domain && !strings.HasSuffix(got, "."+domain) {
+		return ErrEmailDomainMismatch
+	}
+	email = strings.ToLower(strings.TrimSpace(email))
+
+	// a work email proves employment for one account only
+	owner, err := s.repo.GetWorkEmailOwner(ctx, email)
+	if err != nil {
+		return err
+	}
+	if owner != 0 && owner != employeeID {
+		return repos.ErrWorkEmailTaken
+	}
+
+	// a new code keeps the failed attempts of the earlier ones, so asking for
+	// codes does not reset the guessing budget
+	issued, attempts, err := s.repo.GetEmailCodeUsage(ctx, rateID, time.Now().Add(-emailCodeWindow))
+	if err != nil {
+		return err
+	}
+	if issued >= maxEmailCodes {
+		return ErrTooManyVerifyCodes
+	}
+	if attempts >= maxEmailCodeAttempts {
+		return ErrTooManyVerifyAttempts
+	}
+
+	n, err := rand.Int(rand.Reader, big.NewInt(1000000))
+	if err != nil {
+		return fmt.Errorf("failed to generate verification code: %w", err)
+	}
+	code := fmt.Sprintf("%06d", n.Int64())
+	codeHash := hashSecret(fmt.Sprintf("%d:%s", rateID, code))
+	expiresAt := time.Now().Add(emailCodeTTL)
+
+	verification := &models.RateVerification{
+		RateID:     rateID,
+		EmployeeID: employeeID,
+		Method:     models.VerificationWorkEmail,
+		Status:     "pending",
+		Email:      &email,
+		CodeHash:   &codeHash,
+		Attempts:   attempts,
+		ExpiresAt:  &expiresAt,
+	}
+	if _, err := s.repo.CreateVerification(ctx, verification); err != nil {
+		s.log.Error().Err(err).Int("rateID", rateID).Msg("CreateVerification failed")
+		return err
+	}
+
+	body := fmt.Sprintf("Your verification code is %s. It expires in %d minutes.", code, int(emailCodeTTL.Minutes()))
+	return s.mailer.Send(ctx, email, "Verify your review", body)
+}
+
+// ConfirmEmailVerification checks the emailed code and marks the rate verified.
+func (s *VerificationService) ConfirmEmailVerification(ctx context.Context, employeeID int, rateID int, code string) error {
+	rate, err := s.authorRate(ctx, employeeID, rateID)
+	if err != nil {
+		return err
+	}
+
+	pending, err := s.repo.GetPendingEmailVerification(ctx, rateID)
+	if err != nil {
+		return err
+	}
+	if pending.Attempts >= maxEmailCodeAttempts {
+		return ErrTooManyVerifyAttempts
+	}
+
+	expected := []byte(*pending.CodeHash)
+	given := []byte(hashSecret(fmt.Sprintf("%d:%s", rateID, strings.TrimSpace(code))))
+	if subtle.ConstantTimeCompare(expected, given) != 1 {
+		if err := s.repo.IncrementVerificationAttempts(ctx, pending.ID); err != nil {
+			s.log.Error().Err(err).Int("verificationID", pending.ID).Msg("IncrementVerificationAttempts failed")
+		}
+		return ErrInvalidVerifyCode
+	}
+
+	if err := s.repo.CompleteVerification(ctx, pending.ID, true, nil, nil); err != nil {
+		return err
+	}
+	s.rateVerified(ctx, rate)
+	return nil
+}
+
+// SubmitDocument stores an employment document for moderator review. save
+// writes the uploaded file to the given destination.
+func (s *VerificationService) SubmitDocument(ctx context.Context, employeeID int, rateID int, filename string, save func(dst string) error) (*models.RateVerification, error) {
+	if _, err := s.authorRate(ctx, employeeID, rateID); err != nil {
+		return nil, err
+	}
+
+	dst := filepath.Join(s.uploadDir, uuid.NewString()+strings.ToLower(filepath.Ext(filename)))
+	if err := save(dst); err != nil {
+		return nil, fmt.Errorf("failed to store verification document: %w", err)
+	}
+
+	verification := &models.RateVerification{
+		RateID:       rateID,
+		EmployeeID:   employeeID,
+		Method:       models.VerificationDocument,
+		Status:       "pending",
+		DocumentPath: &dst,
+	}
+	if _, err := s.repo.CreateVerification(ctx, verification); err != nil {
+		s.log.Error().Err(err).Int("rateID", rateID).Msg("CreateVerification failed")
+		return nil, err
+	}
+	return verification, nil
+}
+
+// RedeemInviteToken verifies a rate with a token issued by the rated HR.
+func (s *VerificationService) RedeemInviteToken(ctx context.Context, employeeID int, rateID int, token string) error {
+	rate, err := s.authorRate(ctx, employeeID, rateID)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	verification := &models.RateVerification{
+		RateID:     rateID,
+		EmployeeID: employeeID,
+		Method:     models.VerificationInviteToken,
+		Status:     "verified",
+		VerifiedAt: &now,
+	}
+	if err := s.repo.RedeemInviteToken(ctx, hashSecret(strings.TrimSpace(token)), rate.HRProfileID, verification); err != nil {
+		return err
+	}
+	s.rateVerified(ctx, rate)
+	return nil
+}
+
+// ReviewDocument is the moderator decision on a document verification.
+func (s *VerificationService) ReviewDocument(ctx context.Context, moderatorID int, verificationID int, approve bool, note *string) error {
+	verification, err := s.repo.GetVerification(ctx, verificationID)
+	if err != nil {
+		return err
+	}
+	// emailed codes and invite tokens prove employment by themselves
+	if verification.Method != models.VerificationDocument {
+		return ErrNotDocumentReview
+	}
+
+	if err := s.repo.CompleteVerification(ctx, verificationID, approve, &moderatorID, note); err != nil {
+		s.log.Error().Err(err).Int("verificationID", verificationID).Msg("CompleteVerification failed")
+		return err
+	}
+
+	action := "verification.rejected"
+	if approve {
+		action = "verification.approved"
+	}
+	s.audit.Record(ctx, &moderatorID, action, "rate", verification.RateID,
+		map[string]interface{}{"verification_id": verificationID, "note": note})
+	if approve {
+		if rate, err := s.hrRepo.GetRate(ctx, verification.RateID); err == nil {
+			s.rateVerified(ctx, rate)
+		}
+	}
+
+	title := "Your employment proof was rejected"
+	if approve {
+		title = "Your review is now verified"
+	}
+	s.notifications.Notify(ctx, RecipientEmployee, verification.EmployeeID, "rate_verification", title,
+		map[string]interface{}{"rate_id": verification.RateID, "approved": approve})
+	return nil
+}
+
+func (s *VerificationService) GetVerifications(ctx context.Context, status string, pagination bootstrap.Pagination) ([]models.RateVerification, error) {
+	return s.repo.GetVerifications(ctx, status, pagination)
+}
+
+func (s *VerificationService) GetVerification(ctx context.Context, verificationID int) (*models.RateVerification, error) {
+	return s.repo.GetVerification(ctx, verificationID)
+}
+
+// CreateInviteToken issues a new token for the HR; the plain token is only
+// returned here, the database keeps its hash.
+func (s *VerificationService) CreateInviteToken(ctx context.Context, hrProfileID int, maxUses int, expiresInDays int) (*models.HRInviteToken, error) {
+	if maxUses <= 0 {
+		maxUses = 1
+	}
+
+	raw := make([]byte, 24)
+	if _, err := rand.Read(raw); err != nil {
+		return nil, fmt.Errorf("failed to generate invite token: %w", err)
+	}
+	plain := hex.EncodeToString(raw)
+
+	token := &models.HRInviteToken{
+		HRProfileID: hrProfileID,
+		TokenHash:   hashSecret(plain),
+		MaxUses:     maxUses,
+	}
+	if expiresInDays > 0 {
+		expiresAt := time.Now().AddDate(0, 0, expiresInDays)
+		token.ExpiresAt = &expiresAt
+	}
+
+	if _, err := s.repo.CreateInviteToken(ctx, token); err != nil {
+		s.log.Error().Err(err).Int("hrProfileID", hrProfileID).Msg("CreateInviteToken failed")
+		return nil, err
+	}
+	token.Token = plain
+	return token, nil
+}
+
+func (s *VerificationService) GetInviteTokens(ctx context.Context, hrProfileID int) ([]models.HRInviteToken, error) {
+	return s.repo.GetInviteTokens(ctx, hrProfileID)
+}
+
+func (s *VerificationService) RevokeInviteToken(ctx context.Context, hrProfileID int, tokenID int) (int, error) {
+	return s.repo.RevokeInviteToken(ctx, hrProfileID, tokenID)
+}
@@ -0,0 +1,408 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/rs/zerolog"
+	"githup.ahmedramadan.4cashier/internal/models"
+	"githup.ahmedramadan.4cashier/internal/repos"
+)
+
+// helpfulReviewLikes is the likes count at which a review counts as helpful
+// for achievements, as long as it has more likes than dislikes.
+const helpfulReviewLikes = 5
+
+// achievementQueueSize bounds the employees waiting for a background
+// evaluation; past it, events evaluate in place rather than being dropped.
+const achievementQueueSize = 1024
+
+// AchievementEvaluator هو العقد الذي يجب أن تتبعه كل قاعدة إنجاز للموظفين
+type AchievementEvaluator interface {
+	Evaluate(ctx context.Context, stats *models.AchievementStats) (*models.EmployeeAchievement, error)
+	Definition() models.AchievementDefinition
+	Progress(stats *models.AchievementStats) (current int, target int)
+}
+
+// DefaultAchievementEvaluators returns the achievements employees can earn.
+func DefaultAchievementEvaluators() []AchievementEvaluator {
+	return []AchievementEvaluator{
+		NewFirstReviewEvaluator(),
+		NewHelpfulReviewsEvaluator(10, 30),
+		NewVerifiedReviewerEvaluator(),
+		NewWeeklyStreakEvaluator(4, 20),
+		NewWeeklyStreakEvaluator(12, 60),
+		NewWeeklyStreakEvaluator(26, 150),
+	}
+}
+
+// reached builds the achievement once progress reaches target.
+func reached(definition models.AchievementDefinition, stats *models.AchievementStats, current int, target int, note string) *models.EmployeeAchievement {
+	if current < target {
+		return nil
+	}
+	return &models.EmployeeAchievement{
+		EmployeeID: stats.EmployeeID,
+		Code:       definition.Code,
+		Points:     definition.Points,
+		Note:       &note,
+		Definition: &definition,
+	}
+}
+
+// =================================================================
+// ✍️ تطبيق قاعدة إنجاز: First Review
+// =================================================================
+
+type FirstReviewEvaluator struct{}
+
+func NewFirstReviewEvaluator() AchievementEvaluator {
+	return &FirstReviewEvaluator{}
+}
+
+func (e *FirstReviewEvaluator) Definition() models.AchievementDefinition {
+	return models.AchievementDefinition{Code: "first_review", NameEn: "First Review", NameAr: "أول تقييم", Points: 5}
+}
+
+func (e *FirstReviewEvaluator) Progress(stats *models.AchievementStats) (int, int) {
+	return min(stats.Reviews, 1), 1
+}
+
+func (e *FirstReviewEvaluator) Evaluate(ctx context.Context, stats *models.AchievementStats) (*models.EmployeeAchievement, error) {
+	current, target := e.Progress(stats)
+	return reached(e.Definition(), stats, current, target, "Published a first signed review"), nil
+}
+
+// =================================================================
+// 👍 تطبيق قاعدة إنجاز: N Helpful Reviews
+// =================================================================
+
+type HelpfulReviewsEvaluator struct {
+	count  int
+	points int
+}
+
+func NewHelpfulReviewsEvaluator(count int, points int) AchievementEvaluator {
+	return &HelpfulReviewsEvaluator{count: count, points: points}
+}
+
+func (e *HelpfulReviewsEvaluator) Definition() models.AchievementDefinition {
+	return models.AchievementDefinition{
+		Code:   fmt.Sprintf("helpful_reviews_%d", e.count),
+		NameEn: fmt.Sprintf("%d Helpful Reviews", e.count),
+		NameAr: fmt.Sprintf("%d تقييمات مفيدة", e.count),
+		Points: e.points,
+	}
+}
+
+func (e *HelpfulReviewsEvaluator) Progress(stats *models.AchievementStats) (int, int) {
+	return min(stats.HelpfulReviews, e.count), e.count
+}
+
+func (e *HelpfulReviewsEvaluator) Evaluate(ctx context.Context, stats *models.AchievementStats) (*models.EmployeeAchievement, error) {
+	current, target := e.Progress(stats)
+	note := fmt.Sprintf("%d reviews with at least %d likes", stats.HelpfulReviews, helpfulReviewLikes)
+	return reached(e.Definition(), stats, current, target, note), nil
+}
+
+// =================================================================
+// ✅ تطبيق قاعدة إنجاز: Verified Reviewer
+// =================================================================
+
+type VerifiedReviewerEvaluator struct{}
+
+func NewVerifiedReviewerEvaluator() AchievementEvaluator {
+	return &VerifiedReviewerEvaluator{}
+}
+
+func (e *VerifiedReviewerEvaluator) Definition() models.AchievementDefinition {
+	return models.AchievementDefinition{Code: "verified_reviewer", NameEn: "Verified Reviewer", NameAr: "مُقيّم موثَّق", Points: 15}
+}
+
+func (e *VerifiedReviewerEvaluator) Progress(stats *models.AchievementStats) (int, int) {
+	return min(stats.VerifiedReviews, 1), 1
+}
+
+func (e *VerifiedReviewerEvaluator) Evaluate(ctx context.Context, stats *models.AchievementStats) (*models.EmployeeAchievement, error) {
+	current, target := e.Progress(stats)
+	return reached(e.Definition(), stats, current, target, "Published a review with verified employment"), nil
+}
+
+// =================================================================
+// 🔥 تطبيق قاعدة إنجاز: Weekly Contribution Streak
+// =================================================================
+
+type WeeklyStreakEvaluator struct {
+	weeks  int
+	points int
+}
+
+func NewWeeklyStreakEvaluator(weeks int, points int) AchievementEvaluator {
+	return &WeeklyStreakEvaluator{weeks: weeks, points: points}
+}
+
+func (e *WeeklyStreakEvaluator) Definition() models.AchievementDefinition {
+	return models.AchievementDefinition{
+		Code:   fmt.Sprintf("weekly_streak_%d", e.weeks),
+		NameEn: fmt.Sprintf("%d-Week Streak", e.weeks),
+		NameAr: fmt.Sprintf("مساهمة %d أسابيع متتالية", e.weeks),
+		Points: e.points,
+	}
+}
+
+// Progress follows the current streak; the achievement itself goes by the
+// longest one, so a streak that ended still earns what it reached.
+func (e *WeeklyStreakEvaluator) Progress(stats *models.AchievementStats) (int, int) {
+	return min(stats.CurrentStreak, e.weeks), e.weeks
+}
+
+func (e *WeeklyStreakEvaluator) Evaluate(ctx context.Context, stats *models.AchievementStats) (*models.EmployeeAchievement, error) {
+	note := fmt.Sprintf("Contributed %d weeks in a row", stats.LongestStreak)
+	return reached(e.Definition(), stats, stats.LongestStreak, e.weeks, note), nil
+}
+
+// =================================================================
+// 🏅 خدمة إنجازات الموظفين
+// =================================================================
+
+// AchievementService evaluates employee achievements on contribution events,
+// awards their points through the ledger and lists them on the profile. Once
+// started, events are evaluated by a background worker.
+type AchievementService struct {
+	log           zerolog.Logger
+	repo          repos.HRRepository
+	evaluators    []AchievementEvaluator
+	notifications *NotificationService
+	levels        *ContributorLevels // achievement points count towards the level; may be nil
+
+	mu      sync.Mutex
+	queue   chan int     // nil until Start
+	pending map[int]bool // employees waiting in the queue
+}
+
+// NewAchievementService creates a new instance of AchievementService.
+func NewAchievementService(log zerolog.Logger, repo repos.HRRepository, notifications *NotificationService, levels *ContributorLevels,
+	evaluators ...AchievementEvaluator) *AchievementService {
+	if len(evaluators) == 0 {
+		evaluators = DefaultAchievementEvaluators()
+	}
+	return &AchievementService{
+		log:           log.With().Str("layer", "service").Str("component", "AchievementService").Logger(),
+		repo:          repo,
+		evaluators:    evaluators,
+		notifications: notifications,
+		levels:        levels,
+		pending:       make(map[int]bool),
+	}
+}
+
+// Start runs the background evaluation worker until ctx is cancelled. Tools
+// that never start it evaluate in place.
+func (s *AchievementService) Start(ctx context.Context) {
+	queue := make(chan int, achievementQueueSize)
+	s.mu.Lock()
+	s.queue = queue
+	s.mu.Unlock()
+
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case employeeID := <-queue:
+				// يُحذف قبل التقييم كي يُعاد وضعه في الطابور إذا جاء حدث أثناءه
+				s.mu.Lock()
+				delete(s.pending, employeeID)
+				s.mu.Unlock()
+				s.award(ctx, employeeID)
+			}
+		}
+	}()
+}
+
+// enqueue queues an evaluation of an employee, so the event that triggered it
+// (a vote, a review) does not wait for the streak and stats queries. An
+// employee already waiting is not queued twice.
+func (s *AchievementService) enqueue(ctx context.Context, employeeID int) {
+	if s == nil || employeeID <= 0 {
+		return
+	}
+
+	s.mu.Lock()
+	if s.queue != nil && s.pending[employeeID] {
+		s.mu.Unlock()
+		return
+	}
+	queued := false
+	if s.queue != nil {
+		select {
+		case s.queue <- employeeID:
+			s.pending[employeeID] = true
+			queued = true
+		default:
+			s.log.Warn().Int("employeeID", employeeID).Msg("achievement queue is full, evaluating in place")
+		}
+	}
+	s.mu.Unlock()
+
+	if !queued {
+		s.award(ctx, employeeID)
+	}
+}
+
+// award evaluates an employee and refreshes their level when new achievement
+// points came in.
+func (s *AchievementService) award(ctx context.Context, employeeID int) {
+	if s.evaluate(ctx, employeeID) {
+		s.levels.refresh(ctx, employeeID)
+	}
+}
+
+// Catalog lists every achievement employees can earn.
+func (s *AchievementService) Catalog() []models.AchievementDefinition {
+	catalog := make([]models.AchievementDefinition, 0, len(s.evaluators))
+	for _, evaluator := range s.evaluators {
+		catalog = append(catalog, evaluator.Definition())
+	}
+	return catalog
+}
+
+// Evaluate awards an employee every achievement they reached and do not hold
+// yet, and returns the new ones.
+func (s *AchievementService) Evaluate(ctx context.Context, employeeID int) ([]models.EmployeeAchievement, error) {
+	stats, err := s.repo.GetAchievementStats(ctx, employeeID, helpfulReviewLikes)
+	if err != nil {
+		if !errors.Is(err, repos.ErrEmployeeNotFound) {
+			s.log.Error().Err(err).Int("employeeID", employeeID).Msg("GetAchievementStats failed")
+		}
+		return nil, err
+	}
+	held, err := s.repo.GetEmployeeAchievements(ctx, employeeID)
+	if err != nil {
+		s.log.Error().Err(err).Int("employeeID", employeeID).Msg("GetEmployeeAchievements failed")
+		return nil, err
+	}
+	holds := make(map[string]bool, len(held))
+	for _, achievement := range held {
+		holds[achievement.Code] = true
+	}
+
+	awarded := []models.EmployeeAchievement{}
+	for _, evaluator := range s.evaluators {
+		definition := evaluator.Definition()
+		if holds[definition.Code] {
+			continue
+		}
+		achievement, err := evaluator.Evaluate(ctx, stats)
+		if err != nil {
+			s.log.Error().Err(err).Int("employeeID", employeeID).Str("achievement", definition.Code).Msg("evaluating achievement failed")
+			continue
+		}
+		if achievement == nil {
+			continue
+		}
+
+		if err := s.repo.AwardAchievement(ctx, achievement); err != nil {
+			// حدث متزامن منحه قبلنا
+			if errors.Is(err, repos.ErrAchievementAlreadyAwarded) {
+				continue
+			}
+			s.log.Error().Err(err).Int("employeeID", employeeID).Str("achievement", definition.Code).Msg("AwardAchievement failed")
+			return awarded, err
+		}
+		awarded = append(awarded, *achievement)
+
+		s.log.Info().Int("employeeID", employeeID).Str("achievement", definition.Code).Int("points", definition.Points).Msg("achievement awarded")
+		s.notifications.Notify(ctx, RecipientEmployee, employeeID, "achievement_unlocked", "You unlocked an achievement: "+definition.NameEn,
+			map[string]interface{}{"achievement": definition, "points": definition.Points})
+	}
+	return awarded, nil
+}
+
+// evaluate is Evaluate after an event that already succeeded: failures are
+// logged and the next event catches up. It reports whether anything was awarded.
+func (s *AchievementService) evaluate(ctx context.Context, employeeID int) bool {
+	if s == nil || employeeID <= 0 {
+		return false
+	}
+	awarded, _ := s.Evaluate(ctx, employeeID)
+	return len(awarded) > 0
+}
+
+// EvaluateAll evaluates every employee, for achievements added to the catalog
+// and past activity. It returns how many achievements were awarded.
+func (s *AchievementService) EvaluateAll(ctx context.Context) (int, error) {
+	ids, err := s.repo.GetEmployeeIDs(ctx)
+	if err != nil {
+		s.log.Error().Err(err).Msg("GetEmployeeIDs failed")
+		return 0, err
+	}
+
+	count := 0
+	for _, id := range ids {
+		awarded, err := s.Evaluate(ctx, id)
+		count += len(awarded)
+		if err != nil && !errors.Is(err, repos.ErrEmployeeNotFound) {
+			return count, err
+		}
+	}
+	return count, nil
+}
+
+// GetEarned returns the achievements an employee holds, newest first.
+func (s *AchievementService) GetEarned(ctx context.Context, employeeID int) ([]models.EmployeeAchievement, error) {
+	earned, err := s.repo.GetEmployeeAchievements(ctx, employeeID)
+	if err != nil {
+		s.log.Error().Err(err).Int("employeeID", employeeID).Msg("GetEmployeeAchievements failed")
+		return nil, err
+	}
+	definitions := make(map[string]models.AchievementDefinition, len(s.evaluators))
+	for _, definition := range s.Catalog() {
+		definitions[definition.Code] = definition
+	}
+	for i := range earned {
+		if definition, ok := definitions[earned[i].Code]; ok {
+			earned[i].Definition = &definition
+		}
+	}
+	return earned, nil
+}
+
+// GetEmployeeAchievements returns the achievements section of an employee
+// profile: what they earned, their progress on the rest and their streak.
+func (s *AchievementService) GetEmployeeAchievements(ctx context.Context, employeeID int) (*models.EmployeeAchievements, error) {
+	stats, err := s.repo.GetAchievementStats(ctx, employeeID, helpfulReviewLikes)
+	if err != nil {
+		if !errors.Is(err, repos.ErrEmployeeNotFound) {
+			s.log.Error().Err(err).Int("employeeID", employeeID).Msg("GetAchievementStats failed")
+		}
+		return nil, err
+	}
+	earned, err := s.GetEarned(ctx, employeeID)
+	if err != nil {
+		return nil, err
+	}
+	holds := make(map[string]bool, len(earned))
+	for _, achievement := range earned {
+		holds[achievement.Code] = true
+	}
+
+	locked := []models.AchievementProgress{}
+	for _, evaluator := range s.evaluators {
+		definition := evaluator.Definition()
+		if holds[definition.Code] {
+			continue
+		}
+		current, target := evaluator.Progress(stats)
+		locked = append(locked, models.AchievementProgress{Definition: definition, Progress: current, Target: target})
+	}
+
+	return &models.EmployeeAchievements{
+		EmployeeID: employeeID,
+		Earned:     earned,
+		Locked:     locked,
+		Streak:     models.ContributionStreak{CurrentWeeks: stats.CurrentStreak, LongestWeeks: stats.LongestStreak},
+	}, nil
+}
@@ -2,58 +2,353 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"slices"
+	"strings"
 	"time"
 
+	"githup.ahmedramadan.4cashier/internal/badgerules"
 	"githup.ahmedramadan.4cashier/internal/models"
 	"githup.ahmedramadan.4cashier/internal/repos"
 )
 
-// BadgeEvaluator هو العقد الذي يجب أن تتبعه كل قاعدة إنجاز
-type BadgeEvaluator interface {
-	Evaluate(ctx context.Context, profile *models.HRProfile) (*models.Badge, error)
-	Name() string
-}
-
 // =================================================================
-// ⭐️ تطبيق قاعدة شارة: Top Rated HR
+// 🏅 محرك الشارات: قواعد تصريحية مخزنة في كتالوج الشارات
 // =================================================================
 
-type TopRatedBadgeEvaluator struct {
-	repo repos.HRRepository
+var (
+	ErrInvalidBadgeRule         = errors.New("invalid badge rule")
+	ErrInvalidBadgeAvailability = errors.New("available_until must be after available_from")
+)
+
+const (
+	defaultDryRunLimit = 50
+	maxDryRunLimit     = 500
+)
+
+// badgeRule is an active catalog entry with its parsed rule.
+type badgeRule struct {
+	definition models.BadgeDefinition
+	rule       *badgerules.Rule
+}
+
+// validateBadgeDefinition checks the rule and the seasonal window before a
+// definition is stored; an empty rule means the badge is only awarded manually.
+func validateBadgeDefinition(definition *models.BadgeDefinition) error {
+	if definition.AvailableFrom != nil && definition.AvailableUntil != nil && !definition.AvailableUntil.After(*definition.AvailableFrom) {
+		return ErrInvalidBadgeAvailability
+	}
+	if definition.Rule == nil {
+		return nil
+	}
+	trimmed := strings.TrimSpace(*definition.Rule)
+	if trimmed == "" {
+		definition.Rule = nil
+		return nil
+	}
+	if _, err := badgerules.Parse(trimmed); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidBadgeRule, err)
+	}
+	definition.Rule = &trimmed
+	return nil
+}
+
+// loadBadgeRules returns the active definitions that have a rule. A stored rule
+// that no longer parses (e.g. a metric was removed) is logged and skipped.
+func (s *HRService) loadBadgeRules(ctx context.Context) ([]badgeRule, error) {
+	definitions, err := s.repo.GetBadgeDefinitions(ctx, true)
+	if err != nil {
+		return nil, err
+	}
+	var rules []badgeRule
+	for _, definition := range definitions {
+		if definition.Rule == nil || strings.TrimSpace(*definition.Rule) == "" {
+			continue
+		}
+		rule, err := badgerules.Parse(*definition.Rule)
+		if err != nil {
+			s.log.Warn().Err(err).Str("badge", definition.Code).Msg("skipping badge with invalid rule")
+			continue
+		}
+		rules = append(rules, badgeRule{definition: definition, rule: rule})
+	}
+	return rules, nil
+}
+
+// ruleMetrics is the union of the metrics read by the rules.
+func ruleMetrics(rules ...*badgerules.Rule) []badgerules.Metric {
+	seen := map[string]bool{}
+	var metrics []badgerules.Metric
+	for _, rule := range rules {
+		for _, metric := range rule.Metrics() {
+			if !seen[metric.Key()] {
+				seen[metric.Key()] = true
+				metrics = append(metrics, metric)
+			}
+		}
+	}
+	return metrics
+}
+
+// snapshotMetrics are recorded on every awarded badge.
+var snapshotMetrics = []badgerules.Metric{{Name: "total_rates_count"}, {Name: "rate"}, {Name: "weighted_rate"}}
+
+// badgeProgressEvery is how many profiles a full run evaluates between progress updates.
+const badgeProgressEvery = 200
+
+// badgeRunStats is the progress of an evaluation run.
+type badgeRunStats struct {
+	Total, Processed, Awarded, Revoked int
+}
+
+// evaluateBadges evaluates every badge rule against one profile, or every
+// profile when profileID is 0: it awards the badges newly earned, revokes the
+// ones whose rule failed for longer than the grace period, and first moves
+// expired badges to the history. Awarding is idempotent: a badge already held
+// is skipped. progress, when set, is called as the run advances.
+func (s *HRService) evaluateBadges(ctx context.Context, profileID int, progress func(badgeRunStats)) (badgeRunStats, error) {
+	var stats badgeRunStats
+	expired, err := s.repo.ExpireBadges(ctx)
+	if err != nil {
+		return stats, err
+	}
+	s.notifyBadgeChanges(ctx, expired)
+	stats.Revoked += len(expired)
+
+	rules, err := s.loadBadgeRules(ctx)
+	if err != nil || len(rules) == 0 {
+		return stats, err
+	}
+
+	parsed := make([]*badgerules.Rule, len(rules))
+	for i := range rules {
+		parsed[i] = rules[i].rule
+	}
+	metrics := ruleMetrics(parsed...)
+	for _, metric := range snapshotMetrics {
+		if !slices.ContainsFunc(metrics, func(m badgerules.Metric) bool { return m.Key() == metric.Key() }) {
+			metrics = append(metrics, metric)
+		}
+	}
+	profiles, err := s.repo.GetBadgeMetrics(ctx, metrics, profileID)
+	if err != nil {
+		return stats, err
+	}
+	stats.Total = len(profiles)
+
+	holders := make([]map[int]models.Badge, len(rules))
+	for i := range rules {
+		if holders[i], err = s.repo.GetBadgeHolders(ctx, rules[i].definition.ID, profileID); err != nil {
+			return stats, err
+		}
+	}
+
+	now := time.Now()
+	for n, profile := range profiles {
+		if progress != nil && n > 0 && n%badgeProgressEvery == 0 {
+			stats.Processed = n
+			progress(stats)
+		}
+		for i := range rules {
+			holds := rules[i].rule.Evaluate(profile.Values)
+			if badge, ok := holders[i][profile.HRProfileID]; ok {
+				revoked, err := s.checkHeldBadge(ctx, &rules[i], &badge, holds, now)
+				if err != nil {
+					return stats, err
+				}
+				if revoked {
+					stats.Revoked++
+				}
+				continue
+			}
+			if !holds || !rules[i].definition.IsAvailable(now) {
+				continue
+			}
+			if err := s.awardRuleBadge(ctx, &rules[i], profile); err != nil {
+				if errors.Is(err, repos.ErrBadgeAlreadyAwarded) {
+					continue
+				}
+				return stats, err
+			}
+			stats.Awarded++
+		}
+	}
+	stats.Processed = len(profiles)
+	if progress != nil {
+		progress(stats)
+	}
+	return stats, nil
+}
+
+// checkHeldBadge applies the grace period of a held badge: a failing rule
+// starts it, a holding rule clears it, and the badge is revoked once it has
+// run out. Definitions without a grace period never revoke automatically.
+func (s *HRService) checkHeldBadge(ctx context.Context, rule *badgeRule, badge *models.Badge, holds bool, now time.Time) (bool, error) {
+	grace := rule.definition.RevokeGraceDays
+	switch {
+	case holds && badge.CriteriaFailingSince != nil:
+		return false, s.repo.SetBadgeCriteriaFailing(ctx, badge.ID, false)
+	case holds || grace == nil:
+		return false, nil
+	}
+
+	since := now
+	if badge.CriteriaFailingSince != nil {
+		since = *badge.CriteriaFailingSince
+	}
+	deadline := since.AddDate(0, 0, *grace)
+	if now.Before(deadline) {
+		if badge.CriteriaFailingSince != nil {
+			return false, nil
+		}
+		if err := s.repo.SetBadgeCriteriaFailing(ctx, badge.ID, true); err != nil {
+			return false, err
+		}
+		s.notifications.Notify(ctx, RecipientHR, badge.HRProfileID, "badge_at_risk",
+			"Your "+rule.definition.NameEn+" badge will be removed unless you meet its criteria again",
+			map[string]interface{}{"badge_id": badge.ID, "definition_id": rule.definition.ID, "revoke_at": deadline})
+		return false, nil
+	}
+
+	reason := "No longer meets: " + rule.rule.String()
+	revoked, err := s.repo.RevokeBadge(ctx, badge.ID, models.BadgeRevokedCriteria, &reason, nil)
+	if errors.Is(err, repos.ErrBadgeNotFound) {
+		return false, nil // revoked meanwhile
+	}
+	if err != nil {
+		return false, err
+	}
+	s.notifyBadgeChanges(ctx, []models.Badge{*revoked})
+	return true, nil
+}
+
+// badgeExpiry is when a badge awarded at now expires, from the definition's validity.
+func badgeExpiry(definition *models.BadgeDefinition, now time.Time) *time.Time {
+	if definition.ValidityDays == nil {
+		return nil
+	}
+	expiresAt := now.AddDate(0, 0, *definition.ValidityDays)
+	return &expiresAt
+}
+
+func (s *HRService) awardRuleBadge(ctx context.Context, rule *badgeRule, profile models.BadgeMetrics) error {
+	rate := profile.Values["weighted_rate"]
+	if rate == 0 {
+		rate = profile.Values["rate"]
+	}
+	now := time.Now()
+	note := "Met rule: " + rule.rule.String()
+	badge := models.Badge{
+		HRProfileID:  profile.HRProfileID,
+		DefinitionID: rule.definition.ID,
+		CreatedDate:  now,
+		TotalRates:   int(profile.Values["total_rates_count"]),
+		Rate:         float32(rate),
+		AwardNote:    &note,
+		ExpiresAt:    badgeExpiry(&rule.definition, now),
+	}
+	if _, err := s.repo.AwardBadge(ctx, &badge); err != nil {
+		return err
+	}
+	s.embeds.Invalidate(profile.HRProfileID)
+
+	s.notifications.Notify(ctx, RecipientHR, profile.HRProfileID, "badge_awarded", "You earned the "+rule.definition.NameEn+" badge",
+		map[string]interface{}{"badge_id": badge.ID, "definition_id": rule.definition.ID, "code": rule.definition.Code, "expires_at": badge.ExpiresAt})
+	return nil
 }
 
-func NewTopRatedBadgeEvaluator(repo repos.HRRepository) BadgeEvaluator {
-	return &TopRatedBadgeEvaluator{repo: repo}
+// notifyBadgeChanges tells the HRs that badges left their active badges.
+func (s *HRService) notifyBadgeChanges(ctx context.Context, badges []models.Badge) {
+	if len(badges) == 0 {
+		return
+	}
+	definitions, err := s.badgeDefinitionsByID(ctx)
+	if err != nil {
+		s.log.Error().Err(err).Msg("failed to load badge definitions for notifications")
+		return
+	}
+	for _, badge := range badges {
+		s.embeds.Invalidate(badge.HRProfileID)
+		name := "badge"
+		if definition, ok := definitions[badge.DefinitionID]; ok {
+			name = definition.NameEn + " badge"
+		}
+		kind, title := "badge_revoked", "Your "+name+" was removed"
+		if badge.RevokeKind != nil && *badge.RevokeKind == models.BadgeRevokedExpired {
+			kind, title = "badge_expired", "Your "+name+" has expired"
+		}
+		s.notifications.Notify(ctx, RecipientHR, badge.HRProfileID, kind, title,
+			map[string]interface{}{"badge_id": badge.ID, "definition_id": badge.DefinitionID, "reason": badge.RevokeReason})
+	}
 }
 
-func (b *TopRatedBadgeEvaluator) Name() string {
-	return "Top Rated HR"
+func (s *HRService) badgeDefinitionsByID(ctx context.Context) (map[int]*models.BadgeDefinition, error) {
+	definitions, err := s.repo.GetBadgeDefinitions(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[int]*models.BadgeDefinition, len(definitions))
+	for i := range definitions {
+		byID[definitions[i].ID] = &definitions[i]
+	}
+	return byID, nil
 }
 
-func (b *TopRatedBadgeEvaluator) Evaluate(ctx context.Context, profile *models.HRProfile) (*models.Badge, error) {
-	// قاعدة العمل: 50 تقييم على الأقل ومتوسط 4.5 أو أعلى
-	if profile.TotalRatesCount >= 50 && *profile.Rate >= 4.5 {
-		
-		// تأكد من عدم تكرار الشارة (باستخدام الـ Repository)
-		hasBadge, err := b.repo.CheckIfProfileHasBadge(ctx, profile.ID, b.Name())
+// DryRunBadgeRule evaluates a rule against every profile without awarding
+// anything. With a definition id the rule defaults to the stored one and the
+// result tells which profiles already hold that badge.
+func (s *HRService) DryRunBadgeRule(ctx context.Context, source string, definitionID *int, limit int) (*models.BadgeDryRun, error) {
+	holders := map[int]models.Badge{}
+	if definitionID != nil {
+		definition, err := s.repo.GetBadgeDefinition(ctx, *definitionID)
 		if err != nil {
 			return nil, err
 		}
-		
-		if !hasBadge {
-			// بناء الشارة
-			badge := &models.Badge{
-				HRProfileID:     profile.ID,
-				CreatedDate:     time.Now(),
-				TotalRates:      profile.TotalRatesCount,
-				Rate:            *profile.Rate,
-				JobPosition:     b.Name(), 
-				CurrentJobRoles: fmt.Sprintf("Achieved %d rates with %.2f avg", profile.TotalRatesCount, profile.Rate),
-			}
-			return badge, nil
+		if strings.TrimSpace(source) == "" && definition.Rule != nil {
+			source = *definition.Rule
+		}
+		if holders, err = s.repo.GetBadgeHolders(ctx, *definitionID, 0); err != nil {
+			return nil, err
 		}
 	}
-	
-	return nil, nil
-}
\ No newline at end of file
+	if strings.TrimSpace(source) == "" {
+		return nil, fmt.Errorf("%w: rule is empty", ErrInvalidBadgeRule)
+	}
+	rule, err := badgerules.Parse(source)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidBadgeRule, err)
+	}
+
+	if limit <= 0 {
+		limit = defaultDryRunLimit
+	}
+	limit = min(limit, maxDryRunLimit)
+
+	metrics, err := s.repo.GetBadgeMetrics(ctx, rule.Metrics(), 0)
+	if err != nil {
+		s.log.Error().Err(err).Str("rule", rule.String()).Msg("DryRunBadgeRule failed")
+		return nil, err
+	}
+
+	result := &models.BadgeDryRun{
+		Rule:            rule.String(),
+		DefinitionID:    definitionID,
+		ProfilesChecked: len(metrics),
+		Profiles:        []models.BadgeDryRunProfile{},
+	}
+	for _, m := range metrics {
+		if !rule.Evaluate(m.Values) {
+			continue
+		}
+		result.QualifyingCount++
+		_, awarded := holders[m.HRProfileID]
+		if !awarded {
+			result.NewAwardsCount++
+		}
+		if len(result.Profiles) < limit {
+			result.Profiles = append(result.Profiles, models.BadgeDryRunProfile{BadgeMetrics: m, AlreadyAwarded: awarded})
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,153 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/rs/zerolog"
+	"githup.ahmedramadan.4cashier/internal/bootstrap"
+	"githup.ahmedramadan.4cashier/internal/models"
+)
+
+// =================================================================
+// ⏳ تقييم الشارات في الخلفية: أحداث + إعادة تقييم دورية
+// =================================================================
+
+// queueBadgeEvaluation queues a badge evaluation after a domain event. The
+// event itself already succeeded, so a failure is only logged; the scheduled
+// full run catches up.
+func (s *HRService) queueBadgeEvaluation(ctx context.Context, profileID *int, trigger string) {
+	if _, err := s.repo.EnqueueBadgeEvaluation(ctx, profileID, trigger); err != nil {
+		s.log.Error().Err(err).Str("trigger", trigger).Msg("EnqueueBadgeEvaluation failed")
+	}
+}
+
+// QueueBadgeBackfill queues a re-evaluation of every profile and returns the
+// job to follow its progress.
+func (s *HRService) QueueBadgeBackfill(ctx context.Context, trigger string) (*models.BadgeEvaluationJob, error) {
+	jobID, err := s.repo.EnqueueBadgeEvaluation(ctx, nil, trigger)
+	if err != nil {
+		s.log.Error().Err(err).Msg("QueueBadgeBackfill failed")
+		return nil, err
+	}
+	return s.repo.GetBadgeEvaluationJob(ctx, jobID)
+}
+
+func (s *HRService) GetBadgeEvaluationJob(ctx context.Context, jobID int64) (*models.BadgeEvaluationJob, error) {
+	return s.repo.GetBadgeEvaluationJob(ctx, jobID)
+}
+
+func (s *HRService) GetBadgeEvaluationJobs(ctx context.Context, status string, pagination bootstrap.Pagination) ([]models.BadgeEvaluationJob, error) {
+	return s.repo.GetBadgeEvaluationJobs(ctx, status, pagination)
+}
+
+// RunNextBadgeJob claims and runs the oldest pending job. It reports false
+// when the queue is empty.
+func (s *HRService) RunNextBadgeJob(ctx context.Context) (bool, error) {
+	job, err := s.repo.ClaimBadgeEvaluationJob(ctx)
+	if err != nil || job == nil {
+		return false, err
+	}
+
+	profileID := 0
+	if job.HRProfileID != nil {
+		profileID = *job.HRProfileID
+	}
+	progress := func(stats badgeRunStats) {
+		if err := s.repo.UpdateBadgeEvaluationProgress(ctx, job.ID, stats.Total, stats.Processed, stats.Awarded, stats.Revoked); err != nil {
+			s.log.Warn().Err(err).Int64("jobID", job.ID).Msg("UpdateBadgeEvaluationProgress failed")
+		}
+	}
+
+	stats, runErr := s.evaluateBadges(ctx, profileID, progress)
+	var jobErr *string
+	if runErr != nil {
+		msg := runErr.Error()
+		jobErr = &msg
+		s.log.Error().Err(runErr).Int64("jobID", job.ID).Int("profileID", profileID).Msg("badge evaluation failed")
+	} else if profileID == 0 || stats.Awarded+stats.Revoked > 0 {
+		s.log.Info().Int64("jobID", job.ID).Str("trigger", job.Trigger).Int("profileID", profileID).
+			Int("awarded", stats.Awarded).Int("revoked", stats.Revoked).Msg("badge evaluation finished")
+	}
+
+	if err := s.repo.FinishBadgeEvaluationJob(ctx, job.ID, jobErr); err != nil {
+		return true, err
+	}
+	return true, nil
+}
+
+// BadgeWorker runs queued badge evaluations in the background and queues a
+// full re-evaluation on a schedule, so rule changes and time-windowed metrics
+// reach every profile even when nobody rates them.
+type BadgeWorker struct {
+	log    zerolog.Logger
+	hr     *HRService
+	config bootstrap.BadgeWorkerConfig
+}
+
+func NewBadgeWorker(log zerolog.Logger, hr *HRService, config bootstrap.BadgeWorkerConfig) *BadgeWorker {
+	return &BadgeWorker{
+		log:    log.With().Str("layer", "service").Str("component", "BadgeWorker").Logger(),
+		hr:     hr,
+		config: config,
+	}
+}
+
+// Start runs the worker until ctx is cancelled.
+func (w *BadgeWorker) Start(ctx context.Context) {
+	if !w.config.Enabled {
+		w.log.Info().Msg("badge worker disabled")
+		return
+	}
+	go w.run(ctx)
+}
+
+func (w *BadgeWorker) run(ctx context.Context) {
+	poll := time.NewTicker(w.config.PollInterval)
+	defer poll.Stop()
+
+	var schedule <-chan time.Time
+	if w.config.FullRunInterval > 0 {
+		ticker := time.NewTicker(w.config.FullRunInterval)
+		defer ticker.Stop()
+		schedule = ticker.C
+	}
+
+	w.failStaleJobs(ctx)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-schedule:
+			w.hr.queueBadgeEvaluation(ctx, nil, models.BadgeTriggerSchedule)
+			w.failStaleJobs(ctx)
+		case <-poll.C:
+			w.drain(ctx)
+		}
+	}
+}
+
+// drain runs jobs until the queue is empty.
+func (w *BadgeWorker) drain(ctx context.Context) {
+	for ctx.Err() == nil {
+		ran, err := w.hr.RunNextBadgeJob(ctx)
+		if err != nil {
+			w.log.Error().Err(err).Msg("RunNextBadgeJob failed")
+			return
+		}
+		if !ran {
+			return
+		}
+	}
+}
+
+func (w *BadgeWorker) failStaleJobs(ctx context.Context) {
+	count, err := w.hr.repo.FailStaleBadgeJobs(ctx, w.config.StaleMinutes)
+	if err != nil {
+		w.log.Error().Err(err).Msg("FailStaleBadgeJobs failed")
+		return
+	}
+	if count > 0 {
+		w.log.Warn().Int("jobs", count).Msg("failed interrupted badge evaluation jobs")
+	}
+}
@@ -0,0 +1,172 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/rs/zerolog"
+	"githup.ahmedramadan.4cashier/internal/bootstrap"
+	"githup.ahmedramadan.4cashier/internal/models"
+	"githup.ahmedramadan.4cashier/internal/repos"
+)
+
+// =================================================================
+// 🎖️ مستويات المساهمين وما تفتحه من صلاحيات
+// =================================================================
+
+// ContributorLevels decides the level of employees from the points ledger,
+// the quality of their reviews and verification, and tells the other services
+// what a level unlocks.
+type ContributorLevels struct {
+	log           zerolog.Logger
+	repo          repos.HRRepository
+	config        bootstrap.LevelConfig
+	notifications *NotificationService
+	embeds        *EmbedService // level changes move HR scores; may be nil
+}
+
+// NewContributorLevels creates a new instance of ContributorLevels.
+func NewContributorLevels(log zerolog.Logger, repo repos.HRRepository, config bootstrap.LevelConfig,
+	notifications *NotificationService, embeds *EmbedService) *ContributorLevels {
+	return &ContributorLevels{
+		log:           log.With().Str("layer", "service").Str("component", "ContributorLevels").Logger(),
+		repo:          repo,
+		config:        config,
+		notifications: notifications,
+		embeds:        embeds,
+	}
+}
+
+// LevelFor returns the highest level the metrics reach.
+func (l *ContributorLevels) LevelFor(metrics models.ContributorMetrics) string {
+	switch {
+	case l.meets(metrics, l.config.Expert):
+		return models.LevelExpert
+	case l.meets(metrics, l.config.Trusted):
+		return models.LevelTrusted
+	default:
+		return models.LevelNewcomer
+	}
+}
+
+func (l *ContributorLevels) meets(metrics models.ContributorMetrics, requirement bootstrap.LevelRequirement) bool {
+	if metrics.Points < requirement.Points || metrics.Reviews < requirement.Reviews ||
+		metrics.RejectedReviews > requirement.MaxRejected || (requirement.Verified && !metrics.Verified) {
+		return false
+	}
+	// الجودة تُحكم بعد عدد كافٍ من الأصوات فقط
+	votes := metrics.HelpfulVotes + metrics.UnhelpfulVotes
+	if votes >= l.config.MinVotes && votes > 0 {
+		return float64(metrics.HelpfulVotes)/float64(votes) >= requirement.MinHelpfulness
+	}
+	return true
+}
+
+// Privileges returns what a level unlocks; newcomers have none.
+func (l *ContributorLevels) Privileges(level string) models.ContributorPrivileges {
+	var privileges bootstrap.LevelPrivileges
+	switch level {
+	case models.LevelExpert:
+		privileges = l.config.Expert.Privileges
+	case models.LevelTrusted:
+		privileges = l.config.Trusted.Privileges
+	default:
+		return models.ContributorPrivileges{ReviewWeight: 1, ReportWeight: 1}
+	}
+	return models.ContributorPrivileges{
+		ReviewWeight:       privileges.ReviewWeight,
+		ReportWeight:       privileges.ReportWeight,
+		SkipModerationHold: privileges.SkipModerationHold,
+	}
+}
+
+// PrivilegesOf returns the stored level of an employee with what it unlocks.
+func (l *ContributorLevels) PrivilegesOf(ctx context.Context, employeeID int) (string, models.ContributorPrivileges, error) {
+	level, err := l.repo.GetContributorLevel(ctx, employeeID)
+	if err != nil {
+		return models.LevelNewcomer, l.Privileges(models.LevelNewcomer), err
+	}
+	return level, l.Privileges(level), nil
+}
+
+// Refresh recomputes the level of an employee and stores it when it changed.
+func (l *ContributorLevels) Refresh(ctx context.Context, employeeID int) (string, error) {
+	metrics, err := l.repo.GetContributorMetrics(ctx, employeeID)
+	if err != nil {
+		if !errors.Is(err, repos.ErrEmployeeNotFound) {
+			l.log.Error().Err(err).Int("employeeID", employeeID).Msg("GetContributorMetrics failed")
+		}
+		return "", err
+	}
+	return l.apply(ctx, *metrics)
+}
+
+// refresh is Refresh after an action that already succeeded: failures are
+// logged and the next refresh catches up.
+func (l *ContributorLevels) refresh(ctx context.Context, employeeID int) {
+	if l == nil || employeeID <= 0 {
+		return
+	}
+	_, _ = l.Refresh(ctx, employeeID)
+}
+
+// RecomputeAll re-evaluates every employee, for threshold changes. It returns
+// how many levels changed.
+func (l *ContributorLevels) RecomputeAll(ctx context.Context) (int, error) {
+	all, err := l.repo.GetAllContributorMetrics(ctx)
+	if err != nil {
+		l.log.Error().Err(err).Msg("GetAllContributorMetrics failed")
+		return 0, err
+	}
+
+	changed := 0
+	for _, metrics := range all {
+		level, err := l.apply(ctx, metrics)
+		if err != nil {
+			return changed, err
+		}
+		if level != metrics.Level {
+			changed++
+		}
+	}
+	return changed, nil
+}
+
+// apply stores the level the metrics reach and tells the employee when it moved.
+func (l *ContributorLevels) apply(ctx context.Context, metrics models.ContributorMetrics) (string, error) {
+	level := l.LevelFor(metrics)
+	if level == metrics.Level {
+		return level, nil
+	}
+
+	profileIDs, changed, err := l.repo.SetContributorLevel(ctx, metrics.EmployeeID, level)
+	if err != nil {
+		l.log.Error().Err(err).Int("employeeID", metrics.EmployeeID).Str("level", level).Msg("SetContributorLevel failed")
+		return metrics.Level, err
+	}
+	if !changed {
+		return level, nil
+	}
+	for _, profileID := range profileIDs {
+		l.embeds.Invalidate(profileID)
+	}
+
+	l.log.Info().Int("employeeID", metrics.EmployeeID).Str("from", metrics.Level).Str("to", level).Msg("contributor level changed")
+	title := "You reached a new contributor level"
+	if levelRank(level) < levelRank(metrics.Level) {
+		title = "Your contributor level changed"
+	}
+	l.notifications.Notify(ctx, RecipientEmployee, metrics.EmployeeID, "level_changed", title,
+		map[string]interface{}{"level": level, "previous_level": metrics.Level, "privileges": l.Privileges(level)})
+	return level, nil
+}
+
+func levelRank(level string) int {
+	switch level {
+	case models.LevelExpert:
+		return 2
+	case models.LevelTrusted:
+		return 1
+	}
+	return 0
+}
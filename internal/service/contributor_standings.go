@@ -0,0 +1,74 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"githup.ahmedramadan.4cashier/internal/models"
+	"githup.ahmedramadan.4cashier/internal/repos"
+)
+
+// contributorStandingsTTL is how long the ranking of all contributors is kept
+// before it is computed again.
+const contributorStandingsTTL = 10 * time.Minute
+
+// =================================================================
+// 📊 ترتيب المساهمين المئوي (يُحسب لكل الموظفين مرة ويُخزن مؤقتاً)
+// =================================================================
+
+// contributorStandings keeps the all-time standing of every employee in
+// memory. Ranking needs a window over every employee, so it runs once per TTL
+// instead of on every stats request.
+type contributorStandings struct {
+	repo repos.HRRepository
+
+	mu         sync.Mutex
+	byEmployee map[int]models.ContributorStanding
+	expires    time.Time
+}
+
+func newContributorStandings(repo repos.HRRepository) *contributorStandings {
+	return &contributorStandings{repo: repo}
+}
+
+// get returns the standing of an employee. Employees who joined after the last
+// ranking have not contributed anything that counts yet.
+func (c *contributorStandings) get(ctx context.Context, employeeID int) (models.ContributorStanding, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.byEmployee == nil || time.Now().After(c.expires) {
+		standings, err := c.repo.GetContributorStandings(ctx)
+		if err != nil {
+			return models.ContributorStanding{}, err
+		}
+		c.byEmployee = make(map[int]models.ContributorStanding, len(standings))
+		for _, standing := range standings {
+			c.byEmployee[standing.EmployeeID] = standing
+		}
+		c.expires = time.Now().Add(contributorStandingsTTL)
+	}
+
+	standing, ok := c.byEmployee[employeeID]
+	if !ok {
+		standing = models.ContributorStanding{EmployeeID: employeeID}
+	}
+	return standing, nil
+}
+
+// contributorRank labels a contributor by the share of employees ranked at or
+// above them: "Top N%" within the top half, Contributor below, Newbie without
+// any contribution.
+func contributorRank(score float64, topPercent float64) string {
+	switch {
+	case score <= 0:
+		return "Newbie"
+	case topPercent <= 50:
+		return fmt.Sprintf("Top %d%%", int(math.Ceil(topPercent)))
+	default:
+		return "Contributor"
+	}
+}
@@ -0,0 +1,203 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+	"githup.ahmedramadan.4cashier/internal/bootstrap"
+	"githup.ahmedramadan.4cashier/internal/models"
+	"githup.ahmedramadan.4cashier/internal/openbadges"
+	"githup.ahmedramadan.4cashier/internal/repos"
+	"githup.ahmedramadan.4cashier/internal/widget"
+)
+
+var ErrInvalidEmbedOptions = errors.New("invalid widget options")
+
+// Formats of the embeddable widget.
+const (
+	EmbedSVG  = "svg"
+	EmbedHTML = "html"
+)
+
+// RenderedWidget is a rendered widget with its ETag.
+type RenderedWidget struct {
+	Body        []byte
+	ETag        string
+	ContentType string
+}
+
+type embedEntry struct {
+	widget  *RenderedWidget
+	expires time.Time
+}
+
+// =================================================================
+// 🪧 الودجت القابل للتضمين (تقييم الـ HR في صفحات التوظيف)
+// =================================================================
+
+// EmbedService renders the embeddable rating widgets and keeps them in memory
+// until the profile changes (Invalidate) or the TTL passes.
+type EmbedService struct {
+	log    zerolog.Logger
+	repo   repos.HRRepository
+	config bootstrap.EmbedConfig
+	urls   openbadges.URLs
+
+	mu    sync.Mutex
+	cache map[int]map[string]embedEntry // profile id -> format:options
+}
+
+// NewEmbedService creates a new instance of EmbedService.
+func NewEmbedService(log zerolog.Logger, repo repos.HRRepository, config bootstrap.EmbedConfig) *EmbedService {
+	return &EmbedService{
+		log:    log.With().Str("layer", "service").Str("component", "EmbedService").Logger(),
+		repo:   repo,
+		config: config,
+		urls:   openbadges.URLs{Base: config.BaseURL},
+		cache:  make(map[int]map[string]embedEntry),
+	}
+}
+
+// MaxAge is how long browsers may use a widget before revalidating it.
+func (s *EmbedService) MaxAge() int {
+	return s.config.MaxAge
+}
+
+// Render returns the widget of a profile in the given format, from the cache
+// when it is still fresh.
+func (s *EmbedService) Render(ctx context.Context, profileID int, format string, options widget.Options) (*RenderedWidget, error) {
+	options, err := options.Normalize()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidEmbedOptions, err)
+	}
+	key := format + ":" + options.Key()
+
+	if cached := s.cached(profileID, key); cached != nil {
+		return cached, nil
+	}
+
+	profile, err := s.widgetProfile(ctx, profileID, options.Lang)
+	if err != nil {
+		return nil, err
+	}
+
+	rendered := &RenderedWidget{}
+	switch format {
+	case EmbedSVG:
+		rendered.Body, err = widget.SVG(*profile, options)
+		rendered.ContentType = "image/svg+xml; charset=utf-8"
+	default:
+		rendered.Body, err = widget.HTML(*profile, options)
+		rendered.ContentType = "text/html; charset=utf-8"
+	}
+	if err != nil {
+		s.log.Error().Err(err).Int("profileID", profileID).Str("format", format).Msg("widget render failed")
+		return nil, err
+	}
+	sum := sha256.Sum256(rendered.Body)
+	rendered.ETag = `"` + hex.EncodeToString(sum[:12]) + `"`
+
+	s.store(profileID, key, rendered)
+	return rendered, nil
+}
+
+// Invalidate drops the cached widgets of a profile. It is safe on a nil
+// service, for tools that run without the HTTP server.
+func (s *EmbedService) Invalidate(profileID int) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	delete(s.cache, profileID)
+	s.mu.Unlock()
+}
+
+func (s *EmbedService) cached(profileID int, key string) *RenderedWidget {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entry, ok := s.cache[profileID][key]
+	if !ok || time.Now().After(entry.expires) {
+		return nil
+	}
+	return entry.widget
+}
+
+func (s *EmbedService) store(profileID int, key string, rendered *RenderedWidget) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.cache[profileID] == nil {
+		s.cache[profileID] = make(map[string]embedEntry)
+	}
+	s.cache[profileID][key] = embedEntry{widget: rendered, expires: time.Now().Add(s.config.CacheTTL)}
+}
+
+// widgetProfile loads what the widget shows; the top badge is the active badge
+// of the highest tier.
+func (s *EmbedService) widgetProfile(ctx context.Context, profileID int, lang string) (*widget.Profile, error) {
+	profile, err := s.repo.GetHRProfileByID(ctx, profileID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &widget.Profile{RatesCount: profile.TotalRatesCount, ProfileURL: s.urls.Profile(profile.ID)}
+	if profile.Name != nil {
+		result.Name = *profile.Name
+	}
+	if profile.Rate != nil && profile.TotalRatesCount > 0 {
+		result.Rate = float64(*profile.Rate)
+	}
+
+	top, err := s.topBadge(ctx, profileID)
+	if err != nil {
+		return nil, err
+	}
+	if top != nil {
+		result.TopBadge, result.TopBadgeTier = top.NameEn, top.Tier
+		if lang == widget.LangAr {
+			result.TopBadge = top.NameAr
+		}
+	}
+	return result, nil
+}
+
+var tierRank = map[string]int{"bronze": 1, "silver": 2, "gold": 3, "platinum": 4}
+
+func (s *EmbedService) topBadge(ctx context.Context, profileID int) (*models.BadgeDefinition, error) {
+	badges, err := s.repo.GetProfileBadges(ctx, profileID, 0)
+	if err != nil {
+		s.log.Error().Err(err).Int("profileID", profileID).Msg("GetProfileBadges failed")
+		return nil, err
+	}
+	if len(badges) == 0 {
+		return nil, nil
+	}
+	definitions, err := s.repo.GetBadgeDefinitions(ctx, false)
+	if err != nil {
+		s.log.Error().Err(err).Msg("GetBadgeDefinitions failed")
+		return nil, err
+	}
+	byID := make(map[int]*models.BadgeDefinition, len(definitions))
+	for i := range definitions {
+		byID[definitions[i].ID] = &definitions[i]
+	}
+
+	var top *models.BadgeDefinition
+	now := time.Now()
+	for _, badge := range badges {
+		definition := byID[badge.DefinitionID]
+		if definition == nil || !badge.IsActive(now) {
+			continue
+		}
+		if top == nil || tierRank[definition.Tier] > tierRank[top.Tier] ||
+			(tierRank[definition.Tier] == tierRank[top.Tier] && definition.SortOrder < top.SortOrder) {
+			top = definition
+		}
+	}
+	return top, nil
+}
@@ -0,0 +1,264 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"githup.ahmedramadan.4cashier/internal/bootstrap"
+	"githup.ahmedramadan.4cashier/internal/models"
+	"githup.ahmedramadan.4cashier/internal/moderation"
+)
+
+// =================================================================
+// ⭐️ Fraud scoring: حسابات جديدة، دفعات تقييم، أجهزة مشتركة، حلقات إعجاب، نصوص مكررة
+// =================================================================
+
+// FraudSignal turns one measurement into risk points (0 when it looks normal).
+type FraudSignal struct {
+	Name   string
+	Points func(inputs *models.FraudInputs) (points int, value interface{})
+}
+
+// fraudSignals is the scoring table; the total is capped at 100.
+var fraudSignals = []FraudSignal{
+	{"new_account", func(in *models.FraudInputs) (int, interface{}) {
+		switch {
+		case in.AccountAgeHours < 24:
+			return 25, in.AccountAgeHours
+		case in.AccountAgeHours < 7*24:
+			return 15, in.AccountAgeHours
+		case in.AccountAgeHours < 30*24:
+			return 5, in.AccountAgeHours
+		}
+		return 0, in.AccountAgeHours
+	}},
+	{"target_burst", func(in *models.FraudInputs) (int, interface{}) {
+		switch {
+		case in.TargetBurst >= 5:
+			return 25, in.TargetBurst
+		case in.TargetBurst >= 3:
+			return 15, in.TargetBurst
+		}
+		return 0, in.TargetBurst
+	}},
+	{"author_velocity", func(in *models.FraudInputs) (int, interface{}) {
+		if in.AuthorVelocity >= 5 {
+			return 10, in.AuthorVelocity
+		}
+		return 0, in.AuthorVelocity
+	}},
+	{"shared_ip", func(in *models.FraudInputs) (int, interface{}) {
+		if in.SharedIPAccounts == 0 {
+			return 0, 0
+		}
+		return min(25, 10+5*in.SharedIPAccounts), in.SharedIPAccounts
+	}},
+	{"shared_device", func(in *models.FraudInputs) (int, interface{}) {
+		if in.SharedDeviceAccounts == 0 {
+			return 0, 0
+		}
+		return 30, in.SharedDeviceAccounts
+	}},
+	{"like_ring", func(in *models.FraudInputs) (int, interface{}) {
+		switch {
+		case in.ReciprocalLikes >= 3:
+			return 15, in.ReciprocalLikes
+		case in.ReciprocalLikes >= 1:
+			return 5, in.ReciprocalLikes
+		}
+		return 0, in.ReciprocalLikes
+	}},
+	{"near_duplicate", func(in *models.FraudInputs) (int, interface{}) {
+		value := fmt.Sprintf("%.2f", in.NearDuplicate)
+		switch {
+		case in.NearDuplicate >= 0.8:
+			return 25, value
+		case in.NearDuplicate >= 0.6:
+			return 10, value
+		}
+		return 0, value
+	}},
+}
+
+type fraudSignalResult struct {
+	Signal string      `json:"signal"`
+	Value  interface{} `json:"value"`
+	Points int         `json:"points"`
+}
+
+// scoreFraud sums the points of every signal; only signals that fired are kept.
+func scoreFraud(inputs *models.FraudInputs) (int, []fraudSignalResult) {
+	score := 0
+	fired := []fraudSignalResult{}
+	for _, signal := range fraudSignals {
+		points, value := signal.Points(inputs)
+		if points == 0 {
+			continue
+		}
+		score += points
+		fired = append(fired, fraudSignalResult{Signal: signal.Name, Value: value, Points: points})
+	}
+	return min(score, 100), fired
+}
+
+// shingles are the word pairs of a text, used for Jaccard similarity.
+func shingles(text string) map[string]bool {
+	tokens := moderation.Tokens(text)
+	set := map[string]bool{}
+	if len(tokens) == 1 {
+		set[tokens[0]] = true
+	}
+	for i := 0; i+1 < len(tokens); i++ {
+		set[tokens[i]+" "+tokens[i+1]] = true
+	}
+	return set
+}
+
+// maxSimilarity is the highest Jaccard similarity between text and the corpus.
+func maxSimilarity(text string, corpus []string) float64 {
+	base := shingles(text)
+	if len(base) == 0 {
+		return 0
+	}
+
+	best := 0.0
+	for _, other := range corpus {
+		candidate := shingles(other)
+		shared := 0
+		for shingle := range candidate {
+			if base[shingle] {
+				shared++
+			}
+		}
+		union := len(base) + len(candidate) - shared
+		if union > 0 && float64(shared)/float64(union) > best {
+			best = float64(shared) / float64(union)
+		}
+	}
+	return best
+}
+
+// assessRisk scores a rate from its measured signals and the texts it may have
+// copied. Rates at or above the threshold are flagged.
+func (s *HRService) assessRisk(inputs *models.FraudInputs, text string, corpus []string) (int, models.JSONB, bool, error) {
+	inputs.NearDuplicate = maxSimilarity(text, corpus)
+	score, fired := scoreFraud(inputs)
+	signals, err := json.Marshal(fired)
+	if err != nil {
+		return 0, nil, false, fmt.Errorf("failed to encode fraud signals: %w", err)
+	}
+	return score, signals, score >= s.fraud.RiskThreshold, nil
+}
+
+// ScoreRate computes and stores the fraud score of a saved rate. Flagged rates
+// are left out of the profile averages until a moderator clears them.
+func (s *HRService) ScoreRate(ctx context.Context, rate *models.Rate) (int, error) {
+	inputs, err := s.repo.GetFraudInputs(ctx, rate.ID)
+	if err != nil {
+		return 0, err
+	}
+	corpus, err := s.repo.GetSimilarityCorpus(ctx, rate.HRProfileID, rate.EmployeeID, 200)
+	if err != nil {
+		return 0, err
+	}
+
+	score, signals, flagged, err := s.assessRisk(inputs, rate.ReviewText, corpus)
+	if err != nil {
+		return 0, err
+	}
+	if err := s.repo.SetRateRisk(ctx, rate.ID, score, signals, flagged); err != nil {
+		return 0, err
+	}
+	// تغيّر العلامة يغيّر متوسط الملف، فالودجت المخزن قد يكون قديماً
+	s.embeds.Invalidate(rate.HRProfileID)
+	if flagged {
+		s.audit.Record(ctx, nil, "rate.risk_flagged", "rate", rate.ID, map[string]interface{}{"risk_score": score})
+	}
+	return score, nil
+}
+
+// scoreNewRate scores a rate before it is inserted, so a risky rate is saved
+// already flagged and never counts in the averages. Scoring never blocks a
+// review: on failure the rate is saved unscored and the rescore job scores it.
+func (s *HRService) scoreNewRate(ctx context.Context, rate *models.Rate) {
+	inputs, err := s.repo.GetNewRateFraudInputs(ctx, rate)
+	if err != nil {
+		s.log.Error().Err(err).Int("employeeID", rate.EmployeeID).Msg("GetNewRateFraudInputs failed")
+		return
+	}
+	corpus, err := s.repo.GetSimilarityCorpus(ctx, rate.HRProfileID, rate.EmployeeID, 200)
+	if err != nil {
+		s.log.Error().Err(err).Int("hrProfileID", rate.HRProfileID).Msg("GetSimilarityCorpus failed")
+		return
+	}
+
+	score, signals, flagged, err := s.assessRisk(inputs, rate.ReviewText, corpus)
+	if err != nil {
+		s.log.Error().Err(err).Int("employeeID", rate.EmployeeID).Msg("assessRisk failed")
+		return
+	}
+	rate.RiskScore, rate.RiskSignals, rate.RiskFlagged = &score, signals, flagged
+}
+
+// newRateSaved records the audit entry of a rate that was inserted flagged.
+func (s *HRService) newRateSaved(ctx context.Context, rate *models.Rate) {
+	if rate.RiskFlagged && rate.RiskScore != nil {
+		s.audit.Record(ctx, nil, "rate.risk_flagged", "rate", rate.ID, map[string]interface{}{"risk_score": *rate.RiskScore})
+	}
+}
+
+// scoreRateQuietly scores a rate after it was saved; failures are logged,
+// scoring never blocks a review.
+func (s *HRService) scoreRateQuietly(ctx context.Context, rate *models.Rate) {
+	if _, err := s.ScoreRate(ctx, rate); err != nil {
+		s.log.Error().Err(err).Int("rateID", rate.ID).Msg("ScoreRate failed")
+	}
+}
+
+// RescoreRecentRates rescores the rates of the last days: the first rates of a
+// burst look harmless until the rest of the burst arrives. A rate that fails is
+// logged and skipped; it returns how many rates were rescored.
+func (s *HRService) RescoreRecentRates(ctx context.Context) (int, error) {
+	ids, err := s.repo.GetRecentRateIDs(ctx, s.fraud.RescoreDays)
+	if err != nil {
+		return 0, err
+	}
+
+	scored := 0
+	for _, id := range ids {
+		rate, err := s.repo.GetRate(ctx, id)
+		if err == nil {
+			_, err = s.ScoreRate(ctx, rate)
+		}
+		if err != nil {
+			s.log.Error().Err(err).Int("rateID", id).Msg("rescoring rate failed")
+			continue
+		}
+		scored++
+	}
+	if scored < len(ids) {
+		s.log.Warn().Int("failed", len(ids)-scored).Int("rates", len(ids)).Msg("some rates were not rescored")
+	}
+	return scored, nil
+}
+
+func (s *HRService) GetRiskyRates(ctx context.Context, pagination bootstrap.Pagination) ([]models.Rate, error) {
+	return s.repo.GetRiskyRates(ctx, pagination)
+}
+
+// ReviewRateRisk is the moderator decision on a flagged rate: clearing it lets
+// it count again, otherwise it is rejected.
+func (s *HRService) ReviewRateRisk(ctx context.Context, moderatorID int, rateID int, clear bool, note *string) (*models.Rate, error) {
+	if !clear {
+		return s.DecideRate(ctx, moderatorID, rateID, false, note)
+	}
+
+	rate, err := s.repo.ReviewRateRisk(ctx, rateID, moderatorID)
+	if err != nil {
+		return nil, fmt.Errorf("service failed to clear risk of rate %d: %w", rateID, err)
+	}
+	s.embeds.Invalidate(rate.HRProfileID)
+	s.audit.Record(ctx, &moderatorID, "rate.risk_cleared", "rate", rateID, map[string]interface{}{"note": note})
+	return rate, nil
+}
@@ -0,0 +1,264 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog"
+	"githup.ahmedramadan.4cashier/internal/bootstrap"
+	"githup.ahmedramadan.4cashier/internal/models"
+	"githup.ahmedramadan.4cashier/internal/repos"
+)
+
+var ErrInvalidLeaderboard = errors.New("invalid leaderboard query")
+
+const (
+	defaultLeaderboardSize = 20
+	maxLeaderboardSize     = 100
+	defaultSnapshotsListed = 30
+)
+
+// leaderboardScopes lists the scopes of each board, in snapshot order.
+var leaderboardScopes = map[string][]string{
+	models.BoardContributors: {models.ScopeOverall, models.ScopeCity, models.ScopeJobField},
+	models.BoardHRs:          {models.ScopeOverall, models.ScopeCompany, models.ScopeJobPosition},
+}
+
+// LeaderboardParams are the query parameters of a leaderboard request. Month
+// is YYYY-MM and implies the monthly period; a monthly board without a month
+// is the current one.
+type LeaderboardParams struct {
+	Board  string
+	Scope  string
+	Value  string
+	Period string
+	Month  string
+	Limit  int
+}
+
+// =================================================================
+// 🏆 لوحات الصدارة ولقطاتها الدورية
+// =================================================================
+
+// LeaderboardService ranks contributors and HRs live and keeps periodic
+// snapshots so past rankings stay viewable.
+type LeaderboardService struct {
+	log    zerolog.Logger
+	repo   repos.HRRepository
+	config bootstrap.LeaderboardConfig
+}
+
+// NewLeaderboardService creates a new instance of LeaderboardService.
+func NewLeaderboardService(log zerolog.Logger, repo repos.HRRepository, config bootstrap.LeaderboardConfig) *LeaderboardService {
+	return &LeaderboardService{
+		log:    log.With().Str("layer", "service").Str("component", "LeaderboardService").Logger(),
+		repo:   repo,
+		config: config,
+	}
+}
+
+// GetLeaderboard ranks a board live.
+func (s *LeaderboardService) GetLeaderboard(ctx context.Context, params LeaderboardParams) (*models.Leaderboard, error) {
+	query, err := leaderboardQuery(params, time.Now(), defaultLeaderboardSize)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := s.repo.GetLeaderboard(ctx, query)
+	if err != nil {
+		s.log.Error().Err(err).Str("board", query.Board).Str("scope", query.Scope).Msg("GetLeaderboard failed")
+		return nil, err
+	}
+	return &models.Leaderboard{
+		Board:       query.Board,
+		Scope:       query.Scope,
+		Period:      query.Period,
+		PeriodStart: query.Month,
+		Entries:     entries,
+	}, nil
+}
+
+// GetLeaderboardAt returns the board as it was on a date (YYYY-MM-DD), from
+// the latest snapshot taken on or before it.
+func (s *LeaderboardService) GetLeaderboardAt(ctx context.Context, params LeaderboardParams, at string) (*models.Leaderboard, error) {
+	day, err := time.Parse(time.DateOnly, at)
+	if err != nil {
+		return nil, fmt.Errorf("%w: at must be YYYY-MM-DD", ErrInvalidLeaderboard)
+	}
+	// الشهر الافتراضي للوحة الشهرية هو شهر التاريخ المطلوب لا الشهر الحالي
+	query, err := leaderboardQuery(params, day, defaultLeaderboardSize)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot, err := s.repo.GetLeaderboardSnapshotAt(ctx, query, day)
+	if err != nil {
+		if !errors.Is(err, repos.ErrSnapshotNotFound) {
+			s.log.Error().Err(err).Str("board", query.Board).Msg("GetLeaderboardSnapshotAt failed")
+		}
+		return nil, err
+	}
+	entries, err := s.repo.GetLeaderboardSnapshotEntries(ctx, snapshot.ID, query.Value, query.Limit)
+	if err != nil {
+		s.log.Error().Err(err).Int64("snapshotID", snapshot.ID).Msg("GetLeaderboardSnapshotEntries failed")
+		return nil, err
+	}
+	return &models.Leaderboard{
+		Board:       snapshot.Board,
+		Scope:       snapshot.Scope,
+		Period:      snapshot.Period,
+		PeriodStart: snapshot.PeriodStart,
+		SnapshotID:  &snapshot.ID,
+		TakenAt:     &snapshot.TakenAt,
+		Entries:     entries,
+	}, nil
+}
+
+// ListSnapshots lists the snapshots kept for a board, newest first.
+func (s *LeaderboardService) ListSnapshots(ctx context.Context, params LeaderboardParams) ([]models.LeaderboardSnapshot, error) {
+	query, err := leaderboardQuery(params, time.Now(), defaultSnapshotsListed)
+	if err != nil {
+		return nil, err
+	}
+	snapshots, err := s.repo.GetLeaderboardSnapshots(ctx, query)
+	if err != nil {
+		s.log.Error().Err(err).Str("board", query.Board).Msg("GetLeaderboardSnapshots failed")
+		return nil, err
+	}
+	return snapshots, nil
+}
+
+// snapshotJob is one board to snapshot, as of takenOn.
+type snapshotJob struct {
+	query   models.LeaderboardQuery
+	takenOn time.Time
+}
+
+// TakeSnapshots snapshots every board and scope, all-time and for the current
+// month. The previous month is snapshotted once more as of its last day until
+// a snapshot covers its end, so the final monthly ranking is kept.
+func (s *LeaderboardService) TakeSnapshots(ctx context.Context, now time.Time) ([]models.LeaderboardSnapshot, error) {
+	now = now.UTC()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	month := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	previous := month.AddDate(0, -1, 0)
+	previousEnd := month.AddDate(0, 0, -1)
+
+	taken := []models.LeaderboardSnapshot{}
+	for _, board := range []string{models.BoardContributors, models.BoardHRs} {
+		for _, scope := range leaderboardScopes[board] {
+			jobs := []snapshotJob{
+				{models.LeaderboardQuery{Board: board, Scope: scope, Period: models.PeriodAll}, today},
+				{models.LeaderboardQuery{Board: board, Scope: scope, Period: models.PeriodMonth, Month: &month}, today},
+			}
+
+			final := models.LeaderboardQuery{Board: board, Scope: scope, Period: models.PeriodMonth, Month: &previous}
+			last, err := s.repo.GetLeaderboardSnapshotAt(ctx, final, today)
+			if err != nil && !errors.Is(err, repos.ErrSnapshotNotFound) {
+				s.log.Error().Err(err).Str("board", board).Str("scope", scope).Msg("GetLeaderboardSnapshotAt failed")
+				return taken, err
+			}
+			if last == nil || last.TakenOn.Before(previousEnd) {
+				jobs = append(jobs, snapshotJob{final, previousEnd})
+			}
+
+			for _, q := range jobs {
+				q.query.Limit = s.config.SnapshotSize
+				snapshot, err := s.repo.SaveLeaderboardSnapshot(ctx, q.query, q.takenOn)
+				if err != nil {
+					s.log.Error().Err(err).Str("board", board).Str("scope", scope).Str("period", q.query.Period).Msg("SaveLeaderboardSnapshot failed")
+					return taken, err
+				}
+				taken = append(taken, *snapshot)
+			}
+		}
+	}
+
+	s.log.Info().Int("snapshots", len(taken)).Msg("leaderboard snapshots taken")
+	return taken, nil
+}
+
+// StartSnapshots takes snapshots now and then every SnapshotInterval until
+// ctx is cancelled.
+func (s *LeaderboardService) StartSnapshots(ctx context.Context) {
+	if s.config.SnapshotInterval <= 0 {
+		s.log.Info().Msg("leaderboard snapshots disabled")
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(s.config.SnapshotInterval)
+		defer ticker.Stop()
+		for {
+			// الأخطاء مسجلة بالفعل، وتعاد المحاولة في الدورة التالية
+			_, _ = s.TakeSnapshots(ctx, time.Now())
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// leaderboardQuery validates the parameters and fills the defaults. now picks
+// the month of a monthly board without one.
+func leaderboardQuery(params LeaderboardParams, now time.Time, defaultLimit int) (models.LeaderboardQuery, error) {
+	query := models.LeaderboardQuery{
+		Board:  params.Board,
+		Scope:  strings.ToLower(strings.TrimSpace(params.Scope)),
+		Value:  strings.TrimSpace(params.Value),
+		Period: strings.ToLower(strings.TrimSpace(params.Period)),
+		Limit:  params.Limit,
+	}
+
+	scopes, ok := leaderboardScopes[query.Board]
+	if !ok {
+		return query, fmt.Errorf("%w: unknown board %q (contributors, hrs)", ErrInvalidLeaderboard, query.Board)
+	}
+	if query.Scope == "" {
+		query.Scope = models.ScopeOverall
+	}
+	if !slices.Contains(scopes, query.Scope) {
+		return query, fmt.Errorf("%w: unknown scope %q for %s (%s)", ErrInvalidLeaderboard, query.Scope, query.Board, strings.Join(scopes, ", "))
+	}
+	if query.Scope == models.ScopeOverall && query.Value != "" {
+		return query, fmt.Errorf("%w: the overall scope takes no value", ErrInvalidLeaderboard)
+	}
+
+	if query.Period == "" {
+		query.Period = models.PeriodAll
+		if params.Month != "" {
+			query.Period = models.PeriodMonth
+		}
+	}
+	switch query.Period {
+	case models.PeriodAll:
+		if params.Month != "" {
+			return query, fmt.Errorf("%w: month needs the monthly period", ErrInvalidLeaderboard)
+		}
+	case models.PeriodMonth:
+		month := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+		if params.Month != "" {
+			parsed, err := time.Parse("2006-01", params.Month)
+			if err != nil {
+				return query, fmt.Errorf("%w: month must be YYYY-MM", ErrInvalidLeaderboard)
+			}
+			month = parsed
+		}
+		query.Month = &month
+	default:
+		return query, fmt.Errorf("%w: unknown period %q (all, month)", ErrInvalidLeaderboard, query.Period)
+	}
+
+	switch {
+	case query.Limit <= 0:
+		query.Limit = defaultLimit
+	case query.Limit > maxLeaderboardSize:
+		query.Limit = maxLeaderboardSize
+	}
+	return query, nil
+}
@@ -0,0 +1,94 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog"
+	"githup.ahmedramadan.4cashier/internal/bootstrap"
+)
+
+// Mailer sends transactional emails (verification codes...).
+type Mailer interface {
+	Send(ctx context.Context, to string, subject string, body string) error
+}
+
+// NewMailer returns the mailer selected by MAIL_DRIVER.
+func NewMailer(log zerolog.Logger, config bootstrap.MailConfig) (Mailer, error) {
+	switch config.Driver {
+	case bootstrap.MailDriverSMTP:
+		if config.Host == "" || config.From == "" {
+			return nil, errors.New("the smtp mail driver needs SMTP_HOST and MAIL_FROM")
+		}
+		return NewSMTPMailer(config), nil
+	case bootstrap.MailDriverLog:
+		log.Warn().Msg("MAIL_DRIVER=log: emails are not delivered")
+		return NewLogMailer(log), nil
+	}
+	return nil, fmt.Errorf("unknown MAIL_DRIVER %q", config.Driver)
+}
+
+// SMTPMailer delivers emails through an SMTP server, upgrading to TLS when
+// the server offers STARTTLS.
+type SMTPMailer struct {
+	addr string
+	auth smtp.Auth
+	from string
+}
+
+func NewSMTPMailer(config bootstrap.MailConfig) *SMTPMailer {
+	mailer := &SMTPMailer{
+		addr: net.JoinHostPort(config.Host, strconv.Itoa(config.Port)),
+		from: config.From,
+	}
+	if config.Username != "" {
+		mailer.auth = smtp.PlainAuth("", config.Username, config.Password, config.Host)
+	}
+	return mailer
+}
+
+func (m *SMTPMailer) Send(ctx context.Context, to string, subject string, body string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	// CR/LF in a header would let the caller add headers or recipients
+	if strings.ContainsAny(to, "\r\n") || strings.ContainsAny(subject, "\r\n") {
+		return errors.New("email recipient and subject must be a single line")
+	}
+
+	var msg strings.Builder
+	fmt.Fprintf(&msg, "From: %s\r\n", m.from)
+	fmt.Fprintf(&msg, "To: %s\r\n", to)
+	fmt.Fprintf(&msg, "Subject: %s\r\n", subject)
+	fmt.Fprintf(&msg, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/plain; charset=UTF-8\r\n\r\n")
+	msg.WriteString(strings.ReplaceAll(body, "\n", "\r\n"))
+
+	if err := smtp.SendMail(m.addr, m.auth, m.from, []string{to}, []byte(msg.String())); err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+	return nil
+}
+
+// LogMailer logs that an email would have been sent, without delivering it.
+// The body is never logged since it carries secrets such as verification
+// codes.
+type LogMailer struct {
+	log zerolog.Logger
+}
+
+func NewLogMailer(log zerolog.Logger) *LogMailer {
+	return &LogMailer{log: log.With().Str("component", "LogMailer").Logger()}
+}
+
+func (m *LogMailer) Send(ctx context.Context, to string, subject string, body string) error {
+	m.log.Info().Str("to", to).Str("subject", subject).Msg("email not delivered (log mail driver)")
+	return nil
+}
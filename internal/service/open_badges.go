@@ -0,0 +1,221 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"os"
+
+	"github.com/rs/zerolog"
+	"githup.ahmedramadan.4cashier/internal/bootstrap"
+	"githup.ahmedramadan.4cashier/internal/models"
+	"githup.ahmedramadan.4cashier/internal/openbadges"
+	"githup.ahmedramadan.4cashier/internal/repos"
+)
+
+var (
+	ErrSigningDisabled  = errors.New("signed badge assertions are not configured")
+	ErrAssertionRevoked = errors.New("badge assertion has been revoked")
+)
+
+// =================================================================
+// 🎖️ Open Badges 2.0 (عرض الشارات خارج المنصة والتحقق منها)
+// =================================================================
+
+// OpenBadgeService publishes awarded badges as Open Badges 2.0 assertions.
+type OpenBadgeService struct {
+	log    zerolog.Logger
+	repo   repos.HRRepository
+	config bootstrap.OpenBadgesConfig
+	urls   openbadges.URLs
+	signer *openbadges.Signer // nil when no signing key is configured
+}
+
+// NewOpenBadgeService creates a new instance of OpenBadgeService. A missing or
+// invalid signing key only disables signed assertions.
+func NewOpenBadgeService(log zerolog.Logger, repo repos.HRRepository, config bootstrap.OpenBadgesConfig) *OpenBadgeService {
+	s := &OpenBadgeService{
+		log:    log.With().Str("layer", "service").Str("component", "OpenBadgeService").Logger(),
+		repo:   repo,
+		config: config,
+		urls:   openbadges.URLs{Base: config.BaseURL},
+	}
+
+	if config.SigningKeyFile != "" {
+		pemBytes, err := os.ReadFile(config.SigningKeyFile)
+		if err == nil {
+			s.signer, err = openbadges.ParseSigner(pemBytes)
+		}
+		if err != nil {
+			s.log.Error().Err(err).Str("file", config.SigningKeyFile).Msg("signed badge assertions disabled")
+		}
+	}
+	return s
+}
+
+func (s *OpenBadgeService) Issuer() *openbadges.Issuer {
+	issuer := &openbadges.Issuer{
+		Context:        openbadges.Context,
+		Type:           "Issuer",
+		ID:             s.urls.Issuer(),
+		Name:           s.config.IssuerName,
+		URL:            s.config.IssuerURL,
+		Email:          s.config.IssuerEmail,
+		RevocationList: s.urls.RevocationList(),
+	}
+	if s.signer != nil {
+		issuer.PublicKey = s.urls.PublicKey()
+	}
+	return issuer
+}
+
+func (s *OpenBadgeService) PublicKey() (*openbadges.CryptographicKey, error) {
+	if s.signer == nil {
+		return nil, ErrSigningDisabled
+	}
+	pemKey, err := s.signer.PublicKeyPEM()
+	if err != nil {
+		return nil, err
+	}
+	return &openbadges.CryptographicKey{
+		Context:      openbadges.Context,
+		Type:         "CryptographicKey",
+		ID:           s.urls.PublicKey(),
+		Owner:        s.urls.Issuer(),
+		PublicKeyPem: pemKey,
+	}, nil
+}
+
+// GetBadgeClass returns a catalog badge. Deactivated definitions are still
+// served, assertions awarded before keep pointing at them.
+func (s *OpenBadgeService) GetBadgeClass(ctx context.Context, definitionID int) (*openbadges.BadgeClass, error) {
+	definition, err := s.repo.GetBadgeDefinition(ctx, definitionID)
+	if err != nil {
+		return nil, err
+	}
+
+	description := definition.NameEn
+	if definition.DescriptionEn != nil && *definition.DescriptionEn != "" {
+		description = *definition.DescriptionEn
+	}
+	narrative := description
+	if definition.Rule != nil {
+		narrative += "\n\nRule: " + *definition.Rule
+	}
+
+	return &openbadges.BadgeClass{
+		Context:     openbadges.Context,
+		Type:        "BadgeClass",
+		ID:          s.urls.BadgeClass(definition.ID),
+		Name:        definition.NameEn,
+		Description: description,
+		Image:       s.urls.Image(definition.Icon),
+		Criteria:    openbadges.Criteria{Narrative: narrative},
+		Issuer:      s.urls.Issuer(),
+		Tags:        []string{definition.Category, definition.Tier},
+	}, nil
+}
+
+// GetAssertion returns the hosted assertion of an awarded badge. Revoked
+// badges are returned with Revoked set.
+func (s *OpenBadgeService) GetAssertion(ctx context.Context, badgeID int) (*openbadges.Assertion, error) {
+	badge, err := s.repo.GetBadge(ctx, badgeID)
+	if err != nil {
+		return nil, err
+	}
+	profile, err := s.repo.GetHRProfileByID(ctx, badge.HRProfileID)
+	if err != nil {
+		s.log.Error().Err(err).Int("badgeID", badgeID).Msg("GetHRProfileByID failed")
+		return nil, err
+	}
+	return s.assertion(badge, profile, openbadges.Verification{Type: openbadges.VerificationHosted}), nil
+}
+
+// SignAssertion returns the assertion of an active badge as a JWS.
+func (s *OpenBadgeService) SignAssertion(ctx context.Context, badgeID int) (string, error) {
+	if s.signer == nil {
+		return "", ErrSigningDisabled
+	}
+
+	assertion, err := s.GetAssertion(ctx, badgeID)
+	if err != nil {
+		return "", err
+	}
+	if assertion.Revoked {
+		return "", ErrAssertionRevoked
+	}
+	assertion.Verification = openbadges.Verification{Type: openbadges.VerificationSigned, Creator: s.urls.PublicKey()}
+
+	token, err := s.signer.Sign(assertion)
+	if err != nil {
+		s.log.Error().Err(err).Int("badgeID", badgeID).Msg("SignAssertion failed")
+		return "", err
+	}
+	return token, nil
+}
+
+func (s *OpenBadgeService) GetRevocationList(ctx context.Context) (*openbadges.RevocationList, error) {
+	badges, err := s.repo.GetRevokedBadges(ctx)
+	if err != nil {
+		s.log.Error().Err(err).Msg("GetRevokedBadges failed")
+		return nil, err
+	}
+
+	list := &openbadges.RevocationList{
+		Context:           openbadges.Context,
+		Type:              "RevocationList",
+		ID:                s.urls.RevocationList(),
+		Issuer:            s.urls.Issuer(),
+		RevokedAssertions: make([]openbadges.RevokedAssertion, 0, len(badges)),
+	}
+	for i := range badges {
+		reason, _ := revocationReason(&badges[i])
+		list.RevokedAssertions = append(list.RevokedAssertions, openbadges.RevokedAssertion{
+			ID:               s.urls.Assertion(badges[i].ID),
+			RevocationReason: reason,
+		})
+	}
+	return list, nil
+}
+
+func (s *OpenBadgeService) assertion(badge *models.Badge, profile *models.HRProfile, verification openbadges.Verification) *openbadges.Assertion {
+	assertion := &openbadges.Assertion{
+		Context:      openbadges.Context,
+		Type:         "Assertion",
+		ID:           s.urls.Assertion(badge.ID),
+		Badge:        s.urls.BadgeClass(badge.DefinitionID),
+		IssuedOn:     openbadges.Timestamp(badge.CreatedAt),
+		Verification: verification,
+		Evidence:     []openbadges.Evidence{{ID: s.urls.Profile(profile.ID)}},
+	}
+
+	// البريد لا يُنشر إلا مجزأً؛ البروفايل بلا بريد يُعرَّف برابطه
+	if profile.Email != nil && *profile.Email != "" {
+		assertion.Recipient = openbadges.EmailIdentity(*profile.Email, badge.RecipientSalt)
+	} else {
+		assertion.Recipient = openbadges.URLIdentity(s.urls.Profile(profile.ID))
+	}
+	if badge.ExpiresAt != nil {
+		assertion.Expires = openbadges.Timestamp(*badge.ExpiresAt)
+	}
+	if badge.AwardNote != nil {
+		assertion.Evidence[0].Narrative = *badge.AwardNote
+	}
+	assertion.RevocationReason, assertion.Revoked = revocationReason(badge)
+	return assertion
+}
+
+// revocationReason reports whether a badge is revoked in Open Badges terms and
+// why. Expiry is not a revocation.
+func revocationReason(badge *models.Badge) (string, bool) {
+	switch {
+	case badge.HiddenAt != nil:
+		return "Removed by moderation", true
+	case badge.RevokeKind == nil || *badge.RevokeKind == models.BadgeRevokedExpired:
+		return "", false
+	case badge.RevokeReason != nil && *badge.RevokeReason != "":
+		return *badge.RevokeReason, true
+	case *badge.RevokeKind == models.BadgeRevokedCriteria:
+		return "The badge criteria are no longer met", true
+	}
+	return "Revoked by the issuer", true
+}
@@ -0,0 +1,70 @@
+package service
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+
+	"githup.ahmedramadan.4cashier/internal/models"
+)
+
+// =================================================================
+// ⭐️ إخفاء هوية كاتب التقييم المجهول (is_anonymous)
+// =================================================================
+
+// pseudonymSalt keeps anonymous aliases from being recomputed by outsiders.
+var pseudonymSalt = os.Getenv("ANONYMOUS_ALIAS_SALT")
+
+// canSeeReviewer reports whether viewer may see who wrote a rate: only the
+// author and moderators can.
+func canSeeReviewer(viewer models.Viewer, authorID int) bool {
+	if viewer.IsModerator() {
+		return true
+	}
+	return viewer.Role == "employee" && viewer.UserID > 0 && viewer.UserID == authorID
+}
+
+// anonymousAlias is a stable pseudonym per rate. It is derived from the rate,
+// not the author, so two anonymous rates by the same employee cannot be linked.
+func anonymousAlias(rateID int) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s:rate:%d", pseudonymSalt, rateID)))
+	return "Anonymous #" + hex.EncodeToString(sum[:])[:6]
+}
+
+// shapeRate strips the reviewer identity of an anonymous rate, and the
+// moderation reasons of any rate, for viewers that are not allowed to see them.
+// The sentiment contradiction flag is a moderation hint and only moderators see it.
+func shapeRate(viewer models.Viewer, rate *models.Rate) {
+	if rate == nil {
+		return
+	}
+	if !viewer.IsModerator() {
+		rate.SentimentContradiction = false
+	}
+	if canSeeReviewer(viewer, rate.EmployeeID) {
+		return
+	}
+	rate.ModerationReasons = nil
+	if rate.IsAnonymous {
+		rate.EmployeeID = 0
+	}
+}
+
+// shapeRatesWithDetails applies shapeRate to a GetRates page and replaces the
+// reviewer name and image with a pseudonym.
+func shapeRatesWithDetails(viewer models.Viewer, rates []models.RateWithDetails) {
+	for i := range rates {
+		rate := &rates[i]
+		if !viewer.IsModerator() {
+			rate.SentimentContradiction = false
+		}
+		if !rate.IsAnonymous || canSeeReviewer(viewer, rate.EmployeeID) {
+			continue
+		}
+		alias := anonymousAlias(rate.ID)
+		rate.EmployeeID = 0
+		rate.EmployeeName = &alias
+		rate.EmployeeImage = nil
+	}
+}
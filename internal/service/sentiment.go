@@ -0,0 +1,83 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"githup.ahmedramadan.4cashier/internal/bootstrap"
+	"githup.ahmedramadan.4cashier/internal/models"
+	"githup.ahmedramadan.4cashier/internal/sentiment"
+)
+
+// =================================================================
+// 💬 تحليل المشاعر والجوانب في نص التقييم
+// =================================================================
+
+var ErrUnknownAspect = errors.New("unknown aspect")
+
+// sentimentBatchSize is how many rates the backfill loads per query.
+const sentimentBatchSize = 500
+
+// AnalyzeRate runs the sentiment analyzer on a rate's text and stores the result.
+func (s *HRService) AnalyzeRate(ctx context.Context, rate *models.Rate) (sentiment.Analysis, error) {
+	analysis := s.sentiment.Analyze(rate.ReviewText)
+
+	aspects := make([]models.RateAspect, 0, len(analysis.Aspects))
+	for _, aspect := range analysis.Aspects {
+		aspects = append(aspects, models.RateAspect{RateID: rate.ID, Aspect: aspect.Aspect, Score: aspect.Score, Mentions: aspect.Mentions})
+	}
+
+	err := s.repo.SetRateSentiment(ctx, rate.ID, analysis.Score, analysis.Label,
+		analysis.Contradicts(rate.RateValue), sentiment.Version, aspects)
+	if err != nil {
+		return analysis, fmt.Errorf("service failed to store sentiment of rate %d: %w", rate.ID, err)
+	}
+	return analysis, nil
+}
+
+// analyzeRateQuietly analyzes a rate after it was saved; failures are logged,
+// the backfill job picks the rate up later.
+func (s *HRService) analyzeRateQuietly(ctx context.Context, rate *models.Rate) {
+	if _, err := s.AnalyzeRate(ctx, rate); err != nil {
+		s.log.Error().Err(err).Int("rateID", rate.ID).Msg("AnalyzeRate failed")
+	}
+}
+
+// ReanalyzeRates is the backfill: it analyzes every live rate not yet analyzed
+// with the current lexicon version, or every live rate when all is set.
+func (s *HRService) ReanalyzeRates(ctx context.Context, all bool) (int, error) {
+	processed, afterID := 0, 0
+	for {
+		rates, err := s.repo.GetRatesForSentiment(ctx, sentiment.Version, all, afterID, sentimentBatchSize)
+		if err != nil {
+			return processed, err
+		}
+		if len(rates) == 0 {
+			return processed, nil
+		}
+
+		for i := range rates {
+			if _, err := s.AnalyzeRate(ctx, &rates[i]); err != nil {
+				return processed, err
+			}
+			processed++
+		}
+		afterID = rates[len(rates)-1].ID
+	}
+}
+
+// GetAspects lists the aspects the analyzer detects.
+func (s *HRService) GetAspects() []string {
+	return s.sentiment.Aspects()
+}
+
+// GetAspectLeaders ranks profiles by what their reviews say about an aspect,
+// e.g. the HRs most praised for communication.
+func (s *HRService) GetAspectLeaders(ctx context.Context, aspect string, criticized bool, minRates int, pagination bootstrap.Pagination) ([]models.AspectLeader, error) {
+	if !slices.Contains(s.sentiment.Aspects(), aspect) {
+		return nil, ErrUnknownAspect
+	}
+	return s.repo.GetAspectLeaders(ctx, aspect, criticized, minRates, pagination)
+}
@@ -0,0 +1,203 @@
+// Package widget renders the embeddable rating of an HR profile, as an SVG
+// badge for <img> tags and as a small HTML page for iframes, in a light or
+// dark theme, in Arabic or English and in three sizes.
+//
+// The templates ship inside the binary; the caller loads the profile and
+// caches the output.
+package widget
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"html/template"
+	"math"
+	"strconv"
+	"unicode/utf8"
+)
+
+//go:embed templates/*
+var templateFS embed.FS
+
+var templates = template.Must(template.ParseFS(templateFS, "templates/*"))
+
+// Options values.
+const (
+	ThemeLight = "light"
+	ThemeDark  = "dark"
+
+	LangEn = "en"
+	LangAr = "ar"
+
+	SizeSmall  = "small"
+	SizeMedium = "medium"
+	SizeLarge  = "large"
+)
+
+// Options choose how the widget looks. Empty fields take the defaults
+// (light, en, medium).
+type Options struct {
+	Theme string
+	Lang  string
+	Size  string
+}
+
+// Normalize fills the defaults and rejects unknown values.
+func (o Options) Normalize() (Options, error) {
+	if o.Theme == "" {
+		o.Theme = ThemeLight
+	}
+	if o.Lang == "" {
+		o.Lang = LangEn
+	}
+	if o.Size == "" {
+		o.Size = SizeMedium
+	}
+
+	switch {
+	case o.Theme != ThemeLight && o.Theme != ThemeDark:
+		return o, fmt.Errorf("unknown theme %q (light, dark)", o.Theme)
+	case o.Lang != LangEn && o.Lang != LangAr:
+		return o, fmt.Errorf("unknown language %q (ar, en)", o.Lang)
+	case scales[o.Size] == 0:
+		return o, fmt.Errorf("unknown size %q (small, medium, large)", o.Size)
+	}
+	return o, nil
+}
+
+// Key identifies the options in a cache.
+func (o Options) Key() string {
+	return o.Theme + ":" + o.Lang + ":" + o.Size
+}
+
+// Profile is what the widget shows.
+type Profile struct {
+	Name         string
+	Rate         float64 // 0 when the profile has no counted rates
+	RatesCount   int
+	TopBadge     string // localized name of the best active badge, empty for none
+	TopBadgeTier string
+	ProfileURL   string
+}
+
+// Base size of the SVG; sizes scale it.
+const (
+	svgWidth  = 320
+	svgHeight = 84
+)
+
+var scales = map[string]float64{SizeSmall: 0.75, SizeMedium: 1, SizeLarge: 1.25}
+
+type palette struct {
+	Background, Border, Text, Muted, Star, EmptyStar string
+}
+
+var palettes = map[string]palette{
+	ThemeLight: {Background: "#ffffff", Border: "#e5e7eb", Text: "#111827", Muted: "#6b7280", Star: "#f59e0b", EmptyStar: "#e5e7eb"},
+	ThemeDark:  {Background: "#111827", Border: "#374151", Text: "#f9fafb", Muted: "#9ca3af", Star: "#fbbf24", EmptyStar: "#374151"},
+}
+
+var tierColors = map[string]string{"bronze": "#b45309", "silver": "#64748b", "gold": "#ca8a04", "platinum": "#0e7490"}
+
+var labels = map[string]map[string]string{
+	LangEn: {"rates": "reviews", "rate": "review", "none": "No reviews yet", "view": "View profile", "title": "Rating of"},
+	LangAr: {"rates": "تقييم", "rate": "تقييم", "none": "لا توجد تقييمات بعد", "view": "عرض الملف", "title": "تقييم"},
+}
+
+// view is the data of both templates.
+type view struct {
+	Profile
+	Options
+	Palette   palette
+	BadgeFill string
+	RTL       bool
+	Width     int
+	Height    int
+	Scale     float64
+	TextX     int   // x of the name and the badge in the SVG
+	SummaryX  int   // x of the summary, next to the stars
+	Stars     []int // x of each star
+	ClipX     float64
+	ClipWidth float64
+	Summary   string // "4.6 · 128 reviews"
+	View      string
+	Title     string
+	Percent   float64 // filled share of the stars, for the HTML widget
+}
+
+const (
+	padding   = 16
+	starSize  = 16
+	starGap   = 2
+	starsSpan = 5*starSize + 4*starGap
+	maxName   = 30
+)
+
+func newView(profile Profile, options Options) view {
+	rate := math.Max(0, math.Min(5, profile.Rate))
+	text := labels[options.Lang]
+
+	v := view{
+		Profile:   profile,
+		Options:   options,
+		Palette:   palettes[options.Theme],
+		BadgeFill: tierColors[profile.TopBadgeTier],
+		RTL:       options.Lang == LangAr,
+		Scale:     scales[options.Size],
+		View:      text["view"],
+		Title:     text["title"] + " " + profile.Name,
+		Percent:   math.Round(rate / 5 * 100),
+	}
+	v.Width = int(math.Round(svgWidth * v.Scale))
+	v.Height = int(math.Round(svgHeight * v.Scale))
+	if v.BadgeFill == "" {
+		v.BadgeFill = v.Palette.Muted
+	}
+	if utf8.RuneCountInString(v.Name) > maxName {
+		v.Name = string([]rune(v.Name)[:maxName-1]) + "…"
+	}
+
+	switch {
+	case profile.RatesCount == 0:
+		v.Summary = text["none"]
+	case profile.RatesCount == 1:
+		v.Summary = strconv.FormatFloat(rate, 'f', 1, 64) + " · 1 " + text["rate"]
+	default:
+		v.Summary = strconv.FormatFloat(rate, 'f', 1, 64) + " · " + strconv.Itoa(profile.RatesCount) + " " + text["rates"]
+	}
+
+	// النجوم تمتلئ من اليمين في العربية
+	start := padding
+	v.TextX, v.SummaryX = padding, padding+starsSpan+8
+	if v.RTL {
+		start = svgWidth - padding - starsSpan
+		v.TextX, v.SummaryX = svgWidth-padding, start-8
+	}
+	for i := 0; i < 5; i++ {
+		v.Stars = append(v.Stars, start+i*(starSize+starGap))
+	}
+	v.ClipWidth = math.Round(rate/5*starsSpan*10) / 10
+	v.ClipX = float64(start)
+	if v.RTL {
+		v.ClipX = float64(start+starsSpan) - v.ClipWidth
+	}
+	return v
+}
+
+// SVG renders the badge for an <img> tag.
+func SVG(profile Profile, options Options) ([]byte, error) {
+	return render("badge.svg", profile, options)
+}
+
+// HTML renders the widget page for an iframe.
+func HTML(profile Profile, options Options) ([]byte, error) {
+	return render("widget.html", profile, options)
+}
+
+func render(name string, profile Profile, options Options) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := templates.ExecuteTemplate(&buf, name, newView(profile, options)); err != nil {
+		return nil, fmt.Errorf("failed to render %s: %w", name, err)
+	}
+	return buf.Bytes(), nil
+}
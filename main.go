@@ -1,6 +1,11 @@
 package main
 
 import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+
 	myfiber "githup.ahmedramadan.4cashier/internal/api"
 	// "githup.ahmedramadan.4cashier/internal/bootstrap"
 	mylogger "githup.ahmedramadan.4cashier/internal/mylogger"
@@ -22,9 +27,13 @@ func main() {
 	appLogger := mylogger.ConfigureLogger(logConfig)
 	appLogger.Info().Msg("Application starting...")
 
-	app := myfiber.SetupHandlers(appLogger)
+	// يُلغى السياق عند الإيقاف فتتوقف الخدمات الخلفية والخادم معاً
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	app := myfiber.SetupHandlers(ctx, appLogger)
 
-	myfiber.SetupFiber(app.Handlers, app.DB)
+	myfiber.SetupFiber(ctx, app.Handlers, app.DB)
 }
 
 // bootstrap
@@ -0,0 +1,44 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- تتبع التعديلات على التقييمات
+ALTER TABLE rates
+    ADD COLUMN updated_at TIMESTAMP WITH TIME ZONE DEFAULT now(),
+    ADD COLUMN edited_at TIMESTAMP WITH TIME ZONE,
+    ADD COLUMN deleted_at TIMESTAMP WITH TIME ZONE;
+
+-- جدول Rate Revisions (النسخ السابقة لكل تقييم)
+CREATE TABLE rate_revisions (
+    id SERIAL PRIMARY KEY,
+    rate_id INT NOT NULL REFERENCES rates(id) ON DELETE CASCADE,
+    action VARCHAR(20) NOT NULL CHECK (action IN ('edit', 'delete')),
+    review_text TEXT NOT NULL,
+    rate_value REAL NOT NULL,
+    rating_context TEXT,
+    is_anonymous BOOLEAN DEFAULT FALSE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT now()
+);
+CREATE INDEX idx_rate_revisions_rate_id ON rate_revisions(rate_id);
+
+-- إعادة حساب المتوسط من المصدر (يصلح القيم الخاطئة عندما كان rate = NULL)
+UPDATE hr_profiles p
+SET rate = s.avg_rate,
+    total_rates_count = s.cnt
+FROM (
+    SELECT hp.id,
+           (SELECT AVG(r.rate_value) FROM rates r WHERE r.hr_profile_id = hp.id) AS avg_rate,
+           (SELECT COUNT(*) FROM rates r WHERE r.hr_profile_id = hp.id) AS cnt
+    FROM hr_profiles hp
+) s
+WHERE p.id = s.id;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS rate_revisions;
+ALTER TABLE rates
+    DROP COLUMN IF EXISTS deleted_at,
+    DROP COLUMN IF EXISTS edited_at,
+    DROP COLUMN IF EXISTS updated_at;
+-- +goose StatementEnd
@@ -0,0 +1,42 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- التقييم السابق يصبح superseded عند السماح بتقييم جديد بعد فترة الانتظار
+ALTER TABLE rates ADD COLUMN superseded_at TIMESTAMP WITH TIME ZONE;
+
+-- الإبقاء على أحدث تقييم فعال فقط لكل (موظف، HR) قبل إنشاء القيد
+UPDATE rates r
+SET superseded_at = NOW()
+FROM (
+    SELECT id,
+           ROW_NUMBER() OVER (PARTITION BY hr_profile_id, employee_id ORDER BY created_at DESC, id DESC) AS rn
+    FROM rates
+    WHERE deleted_at IS NULL
+) d
+WHERE r.id = d.id AND d.rn > 1;
+
+-- تقييم فعال واحد فقط لكل علاقة (employee, hr_profile)
+CREATE UNIQUE INDEX ux_rates_active_hr_employee
+    ON rates(hr_profile_id, employee_id)
+    WHERE deleted_at IS NULL AND superseded_at IS NULL;
+
+UPDATE hr_profiles p
+SET rate = s.avg_rate,
+    total_rates_count = s.cnt
+FROM (
+    SELECT hp.id,
+           (SELECT AVG(r.rate_value) FROM rates r
+             WHERE r.hr_profile_id = hp.id AND r.deleted_at IS NULL AND r.superseded_at IS NULL) AS avg_rate,
+           (SELECT COUNT(*) FROM rates r
+             WHERE r.hr_profile_id = hp.id AND r.deleted_at IS NULL AND r.superseded_at IS NULL) AS cnt
+    FROM hr_profiles hp
+) s
+WHERE p.id = s.id;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS ux_rates_active_hr_employee;
+ALTER TABLE rates DROP COLUMN IF EXISTS superseded_at;
+-- +goose StatementEnd
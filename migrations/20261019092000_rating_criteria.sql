@@ -0,0 +1,42 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- جدول Rating Criteria (معايير التقييم القابلة للتعديل)
+CREATE TABLE rating_criteria (
+    id SERIAL PRIMARY KEY,
+    code VARCHAR(50) UNIQUE NOT NULL,
+    name_en VARCHAR(100) NOT NULL,
+    name_ar VARCHAR(100) NOT NULL,
+    is_active BOOLEAN DEFAULT TRUE,
+    sort_order INT DEFAULT 0,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT now(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT now()
+);
+
+INSERT INTO rating_criteria (code, name_en, name_ar, sort_order) VALUES
+    ('communication', 'Communication', 'التواصل', 1),
+    ('fairness', 'Fairness', 'العدالة', 2),
+    ('responsiveness', 'Responsiveness', 'سرعة الاستجابة', 3),
+    ('professionalism', 'Professionalism', 'الاحترافية', 4),
+    ('onboarding_support', 'Onboarding support', 'دعم التهيئة', 5);
+
+-- جدول Rate Scores (درجة كل معيار داخل التقييم)
+CREATE TABLE rate_scores (
+    rate_id INT NOT NULL REFERENCES rates(id) ON DELETE CASCADE,
+    criterion_id INT NOT NULL REFERENCES rating_criteria(id) ON DELETE RESTRICT,
+    score REAL NOT NULL CHECK (score >= 0 AND score <= 5),
+    PRIMARY KEY (rate_id, criterion_id)
+);
+CREATE INDEX idx_rate_scores_criterion_id ON rate_scores(criterion_id);
+
+-- النسخ السابقة تحتفظ بدرجات المعايير أيضاً
+ALTER TABLE rate_revisions ADD COLUMN scores JSONB;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE rate_revisions DROP COLUMN IF EXISTS scores;
+DROP TABLE IF EXISTS rate_scores;
+DROP TABLE IF EXISTS rating_criteria;
+-- +goose StatementEnd
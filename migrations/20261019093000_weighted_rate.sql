@@ -0,0 +1,15 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- درجة مرجّحة (Bayesian) تقاوم العينات الصغيرة والتلاعب
+-- تُملأ للبروفايلات الحالية عبر: go run ./cmd/maintenance recompute-scores
+ALTER TABLE hr_profiles ADD COLUMN weighted_rate REAL;
+CREATE INDEX idx_hr_profiles_weighted_rate ON hr_profiles(weighted_rate DESC NULLS LAST);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_hr_profiles_weighted_rate;
+ALTER TABLE hr_profiles DROP COLUMN IF EXISTS weighted_rate;
+-- +goose StatementEnd
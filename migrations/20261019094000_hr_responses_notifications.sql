@@ -0,0 +1,33 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- رد الـ HR على التقييم
+ALTER TABLE rates
+    ADD COLUMN hr_response_at TIMESTAMP WITH TIME ZONE,
+    ADD COLUMN hr_response_status VARCHAR(20) CHECK (hr_response_status IN ('published', 'held', 'rejected'));
+
+UPDATE rates SET hr_response_status = 'published', hr_response_at = created_at
+WHERE hr_response IS NOT NULL;
+
+-- جدول Notifications (تنبيهات الموظفين والـ HR)
+CREATE TABLE notifications (
+    id SERIAL PRIMARY KEY,
+    recipient_type VARCHAR(20) NOT NULL CHECK (recipient_type IN ('employee', 'hr')),
+    recipient_id INT NOT NULL,
+    kind VARCHAR(50) NOT NULL,
+    title TEXT NOT NULL,
+    payload JSONB,
+    read_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT now()
+);
+CREATE INDEX idx_notifications_recipient ON notifications(recipient_type, recipient_id, created_at DESC);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS notifications;
+ALTER TABLE rates
+    DROP COLUMN IF EXISTS hr_response_status,
+    DROP COLUMN IF EXISTS hr_response_at;
+-- +goose StatementEnd
@@ -0,0 +1,51 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- طريقة إثبات العمل لدى شركة الـ HR
+ALTER TABLE rates ADD COLUMN verification_method VARCHAR(20)
+    CHECK (verification_method IN ('work_email', 'document', 'invite_token'));
+CREATE INDEX idx_rates_verification_method ON rates(verification_method);
+
+-- جدول HR Invite Tokens (روابط دعوة يصدرها الـ HR لموظفيه)
+CREATE TABLE hr_invite_tokens (
+    id SERIAL PRIMARY KEY,
+    hr_profile_id INT NOT NULL REFERENCES hr_profiles(id) ON DELETE CASCADE,
+    token_hash VARCHAR(64) UNIQUE NOT NULL,
+    max_uses INT NOT NULL DEFAULT 1 CHECK (max_uses > 0),
+    used_count INT NOT NULL DEFAULT 0,
+    expires_at TIMESTAMP WITH TIME ZONE,
+    revoked_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT now()
+);
+CREATE INDEX idx_hr_invite_tokens_hr_profile_id ON hr_invite_tokens(hr_profile_id);
+
+-- جدول Rate Verifications (طلبات التوثيق لكل تقييم)
+CREATE TABLE rate_verifications (
+    id SERIAL PRIMARY KEY,
+    rate_id INT NOT NULL REFERENCES rates(id) ON DELETE CASCADE,
+    employee_id INT NOT NULL REFERENCES employees(id) ON DELETE CASCADE,
+    method VARCHAR(20) NOT NULL CHECK (method IN ('work_email', 'document', 'invite_token')),
+    status VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'verified', 'rejected')),
+    email VARCHAR(255),
+    code_hash VARCHAR(64),
+    attempts INT NOT NULL DEFAULT 0,
+    document_path TEXT,
+    invite_token_id INT REFERENCES hr_invite_tokens(id) ON DELETE SET NULL,
+    expires_at TIMESTAMP WITH TIME ZONE,
+    reviewed_by INT,
+    review_note TEXT,
+    reviewed_at TIMESTAMP WITH TIME ZONE,
+    verified_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT now()
+);
+CREATE INDEX idx_rate_verifications_rate_id ON rate_verifications(rate_id);
+CREATE INDEX idx_rate_verifications_pending ON rate_verifications(created_at) WHERE status = 'pending';
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS rate_verifications;
+DROP TABLE IF EXISTS hr_invite_tokens;
+ALTER TABLE rates DROP COLUMN IF EXISTS verification_method;
+-- +goose StatementEnd
@@ -0,0 +1,26 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- نتيجة فحص المحتوى قبل النشر: published | held | rejected
+ALTER TABLE rates ADD COLUMN status VARCHAR(20) NOT NULL DEFAULT 'published'
+    CHECK (status IN ('published', 'held', 'rejected'));
+ALTER TABLE rates ADD COLUMN moderation_reasons JSONB;
+ALTER TABLE rates ADD COLUMN moderated_by INT;
+ALTER TABLE rates ADD COLUMN moderated_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE rates ADD COLUMN hr_response_moderation_reasons JSONB;
+
+CREATE INDEX idx_rates_held ON rates(created_at) WHERE status = 'held' AND deleted_at IS NULL;
+CREATE INDEX idx_rates_response_held ON rates(hr_response_at) WHERE hr_response_status = 'held';
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_rates_response_held;
+DROP INDEX IF EXISTS idx_rates_held;
+ALTER TABLE rates DROP COLUMN IF EXISTS hr_response_moderation_reasons;
+ALTER TABLE rates DROP COLUMN IF EXISTS moderated_at;
+ALTER TABLE rates DROP COLUMN IF EXISTS moderated_by;
+ALTER TABLE rates DROP COLUMN IF EXISTS moderation_reasons;
+ALTER TABLE rates DROP COLUMN IF EXISTS status;
+-- +goose StatementEnd
@@ -0,0 +1,49 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- مصداقية المُبلّغ: ترتفع عند قبول بلاغاته وتنخفض عند رفضها، وتُستخدم كوزن للبلاغ
+ALTER TABLE employees ADD COLUMN report_credibility REAL NOT NULL DEFAULT 1.0;
+
+-- إخفاء شارة بعد قبول بلاغ عليها
+ALTER TABLE badges ADD COLUMN hidden_at TIMESTAMP WITH TIME ZONE;
+
+-- جدول Reports (بلاغات على التقييمات والشارات)
+CREATE TABLE reports (
+    id SERIAL PRIMARY KEY,
+    target_type VARCHAR(20) NOT NULL CHECK (target_type IN ('rate', 'badge')),
+    target_id INT NOT NULL,
+    reporter_id INT NOT NULL REFERENCES employees(id) ON DELETE CASCADE,
+    reason VARCHAR(30) NOT NULL,
+    details TEXT,
+    weight REAL NOT NULL DEFAULT 1.0,
+    status VARCHAR(20) NOT NULL DEFAULT 'open' CHECK (status IN ('open', 'upheld', 'dismissed')),
+    resolved_by INT,
+    resolution_note TEXT,
+    resolved_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT now()
+);
+CREATE UNIQUE INDEX ux_reports_target_reporter ON reports(target_type, target_id, reporter_id);
+CREATE INDEX idx_reports_open ON reports(created_at) WHERE status = 'open';
+
+-- سجل تدقيق كل إجراءات الإشراف (actor_id فارغ = إجراء آلي)
+CREATE TABLE moderation_audit_log (
+    id BIGSERIAL PRIMARY KEY,
+    actor_id INT,
+    action VARCHAR(50) NOT NULL,
+    target_type VARCHAR(20) NOT NULL,
+    target_id INT NOT NULL,
+    details JSONB,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT now()
+);
+CREATE INDEX idx_moderation_audit_log_target ON moderation_audit_log(target_type, target_id);
+CREATE INDEX idx_moderation_audit_log_created_at ON moderation_audit_log(created_at);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS moderation_audit_log;
+DROP TABLE IF EXISTS reports;
+ALTER TABLE badges DROP COLUMN IF EXISTS hidden_at;
+ALTER TABLE employees DROP COLUMN IF EXISTS report_credibility;
+-- +goose StatementEnd
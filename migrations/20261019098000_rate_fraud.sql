@@ -0,0 +1,35 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- بيانات الطلب لاكتشاف الحسابات المشتركة
+ALTER TABLE rates ADD COLUMN client_ip INET;
+ALTER TABLE rates ADD COLUMN device_fingerprint VARCHAR(128);
+
+-- درجة الاحتيال (0-100) وإشاراتها؛ التقييم المُعلَّم لا يدخل في المتوسطات حتى يراجعه مشرف
+ALTER TABLE rates ADD COLUMN risk_score INT;
+ALTER TABLE rates ADD COLUMN risk_signals JSONB;
+ALTER TABLE rates ADD COLUMN risk_flagged BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE rates ADD COLUMN risk_reviewed_by INT;
+ALTER TABLE rates ADD COLUMN risk_reviewed_at TIMESTAMP WITH TIME ZONE;
+
+CREATE INDEX idx_rates_client_ip ON rates(hr_profile_id, client_ip);
+CREATE INDEX idx_rates_device_fingerprint ON rates(hr_profile_id, device_fingerprint);
+CREATE INDEX idx_rates_risk_flagged ON rates(risk_score DESC) WHERE risk_flagged;
+CREATE INDEX idx_rate_likes_employee_id ON rate_likes(employee_id);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_rate_likes_employee_id;
+DROP INDEX IF EXISTS idx_rates_risk_flagged;
+DROP INDEX IF EXISTS idx_rates_device_fingerprint;
+DROP INDEX IF EXISTS idx_rates_client_ip;
+ALTER TABLE rates DROP COLUMN IF EXISTS risk_reviewed_at;
+ALTER TABLE rates DROP COLUMN IF EXISTS risk_reviewed_by;
+ALTER TABLE rates DROP COLUMN IF EXISTS risk_flagged;
+ALTER TABLE rates DROP COLUMN IF EXISTS risk_signals;
+ALTER TABLE rates DROP COLUMN IF EXISTS risk_score;
+ALTER TABLE rates DROP COLUMN IF EXISTS device_fingerprint;
+ALTER TABLE rates DROP COLUMN IF EXISTS client_ip;
+-- +goose StatementEnd
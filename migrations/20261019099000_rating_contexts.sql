@@ -0,0 +1,56 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- جدول Rating Contexts (نوع التعامل مع الـ HR: مقابلة، تهيئة، تقييم أداء...)
+CREATE TABLE rating_contexts (
+    id SERIAL PRIMARY KEY,
+    code VARCHAR(50) UNIQUE NOT NULL,
+    name_en VARCHAR(100) NOT NULL,
+    name_ar VARCHAR(100) NOT NULL,
+    description TEXT,
+    is_active BOOLEAN DEFAULT TRUE,
+    sort_order INT DEFAULT 0,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT now(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT now()
+);
+
+INSERT INTO rating_contexts (code, name_en, name_ar, sort_order) VALUES
+    ('recruitment_interview', 'Recruitment interview', 'مقابلة توظيف', 1),
+    ('onboarding', 'Onboarding', 'التهيئة', 2),
+    ('performance_review', 'Performance review', 'تقييم الأداء', 3),
+    ('payroll_issue', 'Payroll issue', 'مشكلة في الرواتب', 4),
+    ('exit_process', 'Exit process', 'إجراءات إنهاء الخدمة', 5),
+    ('other', 'Other', 'أخرى', 99);
+
+-- النص الحر القديم يُحفظ كما هو كملاحظة أولاً، ثم يصبح السياق رمزاً من الكتالوج
+-- (النص المطابق لرمز بعد توحيده، وإلا other)
+ALTER TABLE rates ADD COLUMN rating_context_note TEXT;
+UPDATE rates SET rating_context_note = rating_context
+    WHERE rating_context IS NOT NULL;
+UPDATE rates r
+SET rating_context = COALESCE(
+        (SELECT c.code FROM rating_contexts c
+          WHERE c.code = lower(replace(trim(r.rating_context), ' ', '_'))),
+        'other')
+WHERE r.rating_context IS NOT NULL;
+ALTER TABLE rates ALTER COLUMN rating_context TYPE VARCHAR(50);
+ALTER TABLE rates ADD CONSTRAINT fk_rates_rating_context
+    FOREIGN KEY (rating_context) REFERENCES rating_contexts(code) ON UPDATE CASCADE;
+CREATE INDEX idx_rates_rating_context ON rates(hr_profile_id, rating_context);
+
+-- معايير خاصة بسياقات معينة (فارغ = كل السياقات)
+ALTER TABLE rating_criteria ADD COLUMN context_codes TEXT[] NOT NULL DEFAULT '{}';
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE rating_criteria DROP COLUMN IF EXISTS context_codes;
+DROP INDEX IF EXISTS idx_rates_rating_context;
+ALTER TABLE rates DROP CONSTRAINT IF EXISTS fk_rates_rating_context;
+ALTER TABLE rates ALTER COLUMN rating_context TYPE TEXT;
+-- الملاحظة تحمل النص الحر الأصلي للتقييمات المنقولة
+UPDATE rates SET rating_context = rating_context_note WHERE rating_context_note IS NOT NULL;
+ALTER TABLE rates DROP COLUMN IF EXISTS rating_context_note;
+DROP TABLE IF EXISTS rating_contexts;
+-- +goose StatementEnd
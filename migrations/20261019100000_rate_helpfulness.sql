@@ -0,0 +1,39 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- الحد الأدنى لفترة ويلسون (95%) لنسبة الإعجاب: ترتيب عادل بين تقييم بـ 2/2 وآخر بـ 90/100
+CREATE OR REPLACE FUNCTION wilson_lower_bound(up INT, down INT) RETURNS REAL AS $$
+    SELECT CASE WHEN up + down = 0 THEN 0 ELSE (
+        (up::float8 / (up + down) + 1.9208 / (up + down)
+         - 1.96 * sqrt((up::float8 * down) / (up + down) + 0.9604) / (up + down))
+        / (1 + 3.8416 / (up + down))
+    )::real END
+$$ LANGUAGE SQL IMMUTABLE;
+
+ALTER TABLE rates ADD COLUMN dislikes_count INT NOT NULL DEFAULT 0;
+ALTER TABLE rates ADD COLUMN helpfulness REAL NOT NULL DEFAULT 0;
+
+UPDATE rates r SET
+    likes_count = v.likes,
+    dislikes_count = v.dislikes,
+    helpfulness = wilson_lower_bound(v.likes, v.dislikes)
+FROM (
+    SELECT rate_id,
+           COUNT(*) FILTER (WHERE is_like) AS likes,
+           COUNT(*) FILTER (WHERE NOT is_like) AS dislikes
+    FROM rate_likes
+    GROUP BY rate_id
+) v
+WHERE v.rate_id = r.id;
+
+CREATE INDEX idx_rates_helpfulness ON rates(hr_profile_id, helpfulness DESC);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_rates_helpfulness;
+ALTER TABLE rates DROP COLUMN IF EXISTS helpfulness;
+ALTER TABLE rates DROP COLUMN IF EXISTS dislikes_count;
+DROP FUNCTION IF EXISTS wilson_lower_bound(INT, INT);
+-- +goose StatementEnd
@@ -0,0 +1,17 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- ملخص التقييمات (التوزيع والاتجاه الشهري) يقرأ نفس التقييمات المحسوبة في hr_profiles.rate
+CREATE INDEX idx_rates_counted_timeline ON rates(hr_profile_id, created_at)
+    WHERE deleted_at IS NULL AND superseded_at IS NULL AND status = 'published' AND NOT risk_flagged;
+
+-- ترتيب الملف بين ملفات نفس المسمى الوظيفي
+CREATE INDEX idx_hr_profiles_position_rate ON hr_profiles(job_position, rate) WHERE rate IS NOT NULL;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_hr_profiles_position_rate;
+DROP INDEX IF EXISTS idx_rates_counted_timeline;
+-- +goose StatementEnd
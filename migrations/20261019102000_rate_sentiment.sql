@@ -0,0 +1,36 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- تحليل المشاعر لنص التقييم (محرك معجمي محلي، عربي وإنجليزي)؛ sentiment_version يحدد التقييمات التي تحتاج إعادة تحليل
+ALTER TABLE rates ADD COLUMN sentiment_score REAL;
+ALTER TABLE rates ADD COLUMN sentiment_label VARCHAR(10) CHECK (sentiment_label IN ('positive', 'negative', 'neutral'));
+ALTER TABLE rates ADD COLUMN sentiment_contradiction BOOLEAN NOT NULL DEFAULT FALSE; -- النجوم تخالف النص
+ALTER TABLE rates ADD COLUMN sentiment_version SMALLINT;
+ALTER TABLE rates ADD COLUMN sentiment_analyzed_at TIMESTAMP WITH TIME ZONE;
+
+-- الجوانب المذكورة في التقييم (الراتب، التواصل، الاحترام، التأخير) ومشاعر كل جانب
+CREATE TABLE rate_aspects (
+    rate_id INT NOT NULL REFERENCES rates(id) ON DELETE CASCADE,
+    aspect VARCHAR(50) NOT NULL,
+    score REAL NOT NULL CHECK (score >= -1 AND score <= 1),
+    mentions INT NOT NULL DEFAULT 1,
+    PRIMARY KEY (rate_id, aspect)
+);
+
+CREATE INDEX idx_rate_aspects_aspect ON rate_aspects(aspect, score);
+CREATE INDEX idx_rates_sentiment_label ON rates(hr_profile_id, sentiment_label);
+CREATE INDEX idx_rates_sentiment_contradiction ON rates(created_at DESC) WHERE sentiment_contradiction;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_rates_sentiment_contradiction;
+DROP INDEX IF EXISTS idx_rates_sentiment_label;
+DROP TABLE IF EXISTS rate_aspects;
+ALTER TABLE rates DROP COLUMN IF EXISTS sentiment_analyzed_at;
+ALTER TABLE rates DROP COLUMN IF EXISTS sentiment_version;
+ALTER TABLE rates DROP COLUMN IF EXISTS sentiment_contradiction;
+ALTER TABLE rates DROP COLUMN IF EXISTS sentiment_label;
+ALTER TABLE rates DROP COLUMN IF EXISTS sentiment_score;
+-- +goose StatementEnd
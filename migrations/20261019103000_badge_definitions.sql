@@ -0,0 +1,60 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- كتالوج الشارات: الاسم والوصف والأيقونة والمستوى لكل نوع شارة
+CREATE TABLE badge_definitions (
+    id SERIAL PRIMARY KEY,
+    code VARCHAR(50) NOT NULL,
+    name_en VARCHAR(100) NOT NULL,
+    name_ar VARCHAR(100) NOT NULL,
+    description_en TEXT,
+    description_ar TEXT,
+    icon VARCHAR(255),
+    tier VARCHAR(10) NOT NULL DEFAULT 'bronze' CHECK (tier IN ('bronze', 'silver', 'gold', 'platinum')),
+    category VARCHAR(50) NOT NULL DEFAULT 'general',
+    is_active BOOLEAN NOT NULL DEFAULT TRUE,
+    sort_order INT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT now(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT now(),
+    CONSTRAINT ux_badge_definitions_code UNIQUE (code)
+);
+
+INSERT INTO badge_definitions (code, name_en, name_ar, description_en, description_ar, icon, tier, category, sort_order) VALUES
+    ('top_rated_hr', 'Top Rated HR', 'الأعلى تقييماً',
+     'At least 50 rates with a weighted score of 4.5 or higher',
+     'خمسون تقييماً على الأقل بدرجة مرجحة 4.5 أو أعلى',
+     'top-rated', 'gold', 'rating', 1);
+
+-- الشارات القديمة كانت تحفظ اسم الشارة في job_position: كل اسم غير معروف يصبح تعريفاً مستقلاً
+INSERT INTO badge_definitions (code, name_en, name_ar, category, sort_order)
+SELECT DISTINCT 'legacy_' || substr(md5(COALESCE(job_position, '')), 1, 8),
+       COALESCE(NULLIF(job_position, ''), 'Legacy badge'), COALESCE(NULLIF(job_position, ''), 'شارة قديمة'),
+       'legacy', 100
+FROM badges
+WHERE COALESCE(job_position, '') <> 'Top Rated HR'
+ON CONFLICT (code) DO NOTHING;
+
+ALTER TABLE badges ADD COLUMN definition_id INT REFERENCES badge_definitions(id);
+UPDATE badges b SET definition_id = d.id
+FROM badge_definitions d
+WHERE d.code = CASE WHEN b.job_position = 'Top Rated HR' THEN 'top_rated_hr'
+                    ELSE 'legacy_' || substr(md5(COALESCE(b.job_position, '')), 1, 8) END;
+ALTER TABLE badges ALTER COLUMN definition_id SET NOT NULL;
+
+-- الوصف العام انتقل إلى الكتالوج؛ current_job_roles كان يحفظ تفاصيل المنح
+ALTER TABLE badges RENAME COLUMN current_job_roles TO award_note;
+ALTER TABLE badges DROP COLUMN job_position;
+
+CREATE INDEX idx_badges_profile_definition ON badges(hr_profile_id, definition_id);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_badges_profile_definition;
+ALTER TABLE badges ADD COLUMN job_position VARCHAR(255);
+UPDATE badges b SET job_position = d.name_en FROM badge_definitions d WHERE d.id = b.definition_id;
+ALTER TABLE badges RENAME COLUMN award_note TO current_job_roles;
+ALTER TABLE badges DROP COLUMN IF EXISTS definition_id;
+DROP TABLE IF EXISTS badge_definitions;
+-- +goose StatementEnd
@@ -0,0 +1,17 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- شرط منح الشارة كقاعدة تعريفية، مثال: total_rates_count >= 50 AND weighted_rate >= 4.5 AND verified
+-- بدون قاعدة = شارة تُمنح يدوياً فقط
+ALTER TABLE badge_definitions ADD COLUMN rule TEXT;
+
+-- قاعدة Top Rated HR التي كانت مكتوبة في الكود (TopRatedBadgeEvaluator)
+UPDATE badge_definitions SET rule = 'total_rates_count >= 50 AND weighted_rate >= 4.5'
+    WHERE code = 'top_rated_hr';
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE badge_definitions DROP COLUMN IF EXISTS rule;
+-- +goose StatementEnd
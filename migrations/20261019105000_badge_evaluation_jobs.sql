@@ -0,0 +1,48 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- شارة واحدة لكل (بروفايل، تعريف): إزالة التكرار القديم مع نقل الإعجابات للشارة الأقدم
+WITH ranked AS (
+    SELECT id, MIN(id) OVER (PARTITION BY hr_profile_id, definition_id) AS keep_id
+    FROM badges
+)
+UPDATE badge_likes l SET badge_id = ranked.keep_id
+FROM ranked
+WHERE l.badge_id = ranked.id AND ranked.id <> ranked.keep_id
+  AND NOT EXISTS (SELECT 1 FROM badge_likes k WHERE k.badge_id = ranked.keep_id AND k.employee_id = l.employee_id);
+
+DELETE FROM badges b
+USING badges keep
+WHERE keep.hr_profile_id = b.hr_profile_id AND keep.definition_id = b.definition_id AND keep.id < b.id;
+
+DROP INDEX IF EXISTS idx_badges_profile_definition;
+ALTER TABLE badges ADD CONSTRAINT ux_badges_profile_definition UNIQUE (hr_profile_id, definition_id);
+
+-- طابور تقييم الشارات في الخلفية
+-- hr_profile_id فارغ = إعادة تقييم كل البروفايلات (جدولة دورية أو تغيير قاعدة)
+CREATE TABLE badge_evaluation_jobs (
+    id BIGSERIAL PRIMARY KEY,
+    hr_profile_id INT REFERENCES hr_profiles(id) ON DELETE CASCADE,
+    trigger VARCHAR(30) NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'running', 'done', 'failed')),
+    profiles_total INT NOT NULL DEFAULT 0,
+    profiles_processed INT NOT NULL DEFAULT 0,
+    badges_awarded INT NOT NULL DEFAULT 0,
+    last_error TEXT,
+    started_at TIMESTAMP WITH TIME ZONE,
+    finished_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT now(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT now()
+);
+-- أحداث متتالية لنفس البروفايل تندمج في مهمة واحدة معلقة
+CREATE UNIQUE INDEX ux_badge_evaluation_jobs_pending ON badge_evaluation_jobs ((COALESCE(hr_profile_id, 0))) WHERE status = 'pending';
+CREATE INDEX idx_badge_evaluation_jobs_created_at ON badge_evaluation_jobs(created_at);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS badge_evaluation_jobs;
+ALTER TABLE badges DROP CONSTRAINT IF EXISTS ux_badges_profile_definition;
+CREATE INDEX idx_badges_profile_definition ON badges(hr_profile_id, definition_id);
+-- +goose StatementEnd
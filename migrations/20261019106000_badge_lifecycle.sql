@@ -0,0 +1,56 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- دورة حياة الشارة في الكتالوج:
+-- available_from/available_until: فترة المنح (شارات موسمية مثل "أفضل HR للربع الثالث 2026")
+-- validity_days: صلاحية الشارة بعد منحها، فارغ = دائمة
+-- revoke_grace_days: مهلة قبل السحب التلقائي عند توقف تحقق القاعدة، فارغ = لا سحب تلقائي
+ALTER TABLE badge_definitions
+    ADD COLUMN available_from TIMESTAMP WITH TIME ZONE,
+    ADD COLUMN available_until TIMESTAMP WITH TIME ZONE,
+    ADD COLUMN validity_days INT CHECK (validity_days > 0),
+    ADD COLUMN revoke_grace_days INT CHECK (revoke_grace_days >= 0),
+    ADD CONSTRAINT ck_badge_definitions_availability CHECK (available_until IS NULL OR available_from IS NULL OR available_until > available_from);
+
+-- الشارة المنتهية أو المسحوبة تبقى في السجل (revoked_at غير فارغ)
+ALTER TABLE badges
+    ADD COLUMN expires_at TIMESTAMP WITH TIME ZONE,
+    ADD COLUMN revoked_at TIMESTAMP WITH TIME ZONE,
+    ADD COLUMN revoked_by INT,
+    ADD COLUMN revoke_kind VARCHAR(20) CHECK (revoke_kind IN ('manual', 'criteria', 'expired')),
+    ADD COLUMN revoke_reason TEXT,
+    ADD COLUMN criteria_failing_since TIMESTAMP WITH TIME ZONE;
+
+-- شارة فعالة واحدة لكل (بروفايل، تعريف)؛ يمكن منحها مجدداً بعد سحبها أو انتهائها
+ALTER TABLE badges DROP CONSTRAINT ux_badges_profile_definition;
+CREATE UNIQUE INDEX ux_badges_active_profile_definition ON badges(hr_profile_id, definition_id) WHERE revoked_at IS NULL;
+CREATE INDEX idx_badges_expires_at ON badges(expires_at) WHERE revoked_at IS NULL AND expires_at IS NOT NULL;
+
+ALTER TABLE badge_evaluation_jobs ADD COLUMN badges_revoked INT NOT NULL DEFAULT 0;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE badge_evaluation_jobs DROP COLUMN IF EXISTS badges_revoked;
+
+DELETE FROM badges WHERE revoked_at IS NOT NULL;
+DROP INDEX IF EXISTS idx_badges_expires_at;
+DROP INDEX IF EXISTS ux_badges_active_profile_definition;
+ALTER TABLE badges ADD CONSTRAINT ux_badges_profile_definition UNIQUE (hr_profile_id, definition_id);
+
+ALTER TABLE badges
+    DROP COLUMN IF EXISTS expires_at,
+    DROP COLUMN IF EXISTS revoked_at,
+    DROP COLUMN IF EXISTS revoked_by,
+    DROP COLUMN IF EXISTS revoke_kind,
+    DROP COLUMN IF EXISTS revoke_reason,
+    DROP COLUMN IF EXISTS criteria_failing_since;
+
+ALTER TABLE badge_definitions
+    DROP CONSTRAINT IF EXISTS ck_badge_definitions_availability,
+    DROP COLUMN IF EXISTS available_from,
+    DROP COLUMN IF EXISTS available_until,
+    DROP COLUMN IF EXISTS validity_days,
+    DROP COLUMN IF EXISTS revoke_grace_days;
+-- +goose StatementEnd
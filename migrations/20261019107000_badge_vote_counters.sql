@@ -0,0 +1,31 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- عدادات الإعجاب/عدم الإعجاب على الشارة (تُحدَّث داخل نفس معاملة التصويت)
+ALTER TABLE badges
+    ADD COLUMN likes_count INT NOT NULL DEFAULT 0,
+    ADD COLUMN dislikes_count INT NOT NULL DEFAULT 0;
+
+UPDATE badges b
+SET likes_count = v.likes, dislikes_count = v.dislikes
+FROM (
+    SELECT badge_id,
+           COUNT(*) FILTER (WHERE is_like) AS likes,
+           COUNT(*) FILTER (WHERE NOT is_like) AS dislikes
+    FROM badge_likes
+    GROUP BY badge_id
+) v
+WHERE v.badge_id = b.id;
+
+-- آخر الشارات الممنوحة (الخلاصة العامة)
+CREATE INDEX idx_badges_recent ON badges(created_at DESC, id DESC) WHERE hidden_at IS NULL AND revoked_at IS NULL;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_badges_recent;
+ALTER TABLE badges
+    DROP COLUMN IF EXISTS likes_count,
+    DROP COLUMN IF EXISTS dislikes_count;
+-- +goose StatementEnd
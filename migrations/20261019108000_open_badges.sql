@@ -0,0 +1,17 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- ملح ثابت لكل شارة يُستخدم لتجزئة بريد المستلم في شهادة Open Badges
+-- (ثابت حتى تبقى الشهادة الموقعة والمستضافة متطابقتين)
+ALTER TABLE badges ADD COLUMN recipient_salt VARCHAR(32) NOT NULL DEFAULT md5(random()::text);
+
+-- قائمة السحب: الشارات المسحوبة يدوياً أو لتوقف تحقق القاعدة أو المخفية بالإشراف
+CREATE INDEX idx_badges_revoked ON badges(revoked_at) WHERE revoke_kind IN ('manual', 'criteria');
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_badges_revoked;
+ALTER TABLE badges DROP COLUMN IF EXISTS recipient_salt;
+-- +goose StatementEnd
@@ -0,0 +1,46 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- سجل النقاط: كل حركة نقاط بسببها ومصدرها، ومفتاح يمنع تسجيلها مرتين
+CREATE TABLE points_ledger (
+    id BIGSERIAL PRIMARY KEY,
+    employee_id INT NOT NULL REFERENCES employees(id) ON DELETE CASCADE,
+    delta INT NOT NULL CHECK (delta <> 0),
+    reason VARCHAR(50) NOT NULL,
+    source_type VARCHAR(30),
+    source_id INT,
+    idempotency_key VARCHAR(200) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    CONSTRAINT ux_points_ledger_idempotency_key UNIQUE (idempotency_key)
+);
+CREATE INDEX idx_points_ledger_employee ON points_ledger(employee_id, created_at DESC, id DESC);
+CREATE INDEX idx_points_ledger_source ON points_ledger(employee_id, reason, source_type, source_id);
+
+-- الرصيد مخزن على الموظف ويتحرك مع السجل في نفس المعاملة؛ active_points كان BOOLEAN ولم يعمل قط
+ALTER TABLE employees ADD COLUMN points_balance INT NOT NULL DEFAULT 0;
+ALTER TABLE employees DROP COLUMN active_points;
+
+-- نقاط الإعجابات القائمة (+1 للمُصوّت) والتقييمات المفيدة (+10 للكاتب عند 50 إعجاباً)
+INSERT INTO points_ledger (employee_id, delta, reason, source_type, source_id, idempotency_key)
+SELECT l.employee_id, 1, 'rate_vote', 'rate', l.rate_id, 'rate_vote:rate:' || l.rate_id || ':' || l.employee_id || ':1'
+FROM rate_likes l
+WHERE l.is_like;
+
+INSERT INTO points_ledger (employee_id, delta, reason, source_type, source_id, idempotency_key)
+SELECT r.employee_id, 10, 'helpful_rate', 'rate', r.id, 'helpful_rate:rate:' || r.id || ':' || r.employee_id || ':1'
+FROM rates r
+WHERE r.likes_count >= 50;
+
+UPDATE employees e
+SET points_balance = s.balance
+FROM (SELECT employee_id, SUM(delta) AS balance FROM points_ledger GROUP BY employee_id) s
+WHERE s.employee_id = e.id;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE employees ADD COLUMN active_points BOOLEAN DEFAULT FALSE;
+ALTER TABLE employees DROP COLUMN IF EXISTS points_balance;
+DROP TABLE IF EXISTS points_ledger;
+-- +goose StatementEnd
@@ -0,0 +1,44 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- لقطات دورية للوحات الصدارة حتى يبقى الترتيب التاريخي متاحاً
+-- board: contributors | hrs، scope: overall | city | job_field | company | job_position
+-- period_start: أول يوم في الشهر للوحات الشهرية، فارغ للترتيب الكلي
+CREATE TABLE leaderboard_snapshots (
+    id BIGSERIAL PRIMARY KEY,
+    board VARCHAR(20) NOT NULL CHECK (board IN ('contributors', 'hrs')),
+    scope VARCHAR(20) NOT NULL,
+    period VARCHAR(10) NOT NULL CHECK (period IN ('all', 'month')),
+    period_start DATE,
+    taken_on DATE NOT NULL,
+    taken_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    CONSTRAINT ux_leaderboard_snapshots UNIQUE NULLS NOT DISTINCT (board, scope, period, period_start, taken_on)
+);
+CREATE INDEX idx_leaderboard_snapshots_lookup ON leaderboard_snapshots(board, scope, period, period_start, taken_on DESC);
+
+-- أعلى N في كل قيمة نطاق (كل مدينة، كل شركة...)؛ scope_value فارغ للنطاق الكلي
+CREATE TABLE leaderboard_snapshot_entries (
+    snapshot_id BIGINT NOT NULL REFERENCES leaderboard_snapshots(id) ON DELETE CASCADE,
+    scope_value TEXT NOT NULL DEFAULT '',
+    rank INT NOT NULL,
+    subject_id INT NOT NULL,
+    name TEXT,
+    image TEXT,
+    score DOUBLE PRECISION NOT NULL,
+    percentile DOUBLE PRECISION NOT NULL,
+    top_percent DOUBLE PRECISION NOT NULL,
+    points INT,
+    reviews INT,
+    likes INT,
+    rates_count INT,
+    PRIMARY KEY (snapshot_id, scope_value, subject_id)
+);
+CREATE INDEX idx_leaderboard_snapshot_entries_rank ON leaderboard_snapshot_entries(snapshot_id, scope_value, rank);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS leaderboard_snapshot_entries;
+DROP TABLE IF EXISTS leaderboard_snapshots;
+-- +goose StatementEnd
@@ -0,0 +1,19 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- مستوى المساهم (newcomer → trusted → expert) يحسبه التطبيق من سجل النقاط وجودة
+-- المراجعات والتحقق، لأن العتبات قابلة للضبط؛ بعد الترحيل شغّل:
+--   go run ./cmd/maintenance recompute-levels
+ALTER TABLE employees
+    ADD COLUMN contributor_level VARCHAR(20) NOT NULL DEFAULT 'newcomer'
+        CHECK (contributor_level IN ('newcomer', 'trusted', 'expert')),
+    ADD COLUMN level_updated_at TIMESTAMP WITH TIME ZONE;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE employees
+    DROP COLUMN IF EXISTS level_updated_at,
+    DROP COLUMN IF EXISTS contributor_level;
+-- +goose StatementEnd
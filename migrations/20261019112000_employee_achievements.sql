@@ -0,0 +1,28 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- إنجازات الموظفين: كل إنجاز يُمنح مرة واحدة، ونقاطه تُسجل في سجل النقاط بنفس المعاملة.
+-- الإنجازات تُقيَّم عند الأحداث؛ لمنحها عن النشاط السابق شغّل:
+--   go run ./cmd/maintenance evaluate-achievements
+CREATE TABLE employee_achievements (
+    id SERIAL PRIMARY KEY,
+    employee_id INT NOT NULL REFERENCES employees(id) ON DELETE CASCADE,
+    code VARCHAR(50) NOT NULL,
+    points INT NOT NULL DEFAULT 0,
+    note TEXT,
+    awarded_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    CONSTRAINT ux_employee_achievements_employee_code UNIQUE (employee_id, code)
+);
+CREATE INDEX idx_employee_achievements_employee ON employee_achievements(employee_id, awarded_at DESC);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+UPDATE employees e
+SET points_balance = e.points_balance - s.total
+FROM (SELECT employee_id, SUM(delta) AS total FROM points_ledger WHERE reason = 'achievement' GROUP BY employee_id) s
+WHERE e.id = s.employee_id;
+DELETE FROM points_ledger WHERE reason = 'achievement';
+DROP TABLE IF EXISTS employee_achievements;
+-- +goose StatementEnd
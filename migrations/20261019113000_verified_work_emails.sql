@@ -0,0 +1,29 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- البريد الوظيفي الموثّق يخص حساب موظف واحد فقط
+CREATE TABLE verified_work_emails (
+    email VARCHAR(255) PRIMARY KEY,
+    employee_id INT NOT NULL REFERENCES employees(id) ON DELETE CASCADE,
+    verified_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+CREATE INDEX idx_verified_work_emails_employee ON verified_work_emails(employee_id);
+
+-- البريد الذي وثّقه أكثر من موظف يبقى لأول من وثّقه
+INSERT INTO verified_work_emails (email, employee_id, verified_at)
+SELECT DISTINCT ON (lower(email)) lower(email), employee_id, COALESCE(verified_at, created_at)
+FROM rate_verifications
+WHERE method = 'work_email' AND status = 'verified' AND email IS NOT NULL
+ORDER BY lower(email), COALESCE(verified_at, created_at), id;
+
+-- عدد الرموز المرسلة لكل تقييم يُحسب من هذا الفهرس
+CREATE INDEX idx_rate_verifications_email_codes ON rate_verifications(rate_id, created_at)
+    WHERE method = 'work_email';
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_rate_verifications_email_codes;
+DROP TABLE IF EXISTS verified_work_emails;
+-- +goose StatementEnd